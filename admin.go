@@ -0,0 +1,127 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// adminMock describes a registered mock in the admin API.
+type adminMock struct {
+	// The identifier used to register the mock.
+	Identifier string `json:"identifier"`
+	// The mock's human-readable name, if any.
+	Name string `json:"name,omitempty"`
+	// The position of the mock among those registered with the same identifier.
+	Index int `json:"index"`
+	// The Go type of the mock.
+	Type string `json:"type"`
+}
+
+// adminError is sent by the admin API when a request fails.
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// WithAdmin exposes a REST API at the specified path prefix (e.g., "/__admin"),
+// so the mock server may be managed remotely by tools not written in Go.
+//
+// The following endpoints are available:
+//
+//   - GET {prefix}/mocks: lists every registered mock;
+//   - POST {prefix}/mocks: registers a MockDefinition (or a list of them);
+//   - DELETE {prefix}/mocks: removes every mock registered with the identifier
+//     provided in the query string (e.g., "?identifier=ListFoos"),
+//     or every mock if no identifier is provided;
+//   - GET {prefix}/requests: lists every request received by the mock server;
+//   - DELETE {prefix}/requests: clears the list of received requests,
+//     including the unmatched ones;
+//   - GET {prefix}/stats: summarizes the requests handled by the mock server, as in Server.Stats.
+func WithAdmin(prefix string) ServerOptions {
+	return func(s *server) {
+		prefix = strings.TrimSuffix(prefix, "/")
+
+		s.mux.HandleFunc("GET "+prefix+"/mocks", s.adminListMocks)
+		s.mux.HandleFunc("POST "+prefix+"/mocks", s.adminRegisterMocks)
+		s.mux.HandleFunc("DELETE "+prefix+"/mocks", s.adminDeleteMocks)
+		s.mux.HandleFunc("GET "+prefix+"/requests", s.adminListRequests)
+		s.mux.HandleFunc("DELETE "+prefix+"/requests", s.adminDeleteRequests)
+		s.mux.HandleFunc("GET "+prefix+"/stats", s.adminStats)
+	}
+}
+
+// adminListMocks sends every registered mock, sorted by their identifier.
+func (s *server) adminListMocks(w http.ResponseWriter, r *http.Request) {
+	mocks := []adminMock{}
+
+	s.mu.RLock()
+	for id, registrations := range s.registrations {
+		for i, reg := range registrations {
+			mocks = append(mocks, adminMock{
+				Identifier: id,
+				Name:       reg.name,
+				Index:      i,
+				Type:       fmt.Sprintf("%T", reg.mock),
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(mocks, func(i, j int) bool {
+		return mocks[i].Identifier < mocks[j].Identifier
+	})
+
+	respond(w, http.StatusOK, mocks)
+}
+
+// adminRegisterMocks registers one or more MockDefinition.
+func (s *server) adminRegisterMocks(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		respond(w, http.StatusBadRequest, adminError{Error: err.Error()})
+		return
+	}
+
+	defs, err := decodeDefinitions(data)
+	if err != nil {
+		respond(w, http.StatusBadRequest, adminError{Error: err.Error()})
+		return
+	}
+
+	err = s.RegisterDefinitions(defs...)
+	if err != nil {
+		respond(w, http.StatusBadRequest, adminError{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// adminDeleteMocks removes the mocks with the requested identifier.
+func (s *server) adminDeleteMocks(w http.ResponseWriter, r *http.Request) {
+	s.unregister(r.URL.Query().Get("identifier"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminListRequests sends every request received by the server.
+func (s *server) adminListRequests(w http.ResponseWriter, r *http.Request) {
+	requests := s.requests()
+	if requests == nil {
+		requests = []RecordedRequest{}
+	}
+
+	respond(w, http.StatusOK, requests)
+}
+
+// adminDeleteRequests clears the requests received by the server.
+func (s *server) adminDeleteRequests(w http.ResponseWriter, r *http.Request) {
+	s.clearHistory()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminStats sends the summary of the requests handled by the server.
+func (s *server) adminStats(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, s.Stats())
+}
@@ -0,0 +1,111 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdmin checks that mocks may be managed through the admin API.
+func TestAdmin(t *testing.T) {
+	s := New(WithAdmin("/__admin"))
+	defer s.Close()
+
+	httpClient := s.Client()
+	client := graphql.NewClient(s.URL())
+
+	// adminRequest sends a request to the admin API, decoding its response into resp.
+	adminRequest := func(method, path, body string, resp any) int {
+		req, err := http.NewRequest(method, s.URL()+"/__admin"+path, strings.NewReader(body))
+		if !assert.NoError(t, err, "failed to create the request") {
+			return 0
+		}
+
+		res, err := httpClient.Do(req)
+		if !assert.NoError(t, err, "failed to send the request") {
+			return 0
+		}
+		defer res.Body.Close()
+
+		if resp != nil {
+			err = json.NewDecoder(res.Body).Decode(resp)
+			assert.NoError(t, err, "failed to decode the response")
+		}
+
+		return res.StatusCode
+	}
+
+	// sendQuery sends a query for ListFoos, returning whether it succeeded.
+	sendQuery := func(num int) (map[string]any, error) {
+		req := graphql.NewRequest(`query ($num:Integer!) {
+			ListFoos(num:$num) {
+				foo
+			}
+		}`)
+		req.Var("num", num)
+
+		var resp map[string]any
+		err := client.Run(context.Background(), req, &resp)
+		return resp, err
+	}
+
+	status := adminRequest(http.MethodPost, "/mocks", `[{
+		"identifier": "ListFoos",
+		"variables": {"match": "exact", "values": {"num": 1}},
+		"response": {"ListFoos": {"foo": 123}}
+	}, {
+		"identifier": "ListFoos",
+		"variables": {"match": "keys", "keys": ["num"]},
+		"response": {"ListFoos": {"foo": 456}}
+	}]`, nil)
+	assert.Equal(t, http.StatusCreated, status, "failed to register the mocks")
+
+	status = adminRequest(http.MethodPost, "/mocks", `{"identifier": "ListFoos", "variables": {"match": "bad"}}`, nil)
+	assert.Equal(t, http.StatusBadRequest, status, "an invalid mock was registered")
+
+	var mocks []adminMock
+	status = adminRequest(http.MethodGet, "/mocks", "", &mocks)
+	assert.Equal(t, http.StatusOK, status, "failed to list the mocks")
+	assert.Len(t, mocks, 2, "unexpected number of registered mocks")
+
+	resp, err := sendQuery(1)
+	if assert.NoError(t, err, "failed to send the first query") {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp, "unexpected response")
+	}
+
+	resp, err = sendQuery(2)
+	if assert.NoError(t, err, "failed to send the second query") {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 456.0}}, resp, "unexpected response")
+	}
+
+	var requests []RecordedRequest
+	status = adminRequest(http.MethodGet, "/requests", "", &requests)
+	assert.Equal(t, http.StatusOK, status, "failed to list the requests")
+	if assert.Len(t, requests, 2, "unexpected number of requests") {
+		assert.Equal(t, map[string]any{"num": 1.0}, requests[0].Variables, "unexpected variables in the first request")
+		assert.Equal(t, "ListFoos", requests[1].Identifier, "unexpected match for the second request")
+	}
+
+	var stats Stats
+	status = adminRequest(http.MethodGet, "/stats", "", &stats)
+	assert.Equal(t, http.StatusOK, status, "failed to summarize the requests")
+	assert.Equal(t, 2, stats.Requests, "unexpected number of summarized requests")
+
+	status = adminRequest(http.MethodDelete, "/mocks?identifier=ListFoos", "", nil)
+	assert.Equal(t, http.StatusNoContent, status, "failed to delete the mocks")
+
+	_, err = sendQuery(1)
+	assert.Error(t, err, "the query succeeded after its mock was deleted")
+
+	status = adminRequest(http.MethodDelete, "/requests", "", nil)
+	assert.Equal(t, http.StatusNoContent, status, "failed to clear the requests")
+
+	requests = nil
+	adminRequest(http.MethodGet, "/requests", "", &requests)
+	assert.Empty(t, requests, "the requests weren't cleared")
+}
@@ -0,0 +1,58 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AmbiguousMatchBehavior defines how the server handles requests matching mocks
+// registered with different identifiers (e.g., "Foos" and "ListFoos").
+// Since the order in which identifiers are checked isn't defined,
+// the server could answer any of these mocks.
+//
+// Mocks registered with the same identifier are never ambiguous,
+// as they are always checked in the order they were registered.
+type AmbiguousMatchBehavior int
+
+const (
+	// AmbiguousMatchIgnore answers with any of the matched mocks. This is the default.
+	AmbiguousMatchIgnore AmbiguousMatchBehavior = iota
+	// AmbiguousMatchWarn answers with the mock whose identifier sorts first,
+	// logging a warning listing every matched mock.
+	AmbiguousMatchWarn
+	// AmbiguousMatchFail answers with a 500 and a GraphQL error listing every matched mock,
+	// and makes ExpectationsWereMet fail.
+	AmbiguousMatchFail
+)
+
+// errAmbiguousMatch is the error sent for requests that match multiple mocks, with AmbiguousMatchFail.
+var errAmbiguousMatch = errors.New("goraphql_mock_server: request matched multiple mocks")
+
+// ambiguityExtensions is sent in the extensions of the error for ambiguous requests,
+// listing every matched mock.
+type ambiguityExtensions struct {
+	Conflicts []string `json:"conflicts"`
+}
+
+// WithAmbiguousMatchBehavior configures how the server handles requests
+// that match mocks registered with different identifiers,
+// to catch accidentally overlapping mocks.
+func WithAmbiguousMatchBehavior(behavior AmbiguousMatchBehavior) ServerOptions {
+	return func(s *server) {
+		s.ambiguousMatch = behavior
+	}
+}
+
+// recordAmbiguity stores the ambiguous match, so it's reported by ExpectationsWereMet.
+// s.mu must be held by the caller.
+func (s *server) recordAmbiguity(req Request, conflicts []string) {
+	if s.ambiguousMatch != AmbiguousMatchFail {
+		return
+	}
+
+	s.ambiguities = append(s.ambiguities, fmt.Sprintf("request '%s' matched multiple mocks: %s",
+		compactQuery(req.Query),
+		strings.Join(conflicts, ", "),
+	))
+}
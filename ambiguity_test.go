@@ -0,0 +1,70 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAmbiguousMatchBehavior checks each of the ways ambiguous matches may be handled.
+func TestAmbiguousMatchBehavior(t *testing.T) {
+	type testCase struct {
+		// How ambiguous matches are handled.
+		behavior AmbiguousMatchBehavior
+		// Whether the request should fail.
+		fail bool
+	}
+
+	testCases := []testCase{{
+		behavior: AmbiguousMatchIgnore,
+	}, {
+		behavior: AmbiguousMatchWarn,
+	}, {
+		behavior: AmbiguousMatchFail,
+		fail:     true,
+	}}
+
+	for i, tc := range testCases {
+		s := New(
+			WithAmbiguousMatchBehavior(tc.behavior),
+			WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		)
+
+		s.RegisterQuery("Foos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		})
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 456}}`),
+		})
+		// Mocks with the same identifier are checked in order, so they aren't ambiguous.
+		s.RegisterQuery("ListBars", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListBars": {"bar": 123}}`),
+		})
+		s.RegisterQuery("ListBars", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+		})
+
+		client := graphql.NewClient(s.URL())
+
+		var resp map[string]any
+		err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+		if tc.fail {
+			assert.ErrorContains(t, err, "matched multiple mocks", "test %d", i)
+			assert.ErrorContains(t, s.ExpectationsWereMet(), "query mock #0 for 'ListFoos'", "test %d", i)
+		} else {
+			assert.NoError(t, err, "test %d", i)
+			assert.NoError(t, s.ExpectationsWereMet(), "test %d", i)
+		}
+
+		resp = nil
+		err = client.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), &resp)
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, map[string]any{"ListBars": map[string]any{"bar": 123.0}}, resp, "test %d", i)
+
+		s.Close()
+	}
+}
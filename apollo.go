@@ -0,0 +1,133 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apolloTracingVersion is the version of the Apollo tracing format sent by WithApolloTracing.
+const apolloTracingVersion = 1
+
+// apolloTracing is the tracing extension, in the Apollo tracing format,
+// describing how long the server took to resolve each field.
+// Every offset and duration is in nanoseconds.
+type apolloTracing struct {
+	Version    int                  `json:"version"`
+	StartTime  string               `json:"startTime"`
+	EndTime    string               `json:"endTime"`
+	Duration   int64                `json:"duration"`
+	Parsing    apolloTracingPhase   `json:"parsing"`
+	Validation apolloTracingPhase   `json:"validation"`
+	Execution  apolloTracingResults `json:"execution"`
+}
+
+// apolloTracingPhase describes when a phase of the request's handling started, and how long it took.
+type apolloTracingPhase struct {
+	StartOffset int64 `json:"startOffset"`
+	Duration    int64 `json:"duration"`
+}
+
+// apolloTracingResults lists the timings of every resolved field.
+type apolloTracingResults struct {
+	Resolvers []apolloTracingResolver `json:"resolvers"`
+}
+
+// apolloTracingResolver describes when a field was resolved, and how long it took.
+type apolloTracingResolver struct {
+	Path        []any  `json:"path"`
+	ParentType  string `json:"parentType"`
+	FieldName   string `json:"fieldName"`
+	ReturnType  string `json:"returnType"`
+	StartOffset int64  `json:"startOffset"`
+	Duration    int64  `json:"duration"`
+}
+
+// apolloTracingExtensions is sent in the extensions of responses traced by WithApolloTracing.
+type apolloTracingExtensions struct {
+	Tracing *apolloTracing `json:"tracing"`
+}
+
+// tracingRequestKey stores a tracingRequest in the context of requests traced by WithApolloTracing.
+type tracingRequestKey struct{}
+
+// tracingRequest describes the traced request.
+type tracingRequest struct {
+	// When the request was received.
+	received time.Time
+	// The request's query.
+	query string
+}
+
+// WithApolloTracing attaches a tracing extension, in the Apollo tracing format,
+// to every response sent by a mock,
+// so the telemetry of clients that parse tracing data may be tested.
+//
+// The timings are synthetic: each field selected by the operation is resolved
+// in the time configured for its mock by Delay, starting right after the request is received.
+// Since the server doesn't know the schema, the return type of every field is empty.
+func WithApolloTracing() ServerOptions {
+	return func(s *server) {
+		s.apolloTracing = true
+	}
+}
+
+// withTracingRequest stores the request in its context, so its response may be traced.
+func withTracingRequest(r *http.Request, req Request, received time.Time) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), tracingRequestKey{}, tracingRequest{
+		received: received,
+		query:    req.Query,
+	}))
+}
+
+// apolloTracing describes how the mock resolved the request, in the Apollo tracing format.
+// It returns nil if the request wasn't traced.
+func (r *registration) apolloTracing(req *http.Request) *apolloTracing {
+	traced, ok := req.Context().Value(tracingRequestKey{}).(tracingRequest)
+	if !ok {
+		return nil
+	}
+
+	// Strip the monotonic clock readings, so the duration matches the timestamps.
+	start, end := traced.received.Round(0), time.Now().Round(0)
+	tracing := &apolloTracing{
+		Version:   apolloTracingVersion,
+		StartTime: start.UTC().Format(time.RFC3339Nano),
+		EndTime:   end.UTC().Format(time.RFC3339Nano),
+		Duration:  end.Sub(start).Nanoseconds(),
+		Execution: apolloTracingResults{Resolvers: []apolloTracingResolver{}},
+	}
+
+	doc, err := parseQuery(traced.query)
+	if err != nil {
+		return tracing
+	}
+
+	parentType := "Query"
+	if r.operation == OperationMutation {
+		parentType = "Mutation"
+	}
+
+	for _, op := range doc.operations {
+		if op.kind != string(r.operation) {
+			continue
+		}
+
+		for _, sel := range op.selections {
+			if sel.kind != selectionField || strings.HasPrefix(sel.name, "__") {
+				continue
+			}
+
+			tracing.Execution.Resolvers = append(tracing.Execution.Resolvers, apolloTracingResolver{
+				Path:       []any{sel.responseKey()},
+				ParentType: parentType,
+				FieldName:  sel.name,
+				Duration:   r.delay.Nanoseconds(),
+			})
+		}
+		break
+	}
+
+	return tracing
+}
@@ -0,0 +1,80 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithApolloTracing checks that responses have a tracing extension derived from the mock's delay.
+func TestWithApolloTracing(t *testing.T) {
+	s := New(WithApolloTracing(), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	delay := 20 * time.Millisecond
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"foos": {"foo": 123}, "ListBars": []}`),
+	}, Delay(delay))
+
+	resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { foos: ListFoos { foo } ListBars { bar } __typename }"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Extensions struct {
+			Tracing apolloTracing `json:"tracing"`
+		} `json:"extensions"`
+	}
+	if !assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res)) {
+		return
+	}
+
+	tracing := res.Extensions.Tracing
+	assert.Equal(t, 1, tracing.Version)
+	assert.GreaterOrEqual(t, tracing.Duration, delay.Nanoseconds())
+
+	start, err := time.Parse(time.RFC3339Nano, tracing.StartTime)
+	assert.NoError(t, err)
+	end, err := time.Parse(time.RFC3339Nano, tracing.EndTime)
+	assert.NoError(t, err)
+	assert.Equal(t, tracing.Duration, end.Sub(start).Nanoseconds())
+
+	assert.Equal(t, []apolloTracingResolver{{
+		Path:       []any{"foos"},
+		ParentType: "Query",
+		FieldName:  "ListFoos",
+		Duration:   delay.Nanoseconds(),
+	}, {
+		Path:       []any{"ListBars"},
+		ParentType: "Query",
+		FieldName:  "ListBars",
+		Duration:   delay.Nanoseconds(),
+	}}, tracing.Execution.Resolvers)
+}
+
+// TestWithoutApolloTracing checks that responses have no extensions unless tracing is enabled.
+func TestWithoutApolloTracing(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data": {"ListFoos": {"foo": 123}}}`, string(body))
+}
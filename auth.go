@@ -0,0 +1,112 @@
+package goraphql_mock_server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AuthErrorPreset selects the framework whose authorization errors are simulated by AuthError.
+type AuthErrorPreset int
+
+const (
+	// ApolloUnauthenticated is the error sent by Apollo Server for unauthenticated requests,
+	// with the code UNAUTHENTICATED in its extensions.
+	ApolloUnauthenticated AuthErrorPreset = iota
+	// ApolloForbidden is the error sent by Apollo Server for requests that aren't allowed,
+	// with the code FORBIDDEN in its extensions.
+	ApolloForbidden
+	// ShieldNotAuthorised is the fallback error sent by graphql-shield for requests denied by its rules,
+	// without any extensions.
+	ShieldNotAuthorised
+)
+
+// authErrorBody is an error response, shaped like the ones sent by the preset's framework.
+type authErrorBody struct {
+	Data   map[string]any `json:"data"`
+	Errors []authError    `json:"errors"`
+}
+
+// authError is an authorization error for a single root field.
+type authError struct {
+	Message    string               `json:"message"`
+	Path       []string             `json:"path,omitempty"`
+	Extensions *authErrorExtensions `json:"extensions,omitempty"`
+}
+
+// authErrorExtensions are the extensions of an authorization error sent by Apollo Server.
+type authErrorExtensions struct {
+	Code string `json:"code"`
+}
+
+// error describes the preset's error for the field at path.
+func (p AuthErrorPreset) error(path []string) authError {
+	switch p {
+	case ApolloForbidden:
+		return authError{
+			Message:    "You are not authorized to perform this action.",
+			Path:       path,
+			Extensions: &authErrorExtensions{Code: "FORBIDDEN"},
+		}
+	case ShieldNotAuthorised:
+		return authError{
+			Message: "Not Authorised!",
+			Path:    path,
+		}
+	default:
+		return authError{
+			Message:    "User is not authenticated",
+			Path:       path,
+			Extensions: &authErrorExtensions{Code: "UNAUTHENTICATED"},
+		}
+	}
+}
+
+// AuthError makes the mock answer with the authorization error sent by a common framework
+// (e.g., ApolloUnauthenticated) instead of its response,
+// so clients' flows for refreshing credentials may be tested against realistic errors.
+//
+// Like a server whose resolvers reject the request,
+// an error is sent for every root field selected by the operation, along with its path,
+// and every root field is null in the data. The response is sent with a 200.
+// If the root fields can't be determined, a single error is sent without any data.
+func AuthError(preset AuthErrorPreset) RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				var body authErrorBody
+				for _, field := range rootFields(mockRequest(req).Query) {
+					if body.Data == nil {
+						body.Data = make(map[string]any)
+					}
+					body.Data[field] = nil
+					body.Errors = append(body.Errors, preset.error([]string{field}))
+				}
+
+				// Reject the whole request if its fields are unknown.
+				if len(body.Errors) == 0 {
+					body.Errors = append(body.Errors, preset.error(nil))
+				}
+
+				respond(w, http.StatusOK, body)
+			})
+		})
+	}
+}
+
+// rootFields lists the response keys of the root fields selected by the query's first operation,
+// except for the introspection fields (e.g., __typename).
+func rootFields(query string) []string {
+	doc, err := parseQuery(query)
+	if err != nil || len(doc.operations) == 0 {
+		return nil
+	}
+
+	var fields []string
+	for _, sel := range doc.operations[0].selections {
+		if sel.kind == selectionField && !strings.HasPrefix(sel.name, "__") {
+			fields = append(fields, sel.responseKey())
+		}
+	}
+
+	return fields
+}
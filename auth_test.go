@@ -0,0 +1,53 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAuthError checks that mocks answer with the authorization errors of each preset.
+func TestAuthError(t *testing.T) {
+	type testCase struct {
+		// The preset selected for the mock.
+		preset AuthErrorPreset
+		// The query sent to the mock.
+		query string
+		// The expected response.
+		expected string
+	}
+
+	testCases := []testCase{{
+		preset:   ApolloUnauthenticated,
+		query:    `query { me { id } }`,
+		expected: `{"data": {"me": null}, "errors": [{"message": "User is not authenticated", "path": ["me"], "extensions": {"code": "UNAUTHENTICATED"}}]}`,
+	}, {
+		preset:   ApolloForbidden,
+		query:    `query { me { id } admin: users { id } }`,
+		expected: `{"data": {"me": null, "admin": null}, "errors": [{"message": "You are not authorized to perform this action.", "path": ["me"], "extensions": {"code": "FORBIDDEN"}}, {"message": "You are not authorized to perform this action.", "path": ["admin"], "extensions": {"code": "FORBIDDEN"}}]}`,
+	}, {
+		preset:   ShieldNotAuthorised,
+		query:    `query { me { id } }`,
+		expected: `{"data": {"me": null}, "errors": [{"message": "Not Authorised!", "path": ["me"]}]}`,
+	}}
+
+	for i, tc := range testCases {
+		s := New()
+		s.RegisterQuery("me", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"me": {"id": 1}}`),
+		}, AuthError(tc.preset))
+
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "`+tc.query+`"}`))
+		if assert.NoError(t, err, "test %d", i) {
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.NoError(t, err, "test %d", i)
+			assert.Equal(t, http.StatusOK, resp.StatusCode, "test %d", i)
+			assert.JSONEq(t, tc.expected, string(body), "test %d", i)
+		}
+		s.Close()
+	}
+}
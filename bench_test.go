@@ -0,0 +1,107 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// BenchmarkHandler measures the cost of answering a request with a static mock,
+// without the overhead of the network.
+func BenchmarkHandler(b *testing.B) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))).(*server)
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": [{"foo": 1, "bar": "a"}, {"foo": 2, "bar": "b"}, {"foo": 3, "bar": "c"}]}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+
+	const body = `{"query": "query ListFoos($num: Int!) { ListFoos(num: $num) { foo bar } }", "variables": {"num": 3}}`
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		s.mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+// BenchmarkReadBody compares reading request bodies through readBody against io.ReadAll,
+// for bodies of both known and unknown sizes.
+func BenchmarkReadBody(b *testing.B) {
+	body := `{"query": "query ListFoos($num: Int!) { ListFoos(num: $num) { foo bar } }", "variables": {"num": 3}}`
+	body = strings.Repeat(body, 64)
+
+	b.Run("ReadAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = io.ReadAll(strings.NewReader(body))
+		}
+	})
+	b.Run("KnownSize", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = readBody(strings.NewReader(body), int64(len(body)))
+		}
+	})
+	b.Run("UnknownSize", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = readBody(strings.NewReader(body), -1)
+		}
+	})
+}
+
+// BenchmarkRespond compares encoding and sending a generated response through the pooled encoders
+// against encoding it with json.Marshal.
+func BenchmarkRespond(b *testing.B) {
+	res := Response{
+		Data: map[string]any{
+			"ListFoos": []any{
+				map[string]any{"foo": 1, "bar": "a"},
+				map[string]any{"foo": 2, "bar": "b"},
+			},
+		},
+	}
+	w := &discardWriter{header: make(http.Header)}
+
+	b.Run("Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, _ := json.Marshal(res)
+			writeJSON(w, http.StatusOK, append(data, '\n'))
+		}
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			respond(w, http.StatusOK, res)
+		}
+	})
+}
+
+// discardWriter is a http.ResponseWriter that discards the response,
+// so benchmarks only measure the cost of encoding it.
+type discardWriter struct {
+	// The response's headers.
+	header http.Header
+}
+
+// Header implements http.ResponseWriter for discardWriter.
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+// Write implements http.ResponseWriter for discardWriter.
+func (w *discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// WriteHeader implements http.ResponseWriter for discardWriter.
+func (w *discardWriter) WriteHeader(int) {}
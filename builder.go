@@ -0,0 +1,271 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Responder generates the response of a mock (e.g., StringResponse, RawResponse or LiveResponse).
+type Responder interface {
+	// Response returns the object that should be sent as the response to the request.
+	Response() any
+}
+
+// JSON creates a Responder that sends the JSON-encoded object as the response's data.
+func JSON(data string) Responder {
+	return StringResponse(data)
+}
+
+// Subset implements a CompareVariables()
+// that checks that the request has (at least) each of these variables, with the same value.
+// Other variables are ignored.
+//
+// The values are compared as JSON, so Go types that encode the same way
+// (e.g., int and float64) are considered equal.
+type Subset map[string]any
+
+// CompareVariables partially implements MockedRequest for Subset.
+func (sub Subset) CompareVariables(got map[string]any) bool {
+	want, ok := normalizeJSON(map[string]any(sub)).(map[string]any)
+	if !ok {
+		return false
+	}
+
+	for k, v := range want {
+		gotValue, ok := got[k]
+		if !ok || !reflect.DeepEqual(v, normalizeJSON(gotValue)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeJSON converts the value to the types it's decoded as from JSON
+// (e.g., numbers to float64 and structs to map[string]any).
+// Values that can't be encoded are returned as they are.
+func normalizeJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+
+	return normalized
+}
+
+// expectedMock implements MockedRequest for the mocks built by an Expectation.
+type expectedMock struct {
+	// Generates the mock's response.
+	responder Responder
+	// Matches the request's variables. If nil, any variables are matched.
+	variables VariablesMatcher
+}
+
+// Response implements MockedRequest for expectedMock.
+func (m *expectedMock) Response() any {
+	return m.responder.Response()
+}
+
+// CompareVariables implements MockedRequest for expectedMock.
+func (m *expectedMock) CompareVariables(v map[string]any) bool {
+	return m.variables == nil || m.variables.CompareVariables(v)
+}
+
+// CompareVariablesContext implements ContextVariablesMatcher for expectedMock,
+// passing the context to matchers implementing it.
+func (m *expectedMock) CompareVariablesContext(ctx context.Context, v map[string]any) bool {
+	if matcher, ok := m.variables.(ContextVariablesMatcher); ok {
+		return matcher.CompareVariablesContext(ctx, v)
+	}
+
+	return m.CompareVariables(v)
+}
+
+// watch implements liveMock for expectedMock,
+// so responses built from a LiveResponse are re-sent to live queries.
+// Other responses are never updated.
+func (m *expectedMock) watch() (any, <-chan struct{}) {
+	if live, ok := m.responder.(liveMock); ok {
+		return live.watch()
+	}
+
+	return m.responder.Response(), nil
+}
+
+// Matcher builds mocks that match the request's variables with a VariablesMatcher.
+// It's created by Match.
+type Matcher struct {
+	variables VariablesMatcher
+}
+
+// Match starts building a mock matching the request's variables with the matcher
+// (e.g., Match(KeyOnlyVariables{"num"}).Respond(JSON(`...`))),
+// so no struct embedding both implementations must be declared.
+// A nil matcher matches any variables.
+func Match(matcher VariablesMatcher) Matcher {
+	return Matcher{variables: compileMatcher(matcher)}
+}
+
+// compileMatcher compiles the matcher once, if possible (e.g., an ExactVariables),
+// so it's cheaper to compare to every request's variables.
+func compileMatcher(matcher VariablesMatcher) VariablesMatcher {
+	if ev, ok := matcher.(ExactVariables); ok && ev.plan == nil {
+		return Exact(ev.Variables)
+	}
+
+	return matcher
+}
+
+// Respond creates the mock, responding with the responder.
+// Mocks responding with a LiveResponse re-send it to live queries.
+func (m Matcher) Respond(responder Responder) MockedRequest {
+	return &expectedMock{responder: responder, variables: m.variables}
+}
+
+// Expectation builds a mock fluently, composing the matchers, responses and options of this package
+// (e.g., s.Expect("ListFoos").WithVariables(Subset{"num": 1}).Times(2).Reply(JSON(`...`))).
+//
+// The mock is registered by Reply, and methods called after Reply update the registered mock.
+// Unless restricted by WithVariables, the mock matches any variables.
+type Expectation struct {
+	// The server where the mock is registered.
+	server *server
+	// The scope that registers the mock, if any.
+	scope      string
+	identifier string
+	operation  OperationType
+	// The mock built by the expectation.
+	mock *expectedMock
+	// The options applied to the mock before it's registered.
+	opts []RegisterOptions
+	// The registered mock. Nil until Reply is called.
+	reg *registration
+}
+
+// Expect implements Server for server.
+func (s *server) Expect(identifier string) *Expectation {
+	return s.expectIn("", identifier)
+}
+
+// Expect implements Server for scopedServer.
+func (sc *scopedServer) Expect(identifier string) *Expectation {
+	return sc.expectIn(sc.id, identifier)
+}
+
+// expectIn starts building a query mock for the identifier, registered in the scope.
+func (s *server) expectIn(scope, identifier string) *Expectation {
+	return &Expectation{
+		server:     s,
+		scope:      scope,
+		identifier: identifier,
+		operation:  OperationQuery,
+		mock:       &expectedMock{},
+	}
+}
+
+// apply applies the option to the mock, either right away (if it's already registered)
+// or once it's registered.
+func (e *Expectation) apply(opt RegisterOptions) *Expectation {
+	if e.reg == nil {
+		e.opts = append(e.opts, opt)
+		return e
+	}
+
+	e.server.mu.Lock()
+	defer e.server.mu.Unlock()
+
+	if err := e.reg.apply([]RegisterOptions{opt}); err != nil {
+		panic(err.Error())
+	}
+	return e
+}
+
+// Mutation makes the mock match mutations, instead of queries.
+func (e *Expectation) Mutation() *Expectation {
+	e.operation = OperationMutation
+	return e.apply(func(r *registration) {
+		r.operation = OperationMutation
+	})
+}
+
+// WithVariables makes the mock only match requests whose variables match (e.g., Subset or ExactVariables).
+func (e *Expectation) WithVariables(matcher VariablesMatcher) *Expectation {
+	return e.apply(func(r *registration) {
+		e.mock.variables = compileMatcher(matcher)
+	})
+}
+
+// WithHeader makes the mock only match requests whose header name has the value, like MatchHeader.
+func (e *Expectation) WithHeader(name, value string) *Expectation {
+	return e.apply(MatchHeader(name, value))
+}
+
+// Named gives the mock a human-readable name, like Named.
+func (e *Expectation) Named(name string) *Expectation {
+	return e.apply(Named(name))
+}
+
+// Times expects the mock to be called exactly n times, like Times.
+func (e *Expectation) Times(n int) *Expectation {
+	return e.apply(Times(n))
+}
+
+// Once expects the mock to be called exactly once.
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// MinTimes expects the mock to be called at least n times, like MinTimes.
+func (e *Expectation) MinTimes(n int) *Expectation {
+	return e.apply(MinTimes(n))
+}
+
+// MaxTimes expects the mock to be called at most n times, like MaxTimes.
+func (e *Expectation) MaxTimes(n int) *Expectation {
+	return e.apply(MaxTimes(n))
+}
+
+// Delay delays the mock's response by the duration, like Delay.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	return e.apply(Delay(d))
+}
+
+// With applies any other option to the mock (e.g., AuthError).
+func (e *Expectation) With(opts ...RegisterOptions) *Expectation {
+	for _, opt := range opts {
+		e.apply(opt)
+	}
+
+	return e
+}
+
+// Reply sets the mock's response, registering the mock if it wasn't registered yet.
+// Like RegisterQuery, it panics if the response is invalid, keeping the previous one.
+func (e *Expectation) Reply(responder Responder) *Expectation {
+	if e.reg != nil {
+		return e.apply(func(r *registration) {
+			candidate := *e.mock
+			candidate.responder = responder
+			if err := e.server.validateRegistration(r.identifier, r.operation, &candidate); err != nil {
+				r.fail(err)
+				return
+			}
+
+			e.mock.responder = responder
+			r.premarshal()
+		})
+	}
+
+	e.mock.responder = responder
+	e.reg = e.server.mustRegisterIn(e.scope, e.identifier, e.operation, e.mock, e.opts)
+	e.opts = nil
+
+	return e
+}
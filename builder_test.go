@@ -0,0 +1,143 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSubset checks that Subset matches requests with, at least, its variables.
+func TestSubset(t *testing.T) {
+	type testCase struct {
+		// The expected variables.
+		subset Subset
+		// The request's variables.
+		variables map[string]any
+		// Whether the variables should match.
+		expected bool
+	}
+
+	testCases := []testCase{{
+		subset:    Subset{"num": 1},
+		variables: map[string]any{"num": 1.0, "other": "x"},
+		expected:  true,
+	}, {
+		subset:    Subset{"filter": map[string]any{"ids": []int{1, 2}}},
+		variables: map[string]any{"filter": map[string]any{"ids": []any{1.0, 2.0}}},
+		expected:  true,
+	}, {
+		subset:    Subset{},
+		variables: nil,
+		expected:  true,
+	}, {
+		subset:    Subset{"num": 1},
+		variables: map[string]any{"num": 2.0},
+	}, {
+		subset:    Subset{"num": 1},
+		variables: map[string]any{"other": 1.0},
+	}}
+
+	for i, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.subset.CompareVariables(tc.variables), "test %d", i)
+	}
+}
+
+// TestExpect checks that mocks built fluently are registered with every option.
+func TestExpect(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	foos := s.Expect("ListFoos").
+		WithVariables(Subset{"num": 1}).
+		Times(2).
+		Reply(JSON(`{"ListFoos": {"foo": 123}}`)).
+		Delay(50 * time.Millisecond)
+	s.Expect("AddFoo").Mutation().Reply(JSON(`{"AddFoo": true}`)).Once()
+
+	client := s.GraphQLClient()
+
+	req := graphql.NewRequest(`query ($num: Int, $page: Int) { ListFoos(num: $num, page: $page) { foo } }`)
+	req.Var("num", 1)
+	req.Var("page", 3)
+
+	start := time.Now()
+	var resp map[string]any
+	if assert.NoError(t, client.Run(context.Background(), req, &resp)) {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "the response wasn't delayed")
+
+	other := graphql.NewRequest(`query ($num: Int) { ListFoos(num: $num) { foo } }`)
+	other.Var("num", 2)
+	assert.Error(t, client.Run(context.Background(), other, nil), "the variables weren't matched")
+
+	assert.NoError(t, client.Run(context.Background(), graphql.NewRequest(`mutation { AddFoo }`), nil))
+	assert.Error(t, client.Run(context.Background(), graphql.NewRequest(`query { AddFoo }`), nil), "a query matched a mutation")
+
+	// The registered mock is updated by the methods called after Reply.
+	foos.Reply(JSON(`{"ListFoos": {"foo": 456}}`)).WithVariables(nil)
+	if assert.NoError(t, client.Run(context.Background(), other, &resp)) {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 456.0}}, resp)
+	}
+
+	// Invalid responses are rejected, keeping the previous one.
+	assert.Panics(t, func() { foos.Reply(JSON(`{"ListFoos": `)) }, "the invalid response was accepted")
+	if assert.NoError(t, client.Run(context.Background(), other, &resp)) {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 456.0}}, resp)
+	}
+
+	s.AssertNumberOfCalls(t, "ListFoos", 3)
+	s.AssertNumberOfCalls(t, "AddFoo", 1)
+	s.Reset()
+
+	// Mocks built from a scope are only visible from it.
+	sc := s.Scope(t)
+	sc.Expect("ListBars").Reply(JSON(`{"ListBars": []}`))
+	assert.NoError(t, sc.GraphQLClient().Run(context.Background(), graphql.NewRequest(`query { ListBars }`), nil))
+	assert.Error(t, client.Run(context.Background(), graphql.NewRequest(`query { ListBars }`), nil))
+}
+
+// TestMatch checks that mocks built by Match combine any matcher with any response.
+func TestMatch(t *testing.T) {
+	type testCase struct {
+		// The mock under test.
+		mock MockedRequest
+		// The request's variables.
+		variables map[string]any
+		// Whether the variables should match.
+		expected bool
+	}
+
+	response := JSON(`{"ListFoos": {"foo": 123}}`)
+	testCases := []testCase{{
+		mock:      Match(KeyOnlyVariables{"num"}).Respond(response),
+		variables: map[string]any{"num": 1.0},
+		expected:  true,
+	}, {
+		mock:      Match(KeyOnlyVariables{"num"}).Respond(response),
+		variables: map[string]any{"page": 1.0},
+	}, {
+		mock:      Match(Subset{"num": 1}).Respond(RawResponse{Payload: 123}),
+		variables: map[string]any{"num": 1.0, "page": 2.0},
+		expected:  true,
+	}, {
+		mock:      Match(NoVariable{}).Respond(response),
+		variables: map[string]any{"num": 1.0},
+	}, {
+		mock:      Match(nil).Respond(response),
+		variables: map[string]any{"num": 1.0},
+		expected:  true,
+	}}
+
+	for i, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.mock.CompareVariables(tc.variables), "test %d", i)
+	}
+
+	assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, testCases[0].mock.Response())
+	assert.Equal(t, 123, testCases[2].mock.Response())
+}
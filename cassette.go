@@ -0,0 +1,154 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cassetteVersion is the version of the go-vcr cassette format written by WriteCassette.
+const cassetteVersion = 2
+
+// cassette is a go-vcr cassette, listing every recorded interaction.
+type cassette struct {
+	Version      int                   `yaml:"version"`
+	Interactions []cassetteInteraction `yaml:"interactions"`
+}
+
+// cassetteInteraction is a single exchange (a request and its response) in a go-vcr cassette.
+type cassetteInteraction struct {
+	ID       int              `yaml:"id"`
+	Request  cassetteRequest  `yaml:"request"`
+	Response cassetteResponse `yaml:"response"`
+}
+
+// cassetteRequest describes a request in a go-vcr cassette.
+type cassetteRequest struct {
+	Proto         string              `yaml:"proto"`
+	ProtoMajor    int                 `yaml:"proto_major"`
+	ProtoMinor    int                 `yaml:"proto_minor"`
+	ContentLength int64               `yaml:"content_length"`
+	Body          string              `yaml:"body"`
+	Form          map[string][]string `yaml:"form"`
+	Headers       map[string][]string `yaml:"headers"`
+	URL           string              `yaml:"url"`
+	Method        string              `yaml:"method"`
+}
+
+// cassetteResponse describes a response in a go-vcr cassette.
+type cassetteResponse struct {
+	Proto         string              `yaml:"proto"`
+	ProtoMajor    int                 `yaml:"proto_major"`
+	ProtoMinor    int                 `yaml:"proto_minor"`
+	ContentLength int64               `yaml:"content_length"`
+	Headers       map[string][]string `yaml:"headers"`
+	Body          string              `yaml:"body"`
+	Status        string              `yaml:"status"`
+	Code          int                 `yaml:"code"`
+	Duration      string              `yaml:"duration"`
+}
+
+// WriteCassette implements Server for server.
+func (s *server) WriteCassette(w io.Writer) error {
+	return writeCassette(w, s.requests())
+}
+
+// writeCassette writes the requests, along with their responses, to w as a go-vcr cassette.
+func writeCassette(w io.Writer, history []RecordedRequest) error {
+	c := cassette{
+		Version:      cassetteVersion,
+		Interactions: make([]cassetteInteraction, 0, len(history)),
+	}
+
+	for i, req := range history {
+		interaction := cassetteInteraction{
+			ID: i,
+			Request: cassetteRequest{
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				ContentLength: int64(len(req.body)),
+				Body:          string(req.body),
+				Form:          map[string][]string{},
+				Headers:       req.Header,
+				URL:           req.URL,
+				Method:        req.Method,
+			},
+		}
+
+		if res := req.Response; res != nil {
+			interaction.Response = cassetteResponse{
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				ContentLength: int64(len(res.Body)),
+				Headers:       res.Header,
+				Body:          res.Body,
+				Status:        fmt.Sprintf("%d %s", res.Status, http.StatusText(res.Status)),
+				Code:          res.Status,
+				Duration:      res.Duration.String(),
+			}
+		}
+
+		c.Interactions = append(c.Interactions, interaction)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("goraphql_mock_server: write cassette: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("goraphql_mock_server: write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// ImportCassette converts the GraphQL interactions recorded in a go-vcr cassette
+// (in either the version 1 or the version 2 format) into mock definitions,
+// which may then be registered with Server.RegisterDefinitions.
+//
+// Each interaction whose request is a POST with a JSON body with a query is converted into a definition
+// that uses the query as the identifier and that matches its variables, if any, exactly.
+// Other interactions (e.g., the ones for REST endpoints) are skipped.
+//
+// The interaction's response must be a successful GraphQL response,
+// whose "data" is used as the mocked response.
+// GraphQL interactions that can't be converted cause the import to fail.
+func ImportCassette(r io.Reader) ([]MockDefinition, error) {
+	var c cassette
+	if err := yaml.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: decode cassette: %w", err)
+	}
+
+	defs := []MockDefinition{}
+	for i, interaction := range c.Interactions {
+		if interaction.Request.Method != http.MethodPost {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(interaction.Request.Body), &req); err != nil || req.Query == "" {
+			continue
+		}
+
+		if interaction.Response.Code != http.StatusOK {
+			return nil, fmt.Errorf("goraphql_mock_server: cassette interaction #%d: unsupported response status %d", i, interaction.Response.Code)
+		}
+
+		data, err := responseData([]byte(interaction.Response.Body))
+		if err != nil {
+			return nil, fmt.Errorf("goraphql_mock_server: cassette interaction #%d: %w", i, err)
+		}
+
+		def := collectedDefinition("", req)
+		def.Response = data
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
@@ -0,0 +1,79 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCassette checks that the recorded traffic is written as a go-vcr cassette,
+// which may then be imported into another server.
+func TestCassette(t *testing.T) {
+	const query = `query ListFoos($num: Int!) { ListFoos(num: $num) { foo } }`
+
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", struct {
+		StringResponse
+		KeyOnlyVariables
+	}{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+
+	req := graphql.NewRequest(query)
+	req.Var("num", 1)
+	err := graphql.NewClient(s.URL()).Run(context.Background(), req, nil)
+	if !assert.NoError(t, err, "failed to send the query") {
+		return
+	}
+
+	var buf bytes.Buffer
+	err = s.WriteCassette(&buf)
+	if !assert.NoError(t, err, "failed to write the cassette") {
+		return
+	}
+	assert.Contains(t, buf.String(), "version: 2", "unexpected cassette version")
+	assert.Contains(t, buf.String(), "code: 200", "the response wasn't recorded")
+
+	defs, err := ImportCassette(&buf)
+	if !assert.NoError(t, err, "failed to import the cassette") || !assert.Len(t, defs, 1, "unexpected number of interactions") {
+		return
+	}
+	assert.Equal(t, query, defs[0].Identifier, "unexpected identifier")
+
+	replay := New()
+	defer replay.Close()
+
+	err = replay.RegisterDefinitions(defs...)
+	if !assert.NoError(t, err, "failed to register the interactions") {
+		return
+	}
+
+	var resp map[string]any
+	err = graphql.NewClient(replay.URL()).Run(context.Background(), req, &resp)
+	if assert.NoError(t, err, "failed to replay the query") {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp, "unexpected response")
+	}
+
+	// REST interactions are skipped.
+	defs, err = ImportCassette(strings.NewReader(`
+version: 1
+interactions:
+- request:
+    body: ""
+    url: https://example.com/health
+    method: GET
+  response:
+    body: ok
+    status: 200 OK
+    code: 200
+`))
+	assert.NoError(t, err, "failed to import a REST cassette")
+	assert.Empty(t, defs, "a REST interaction was imported")
+}
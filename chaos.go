@@ -0,0 +1,64 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ChaosProfile names a bundle of faults simulated by every request to the server.
+type ChaosProfile string
+
+const (
+	// ChaosSlowNetwork delays every request by 200ms to 300ms.
+	ChaosSlowNetwork ChaosProfile = "slow-network"
+	// ChaosFlakyNetwork delays every request by 20ms to 220ms
+	// and fails 10% of them with a 502.
+	ChaosFlakyNetwork ChaosProfile = "flaky-network"
+	// ChaosOverloadedBackend delays every request by 300ms to 1s,
+	// fails 5% of them with a 503
+	// and answers with a 429 every request over 20 per second.
+	ChaosOverloadedBackend ChaosProfile = "overloaded-backend"
+)
+
+// chaosProfiles lists the options that simulate each profile's faults.
+var chaosProfiles = map[ChaosProfile][]ServerOptions{
+	ChaosSlowNetwork: {
+		WithLatency(200*time.Millisecond, 100*time.Millisecond),
+	},
+	ChaosFlakyNetwork: {
+		WithLatency(20*time.Millisecond, 200*time.Millisecond),
+		WithErrorRate(0.1, http.StatusBadGateway),
+	},
+	ChaosOverloadedBackend: {
+		WithLatency(300*time.Millisecond, 700*time.Millisecond),
+		WithErrorRate(0.05, http.StatusServiceUnavailable),
+		WithRateLimit(20, time.Second),
+	},
+}
+
+// Valid checks whether the profile is one of the profiles defined in this package.
+func (p ChaosProfile) Valid() bool {
+	_, ok := chaosProfiles[p]
+	return ok
+}
+
+// WithChaos simulates the profile's faults in every request,
+// so a suite may run the same tests under degraded conditions with a single option.
+// Use WithSeed to make the faults reproducible.
+//
+// The server fails to start if the profile isn't Valid.
+func WithChaos(profile ChaosProfile) ServerOptions {
+	opts, ok := chaosProfiles[profile]
+	if !ok {
+		return func(s *server) {
+			s.fail(fmt.Errorf("goraphql_mock_server: unknown chaos profile '%s'", profile))
+		}
+	}
+
+	return func(s *server) {
+		for _, fn := range opts {
+			fn(s)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package goraphql_mock_server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithChaos checks that chaos profiles simulate their faults.
+func TestWithChaos(t *testing.T) {
+	assert.True(t, ChaosFlakyNetwork.Valid())
+	assert.False(t, ChaosProfile("unknown").Valid())
+	_, err := NewE(WithChaos("unknown"))
+	assert.EqualError(t, err, "goraphql_mock_server: unknown chaos profile 'unknown'")
+
+	s := New(WithChaos(ChaosSlowNetwork))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	start := time.Now()
+	resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond, "the request wasn't delayed")
+}
@@ -0,0 +1,25 @@
+package goraphql_mock_server
+
+import (
+	"net/http"
+
+	"github.com/machinebox/graphql"
+	shurcool "github.com/shurcooL/graphql"
+)
+
+// GraphQLClient implements Server for server.
+func (s *server) GraphQLClient(opts ...graphql.ClientOption) *graphql.Client {
+	return newGraphQLClient(s.URL(), s.Client(), opts)
+}
+
+// ShurcoolClient implements Server for server.
+func (s *server) ShurcoolClient() *shurcool.Client {
+	return shurcool.NewClient(s.URL(), s.Client())
+}
+
+// newGraphQLClient creates a machinebox/graphql client for the address,
+// sending its requests through the HTTP client.
+// The options are applied after the HTTP client is set, so they may replace it.
+func newGraphQLClient(url string, client *http.Client, opts []graphql.ClientOption) *graphql.Client {
+	return graphql.NewClient(url, append([]graphql.ClientOption{graphql.WithHTTPClient(client)}, opts...)...)
+}
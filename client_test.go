@@ -0,0 +1,61 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	shurcool "github.com/shurcooL/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGraphQLClient checks that the clients created by the server reach it, with or without TLS and scopes.
+func TestGraphQLClient(t *testing.T) {
+	type testCase struct {
+		// The options of the server.
+		opts []ServerOptions
+		// Whether the clients are created from a scope.
+		scoped bool
+	}
+
+	testCases := []testCase{{}, {
+		opts: []ServerOptions{WithTLS()},
+	}, {
+		scoped: true,
+	}}
+
+	for i, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			s := New(append(tc.opts, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))...)
+			defer s.Close()
+
+			target := s
+			if tc.scoped {
+				target = s.Scope(t)
+			}
+
+			target.RegisterQuery("viewer{login}", SimpleMockedRequest{
+				StringResponse: StringResponse(`{"viewer": {"login": "foo"}}`),
+			})
+
+			var resp map[string]map[string]string
+			err := target.GraphQLClient().Run(context.Background(), graphql.NewRequest(`{viewer{login}}`), &resp)
+			if assert.NoError(t, err, "test %d", i) {
+				assert.Equal(t, "foo", resp["viewer"]["login"], "test %d", i)
+			}
+
+			var viewer struct {
+				Viewer struct {
+					Login shurcool.String
+				}
+			}
+			if assert.NoError(t, target.ShurcoolClient().Query(context.Background(), &viewer, nil), "test %d", i) {
+				assert.Equal(t, shurcool.String("foo"), viewer.Viewer.Login, "test %d", i)
+			}
+
+			assert.Equal(t, map[string]int{"viewer{login}": 2}, target.Counts(), "test %d", i)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+)
+
+// Cluster manages multiple named mock servers with a shared lifecycle,
+// for testing applications that communicate with several GraphQL services.
+type Cluster interface {
+	// Close closes every server in the cluster.
+	Close()
+
+	// Server returns the server with the given name.
+	// It panics if there's no server with that name,
+	// as that's most certainly a mistake in the test.
+	Server(name string) Server
+
+	// Names returns the name of every server in the cluster,
+	// in the same order they were created.
+	Names() []string
+
+	// URLs returns the address of every server in the cluster, indexed by their name.
+	URLs() map[string]string
+}
+
+// cluster implements Cluster.
+type cluster struct {
+	// The name of every server, in the order they were created.
+	names []string
+	// Every server in the cluster, indexed by their name.
+	servers map[string]Server
+}
+
+// NewCluster starts a new mocked GraphQL server for each of the names,
+// configuring every one of them with the same options.
+//
+// Be sure to call Close() when done with the cluster!
+func NewCluster(names []string, opts ...ServerOptions) Cluster {
+	c := cluster{
+		servers: make(map[string]Server, len(names)),
+	}
+
+	for _, name := range names {
+		if _, ok := c.servers[name]; ok {
+			c.Close()
+			panic(fmt.Sprintf("goraphql_mock_server: duplicated server name '%s' in cluster", name))
+		}
+
+		c.names = append(c.names, name)
+		c.servers[name] = New(opts...)
+	}
+
+	return &c
+}
+
+// Close implements Cluster for cluster.
+func (c *cluster) Close() {
+	for _, name := range c.names {
+		c.servers[name].Close()
+	}
+}
+
+// Server implements Cluster for cluster.
+func (c *cluster) Server(name string) Server {
+	s, ok := c.servers[name]
+	if !ok {
+		panic(fmt.Sprintf("goraphql_mock_server: no server named '%s' in cluster", name))
+	}
+
+	return s
+}
+
+// Names implements Cluster for cluster.
+func (c *cluster) Names() []string {
+	return append([]string(nil), c.names...)
+}
+
+// URLs implements Cluster for cluster.
+func (c *cluster) URLs() map[string]string {
+	urls := make(map[string]string, len(c.servers))
+	for name, s := range c.servers {
+		urls[name] = s.URL()
+	}
+
+	return urls
+}
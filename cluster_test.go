@@ -0,0 +1,69 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCluster checks that each server in a cluster serves its own mocks.
+func TestCluster(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	c := NewCluster([]string{"users", "billing"})
+	defer c.Close()
+
+	assert.Equal(t, []string{"users", "billing"}, c.Names(), "unexpected server names")
+	assert.NotEqual(t, c.URLs()["users"], c.URLs()["billing"], "servers share the same address")
+
+	c.Server("users").RegisterQuery("GetUser", DummyResponse{
+		StringResponse: StringResponse(`{"GetUser": {"id": "user"}}`),
+	})
+	c.Server("billing").RegisterQuery("GetInvoice", DummyResponse{
+		StringResponse: StringResponse(`{"GetInvoice": {"id": "invoice"}}`),
+	})
+
+	type testCase struct {
+		// The server where the request is sent to.
+		server string
+		// The GraphQL request to be sent to the mock server.
+		request string
+		// Whether the request should succeed.
+		ok bool
+	}
+
+	testCases := []testCase{{
+		server:  "users",
+		request: "query { GetUser { id } }",
+		ok:      true,
+	}, {
+		server:  "users",
+		request: "query { GetInvoice { id } }",
+		ok:      false,
+	}, {
+		server:  "billing",
+		request: "query { GetInvoice { id } }",
+		ok:      true,
+	}}
+
+	for _, tc := range testCases {
+		client := graphql.NewClient(c.URLs()[tc.server])
+
+		var resp map[string]any
+		err := client.Run(context.Background(), graphql.NewRequest(tc.request), &resp)
+		if tc.ok {
+			assert.NoError(t, err, "failed to send '%s' to %s", tc.request, tc.server)
+		} else {
+			assert.Error(t, err, "'%s' should have failed on %s", tc.request, tc.server)
+		}
+	}
+
+	assert.Panics(t, func() {
+		c.Server("unknown")
+	}, "retrieving an unknown server should panic")
+}
@@ -0,0 +1,190 @@
+// Command goraphql_mock_server starts a standalone mock GraphQL server,
+// configured from a file and from command line flags.
+//
+// Usage:
+//
+//	goraphql_mock_server [-config config.json] [-addr 127.0.0.1:8080] [-tls] [-admin /__admin] [-mocks mocks.json] [-watch dir] [-chaos profile]
+//
+// The configuration file is a JSON object such as:
+//
+//	{
+//		"address": "127.0.0.1:8080",
+//		"tls": false,
+//		"admin": "/__admin",
+//		"watch": "./mocks",
+//		"chaos": "flaky-network",
+//		"mocks": [{
+//			"identifier": "ListFoos",
+//			"variables": {"match": "keys", "keys": ["num"]},
+//			"response": {"ListFoos": {"foo": 123}}
+//		}]
+//	}
+//
+// Flags take precedence over the values in the configuration file,
+// and the mocks in the file (or directory) passed to -mocks are registered
+// after the ones in the configuration file.
+// See Server.LoadMocks for the accepted formats.
+//
+// The mock definitions in the directory passed to -watch are reloaded
+// whenever a file in that directory changes, so fixtures may be edited
+// without restarting the server.
+//
+// The faults of the profile passed to -chaos (e.g., "flaky-network") are simulated in every request.
+// See ChaosProfile for the available profiles.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	mock "github.com/SirGFM/goraphql_mock_server"
+)
+
+// config describes how the mock server should be started.
+type config struct {
+	// The address where the server listens on, as "host:port".
+	Address string `json:"address"`
+	// Whether the server should be started with TLS enabled.
+	TLS bool `json:"tls"`
+	// The path prefix for the admin API. If empty, the admin API is disabled.
+	Admin string `json:"admin"`
+	// A directory with mock definitions, reloaded whenever modified.
+	Watch string `json:"watch"`
+	// The profile of faults simulated in every request. If empty, no fault is simulated.
+	Chaos string `json:"chaos"`
+	// The mocks registered when the server starts.
+	Mocks []mock.MockDefinition `json:"mocks"`
+}
+
+func main() {
+	var cfg config
+
+	configPath := flag.String("config", "", "path to a JSON configuration file")
+	addr := flag.String("addr", "", "address to listen on, as host:port (default: a random local port)")
+	useTLS := flag.Bool("tls", false, "start the server with TLS enabled")
+	admin := flag.String("admin", "", "path prefix for the admin API (e.g., /__admin)")
+	mocksPath := flag.String("mocks", "", "path to a file (JSON or YAML) or a directory with mock definitions")
+	watch := flag.String("watch", "", "directory with mock definitions, reloaded whenever modified")
+	chaos := flag.String("chaos", "", "profile of faults simulated in every request (e.g., flaky-network)")
+	watchInterval := flag.Duration("watch-interval", time.Second, "how often the directory passed to -watch is checked for modifications")
+	flag.Parse()
+
+	if *configPath != "" {
+		if err := readJSON(*configPath, &cfg); err != nil {
+			fatal("failed to read the configuration: %v", err)
+		}
+	}
+
+	// Override the configuration with the flags that were explicitly set.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Address = *addr
+		case "tls":
+			cfg.TLS = *useTLS
+		case "admin":
+			cfg.Admin = *admin
+		case "watch":
+			cfg.Watch = *watch
+		case "chaos":
+			cfg.Chaos = *chaos
+		}
+	})
+
+	opts, err := cfg.options(*watchInterval)
+	if err != nil {
+		fatal("invalid configuration: %v", err)
+	}
+
+	s, err := mock.NewE(opts...)
+	if err != nil {
+		fatal("failed to start the server: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.RegisterDefinitions(cfg.Mocks...); err != nil {
+		s.Close()
+		fatal("invalid mock: %v", err)
+	}
+
+	if *mocksPath != "" {
+		if err := s.LoadMocks(*mocksPath); err != nil {
+			s.Close()
+			fatal("failed to load the mocks: %v", err)
+		}
+	}
+
+	fmt.Println(s.URL())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+}
+
+// options converts the configuration into the server's options.
+func (cfg config) options(watchInterval time.Duration) ([]mock.ServerOptions, error) {
+	var opts []mock.ServerOptions
+
+	if cfg.Address != "" {
+		host, portStr, err := net.SplitHostPort(cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port '%s': %w", portStr, err)
+		}
+
+		ip := net.ParseIP(host)
+		ipv6 := ip != nil && ip.To4() == nil
+		opts = append(opts, mock.WithAddress(host, uint16(port), ipv6))
+	}
+
+	if cfg.TLS {
+		opts = append(opts, mock.WithTLS())
+	}
+
+	if cfg.Admin != "" {
+		opts = append(opts, mock.WithAdmin(cfg.Admin))
+	}
+
+	if cfg.Watch != "" {
+		opts = append(opts, mock.WithMockDirectory(cfg.Watch, watchInterval))
+	}
+
+	if cfg.Chaos != "" {
+		profile := mock.ChaosProfile(cfg.Chaos)
+		if !profile.Valid() {
+			return nil, fmt.Errorf("unknown chaos profile '%s'", cfg.Chaos)
+		}
+
+		opts = append(opts, mock.WithChaos(profile))
+	}
+
+	return opts, nil
+}
+
+// readJSON decodes the JSON file at path into v.
+func readJSON(path string, v any) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewDecoder(f).Decode(v)
+}
+
+// fatal prints the message to stderr and exits.
+func fatal(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "goraphql_mock_server: "+format+"\n", args...)
+	os.Exit(1)
+}
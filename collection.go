@@ -0,0 +1,208 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// postmanCollection is a Postman collection, in the v2.1 format.
+type postmanCollection struct {
+	Item []postmanItem `json:"item"`
+}
+
+// postmanItem is either a request saved in a Postman collection or a folder of items.
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item"`
+	Request  *postmanRequest   `json:"request"`
+	Response []postmanResponse `json:"response"`
+}
+
+// postmanRequest is a request saved in a Postman collection.
+// Only the parts needed to identify GraphQL requests are decoded.
+type postmanRequest struct {
+	Method string `json:"method"`
+	Body   *struct {
+		Mode    string `json:"mode"`
+		Raw     string `json:"raw"`
+		GraphQL *struct {
+			Query     string `json:"query"`
+			Variables string `json:"variables"`
+		} `json:"graphql"`
+	} `json:"body"`
+}
+
+// postmanResponse is an example response saved along with a request in a Postman collection.
+type postmanResponse struct {
+	Code int    `json:"code"`
+	Body string `json:"body"`
+}
+
+// insomniaExport is an Insomnia export, in the v4 format.
+type insomniaExport struct {
+	Resources []insomniaResource `json:"resources"`
+}
+
+// insomniaResource is a single resource (e.g., a request or a workspace) in an Insomnia export.
+// Only the parts needed to identify GraphQL requests are decoded.
+type insomniaResource struct {
+	Type   string `json:"_type"`
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Body   struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	} `json:"body"`
+}
+
+// ImportPostman converts the GraphQL requests saved in a Postman collection (in the v2.1 format)
+// into mock definitions, which may then be registered with Server.RegisterDefinitions.
+//
+// Requests are converted if their body is either in the GraphQL mode
+// or a raw JSON object with a query.
+// Each definition is named after its request, uses the request's query as the identifier
+// and matches its variables, if any, exactly.
+// The data of the first successful example response saved for the request is used as the mocked response,
+// so requests without any example are mocked with a null response.
+//
+// Requests that aren't for GraphQL (e.g., the ones for REST endpoints) are skipped,
+// while GraphQL requests that can't be converted cause the import to fail.
+func ImportPostman(r io.Reader) ([]MockDefinition, error) {
+	var collection postmanCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: decode Postman collection: %w", err)
+	}
+
+	defs := []MockDefinition{}
+	if err := collectPostmanItems(collection.Item, &defs); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// collectPostmanItems converts every GraphQL request in the items (and in the folders among them)
+// into a mock definition, appending it to defs.
+func collectPostmanItems(items []postmanItem, defs *[]MockDefinition) error {
+	for _, item := range items {
+		if item.Request == nil {
+			if err := collectPostmanItems(item.Item, defs); err != nil {
+				return err
+			}
+			continue
+		}
+
+		def, ok, err := item.definition()
+		if err != nil {
+			return fmt.Errorf("goraphql_mock_server: Postman request '%s': %w", item.Name, err)
+		} else if ok {
+			*defs = append(*defs, def)
+		}
+	}
+
+	return nil
+}
+
+// definition converts the request saved in the item into a MockDefinition,
+// returning false if it isn't a GraphQL request.
+func (item postmanItem) definition() (MockDefinition, bool, error) {
+	body := item.Request.Body
+	if body == nil || (item.Request.Method != "" && item.Request.Method != http.MethodPost) {
+		return MockDefinition{}, false, nil
+	}
+
+	var req Request
+	switch {
+	case body.Mode == "graphql" && body.GraphQL != nil:
+		req.Query = body.GraphQL.Query
+		if body.GraphQL.Variables != "" {
+			if err := json.Unmarshal([]byte(body.GraphQL.Variables), &req.Variables); err != nil {
+				return MockDefinition{}, false, fmt.Errorf("invalid variables: %w", err)
+			}
+		}
+	case body.Mode == "raw":
+		// Raw bodies are only GraphQL requests if they are JSON objects with a query.
+		if err := json.Unmarshal([]byte(body.Raw), &req); err != nil {
+			return MockDefinition{}, false, nil
+		}
+	}
+	if req.Query == "" {
+		return MockDefinition{}, false, nil
+	}
+
+	def := collectedDefinition(item.Name, req)
+	for _, res := range item.Response {
+		if res.Code != 0 && res.Code != http.StatusOK {
+			continue
+		}
+
+		data, err := responseData([]byte(res.Body))
+		if err != nil {
+			return MockDefinition{}, false, err
+		}
+
+		def.Response = data
+		break
+	}
+
+	return def, true, nil
+}
+
+// ImportInsomnia converts the GraphQL requests in an Insomnia export (in the v4 JSON format)
+// into mock definitions, which may then be registered with Server.RegisterDefinitions.
+//
+// Requests are converted if their body is either in the GraphQL mode or a JSON object with a query,
+// exactly as in ImportPostman.
+// Since Insomnia doesn't export responses, every definition is mocked with a null response,
+// which should be replaced before the definitions are registered.
+func ImportInsomnia(r io.Reader) ([]MockDefinition, error) {
+	var export insomniaExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: decode Insomnia export: %w", err)
+	}
+
+	defs := []MockDefinition{}
+	for _, res := range export.Resources {
+		if res.Type != "request" || (res.Method != "" && res.Method != http.MethodPost) {
+			continue
+		}
+
+		switch res.Body.MimeType {
+		case "application/graphql", "application/json":
+		default:
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal([]byte(res.Body.Text), &req); err != nil || req.Query == "" {
+			continue
+		}
+
+		defs = append(defs, collectedDefinition(res.Name, req))
+	}
+
+	return defs, nil
+}
+
+// collectedDefinition creates the definition, named after a request saved in a collection
+// (or recorded in a cassette), that matches the request's query and variables.
+func collectedDefinition(name string, req Request) MockDefinition {
+	def := MockDefinition{
+		Identifier: req.Query,
+		Name:       name,
+	}
+
+	if op, ok := operationType(req.Query); ok {
+		def.Operation = op
+	}
+	if len(req.Variables) > 0 {
+		def.Variables = &VariablesDefinition{
+			Match:  MatchExact,
+			Values: req.Variables,
+		}
+	}
+
+	return def
+}
@@ -0,0 +1,129 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestImportPostman checks that the GraphQL requests in a Postman collection are converted into working mocks.
+func TestImportPostman(t *testing.T) {
+	const collection = `{
+		"info": {"name": "Foos", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [{
+			"name": "Foos",
+			"item": [{
+				"name": "List foos",
+				"request": {
+					"method": "POST",
+					"url": "{{baseUrl}}/graphql",
+					"body": {
+						"mode": "graphql",
+						"graphql": {"query": "query ListFoos($num: Int!) { ListFoos(num: $num) { foo } }", "variables": "{\"num\": 1}"}
+					}
+				},
+				"response": [{
+					"name": "Not found",
+					"code": 404,
+					"body": "not found"
+				}, {
+					"name": "Success",
+					"code": 200,
+					"body": "{\"data\": {\"ListFoos\": {\"foo\": 123}}}"
+				}]
+			}]
+		}, {
+			"name": "Create foo",
+			"request": {
+				"method": "POST",
+				"body": {"mode": "raw", "raw": "{\"query\": \"mutation { CreateFoo { id } }\"}"}
+			},
+			"response": []
+		}, {
+			"name": "Health check",
+			"request": {"method": "GET", "url": "{{baseUrl}}/health"},
+			"response": []
+		}]
+	}`
+
+	defs, err := ImportPostman(strings.NewReader(collection))
+	if !assert.NoError(t, err, "failed to import the collection") || !assert.Len(t, defs, 2, "unexpected number of requests") {
+		return
+	}
+	assert.Equal(t, "List foos", defs[0].Name, "unexpected name for the GraphQL request")
+	assert.Equal(t, OperationMutation, defs[1].Operation, "unexpected operation for the raw request")
+	assert.Empty(t, defs[1].Response, "a response was set for a request without examples")
+
+	s := New()
+	defer s.Close()
+
+	err = s.RegisterDefinitions(defs...)
+	if !assert.NoError(t, err, "failed to register the requests") {
+		return
+	}
+
+	client := graphql.NewClient(s.URL())
+
+	req := graphql.NewRequest(`query ListFoos($num: Int!) { ListFoos(num: $num) { foo } }`)
+	req.Var("num", 1)
+
+	var resp map[string]any
+	err = client.Run(context.Background(), req, &resp)
+	if assert.NoError(t, err, "failed to send the query") {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp, "unexpected response")
+	}
+
+	_, err = ImportPostman(strings.NewReader(`{"item": [{
+		"name": "Broken",
+		"request": {"method": "POST", "body": {"mode": "graphql", "graphql": {"query": "{ foo }", "variables": "{"}}}
+	}]}`))
+	assert.Error(t, err, "a request with invalid variables was imported")
+}
+
+// TestImportInsomnia checks that the GraphQL requests in an Insomnia export are converted into mock definitions.
+func TestImportInsomnia(t *testing.T) {
+	const export = `{
+		"_type": "export",
+		"__export_format": 4,
+		"resources": [{
+			"_id": "wrk_1",
+			"_type": "workspace",
+			"name": "Foos"
+		}, {
+			"_id": "req_1",
+			"_type": "request",
+			"parentId": "wrk_1",
+			"name": "List foos",
+			"method": "POST",
+			"body": {
+				"mimeType": "application/graphql",
+				"text": "{\"query\": \"query ListFoos($num: Int!) { ListFoos(num: $num) { foo } }\", \"variables\": {\"num\": 1}}"
+			}
+		}, {
+			"_id": "req_2",
+			"_type": "request",
+			"parentId": "wrk_1",
+			"name": "Health check",
+			"method": "GET",
+			"body": {}
+		}]
+	}`
+
+	defs, err := ImportInsomnia(strings.NewReader(export))
+	if !assert.NoError(t, err, "failed to import the export") {
+		return
+	}
+
+	assert.Equal(t, []MockDefinition{{
+		Identifier: "query ListFoos($num: Int!) { ListFoos(num: $num) { foo } }",
+		Name:       "List foos",
+		Operation:  OperationQuery,
+		Variables: &VariablesDefinition{
+			Match:  MatchExact,
+			Values: map[string]any{"num": 1.0},
+		},
+	}}, defs, "unexpected definitions")
+}
@@ -0,0 +1,102 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// tenantKey is the context key of the tenant injected by the middleware in TestContextMockedRequest.
+type tenantKey struct{}
+
+// tenantMock implements ContextMockedRequest, matching and answering requests by the tenant in their context.
+type tenantMock struct {
+	tenant string
+}
+
+// CompareVariables implements MockedRequest for tenantMock.
+func (m tenantMock) CompareVariables(v map[string]any) bool {
+	return false
+}
+
+// Response implements MockedRequest for tenantMock.
+func (m tenantMock) Response() any {
+	return nil
+}
+
+// CompareVariablesContext implements ContextVariablesMatcher for tenantMock.
+func (m tenantMock) CompareVariablesContext(ctx context.Context, v map[string]any) bool {
+	return ctx.Value(tenantKey{}) == m.tenant
+}
+
+// ResponseContext implements ContextResponder for tenantMock.
+func (m tenantMock) ResponseContext(ctx context.Context) any {
+	return map[string]any{"tenant": ctx.Value(tenantKey{})}
+}
+
+// tenantMatcher implements ContextVariablesMatcher, for mocks built by Match.
+type tenantMatcher string
+
+// CompareVariables implements VariablesMatcher for tenantMatcher.
+func (m tenantMatcher) CompareVariables(v map[string]any) bool {
+	return false
+}
+
+// CompareVariablesContext implements ContextVariablesMatcher for tenantMatcher.
+func (m tenantMatcher) CompareVariablesContext(ctx context.Context, v map[string]any) bool {
+	return ctx.Value(tenantKey{}) == string(m)
+}
+
+// TestContextMockedRequest checks that mocks implementing the context-aware interfaces receive the request's context.
+func TestContextMockedRequest(t *testing.T) {
+	injectTenant := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), tenantKey{}, r.Header.Get("X-Tenant"))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	s := New(WithMiddleware(injectTenant), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	var _ ContextMockedRequest = tenantMock{}
+	s.RegisterQuery("tenant", tenantMock{tenant: "acme"})
+	s.RegisterQuery("tenant", Match(tenantMatcher("globex")).Respond(JSON(`{"tenant": "matched"}`)))
+	s.RegisterQueryFunc("tenant", func(req Request) (any, error) {
+		return map[string]any{"tenant": req.Context().Value(tenantKey{})}, nil
+	})
+
+	type testCase struct {
+		// The tenant sent in the request's header.
+		tenant string
+		// The expected response.
+		expected map[string]any
+	}
+
+	testCases := []testCase{{
+		tenant:   "acme",
+		expected: map[string]any{"tenant": "acme"},
+	}, {
+		tenant:   "globex",
+		expected: map[string]any{"tenant": "matched"},
+	}, {
+		tenant:   "initech",
+		expected: map[string]any{"tenant": "initech"},
+	}}
+
+	for i, tc := range testCases {
+		req := graphql.NewRequest(`query { tenant }`)
+		req.Header.Set("X-Tenant", tc.tenant)
+
+		var resp map[string]any
+		err := s.GraphQLClient().Run(context.Background(), req, &resp)
+		if assert.NoError(t, err, "test %d", i) {
+			assert.Equal(t, tc.expected, resp, "test %d", i)
+		}
+	}
+}
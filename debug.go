@@ -0,0 +1,75 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithDebug logs, for every request, its query, its variables
+// and every registered mock that was tried, along with why it didn't match.
+//
+// The attempts are logged with slog.LevelInfo to the logger configured WithLogger.
+// If no logger was configured, they are written to the standard error.
+func WithDebug() ServerOptions {
+	return func(s *server) {
+		s.debug = true
+	}
+}
+
+// logAttempts logs every registered mock tried for the request, and why each didn't match.
+func (s *server) logAttempts(req Request, scope string) {
+	s.logger.Info("goraphql_mock_server: match attempts",
+		"query", compactQuery(req.Query),
+		"variables", req.Variables,
+		"attempts", s.matchAttempts(req, scope),
+	)
+}
+
+// matchAttempts describes the result of comparing the request against every registered mock
+// visible from the scope.
+func (s *server) matchAttempts(req Request, scope string) []string {
+	op, ok := operationType(req.Query)
+	if !ok && req.persistedID == "" {
+		return []string{"unsupported operation in the query"}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attempts := []string{}
+	for _, id := range s.sortedIdentifiers() {
+		for _, reg := range s.registrations[id] {
+			var result string
+
+			switch {
+			case !reg.visibleFrom(scope):
+				continue
+			case reg.persistedID != "" && reg.persistedID != req.persistedID:
+				result = "persisted query ID didn't match"
+			case reg.persistedID == "" && !strings.Contains(req.Query, id):
+				result = "identifier not found in the query"
+			case reg.persistedID == "" && reg.operation != op:
+				result = fmt.Sprintf("registered for a %s, but the request is a %s", reg.operation, op)
+			case !reg.compareVariables(req):
+				result = "variables didn't match"
+				if want, keysOnly, ok := expectedVariables(unwrapMatcher(reg.mock)); ok {
+					if diff := diffVariables(want, req.variables(), keysOnly); len(diff) > 0 {
+						result += " (" + strings.Join(diff, "; ") + ")"
+					}
+				}
+			case !reg.matchesHeaders(req.header):
+				result = "headers didn't match (" + strings.Join(reg.headerMismatches(req.header), "; ") + ")"
+			case reg.expired():
+				result = "expired"
+			case !reg.inTurn():
+				result = "not in turn in the replayed session"
+			default:
+				result = "matched"
+			}
+
+			attempts = append(attempts, reg.describe(s)+": "+result)
+		}
+	}
+
+	return attempts
+}
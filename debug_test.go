@@ -0,0 +1,42 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDebug checks that every match attempt is logged with the reason it failed.
+func TestDebug(t *testing.T) {
+	rec := &recorderT{TB: t}
+	s := NewT(rec, WithDebug())
+	defer rec.finish()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+	s.RegisterMutation("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"foo"},
+	})
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+	})
+
+	req := graphql.NewRequest(`query ($foo:String!) { ListFoos(foo:$foo) { foo } }`)
+	req.Var("foo", "bar")
+
+	var resp map[string]any
+	err := graphql.NewClient(s.URL()).Run(context.Background(), req, &resp)
+	assert.Error(t, err, "the query should have failed")
+
+	logs := strings.Join(rec.logs, "\n")
+	assert.Contains(t, logs, "ListFoos(foo:$foo)", "the query wasn't logged")
+	assert.Contains(t, logs, "query mock #0 for 'ListFoos' (goraphql_mock_server.SimpleMockedRequest): variables didn't match", "the variable mismatch wasn't logged")
+	assert.Contains(t, logs, "registered for a mutation, but the request is a query", "the operation mismatch wasn't logged")
+	assert.Contains(t, logs, "'ListBars' (goraphql_mock_server.SimpleMockedRequest): identifier not found in the query", "the identifier mismatch wasn't logged")
+}
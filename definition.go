@@ -0,0 +1,321 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MockDefinition describes a mocked request as data,
+// so it may be sent over the wire or stored in a file.
+type MockDefinition struct {
+	// The identifier used to match the request's query.
+	// See Server.RegisterQuery for details.
+	Identifier string `json:"identifier"`
+	// An optional human-readable name for the mock.
+	// See Named for details.
+	Name string `json:"name,omitempty"`
+	// The type of operation matched by the mock.
+	// If omitted, the mock matches queries.
+	Operation OperationType `json:"operation,omitempty"`
+	// How the request's variables are matched.
+	// If omitted, the request must not have any variable.
+	Variables *VariablesDefinition `json:"variables,omitempty"`
+	// The headers that requests must have to match the mock.
+	// See MatchHeader for details.
+	Headers map[string]string `json:"headers,omitempty"`
+	// The data sent as the response.
+	Response json.RawMessage `json:"response"`
+}
+
+// Accepted values for VariablesDefinition.Match.
+const (
+	// MatchNone requires the request to not have any variable.
+	MatchNone = "none"
+	// MatchAny matches the request regardless of its variables.
+	MatchAny = "any"
+	// MatchKeys requires the request's variables to have exactly the keys listed in VariablesDefinition.Keys.
+	MatchKeys = "keys"
+	// MatchExact requires the request's variables to be exactly VariablesDefinition.Values.
+	MatchExact = "exact"
+	// MatchCustom describes, in the output of Server.DumpMocks,
+	// variables compared by a custom Go type (named in VariablesDefinition.Type).
+	// Since the comparison can't be represented as data,
+	// definitions with this match can't be registered.
+	MatchCustom = "custom"
+)
+
+// VariablesDefinition describes how a MockDefinition matches the request's variables.
+type VariablesDefinition struct {
+	// How the variables are compared. One of MatchNone, MatchAny, MatchKeys or MatchExact.
+	Match string `json:"match"`
+	// The Go type that compares the variables, for MatchCustom.
+	Type string `json:"type,omitempty"`
+	// The keys used by MatchKeys.
+	Keys []string `json:"keys,omitempty"`
+	// The keys that requests may also have, for MatchKeys.
+	Optional []string `json:"optional,omitempty"`
+	// Whether requests may have keys other than Keys and Optional, for MatchKeys.
+	Extra bool `json:"extra,omitempty"`
+	// The values used by MatchExact.
+	Values map[string]any `json:"values,omitempty"`
+}
+
+// definedMock implements MockedRequest for a MockDefinition.
+type definedMock struct {
+	RawResponse
+	VariablesMatcher
+}
+
+// isDefinitionFile checks whether the file name has the extension of a mock definition file.
+func isDefinitionFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".json", ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeDefinitionFile decodes the mock definitions in the file,
+// detecting its format from its name.
+func decodeDefinitionFile(name string, data []byte) ([]MockDefinition, error) {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		// Convert the YAML document to JSON,
+		// so the definitions are decoded exactly as JSON ones.
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("goraphql_mock_server: decode %s: %w", name, err)
+		}
+
+		var err error
+		data, err = json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("goraphql_mock_server: decode %s: %w", name, err)
+		}
+	}
+
+	defs, err := decodeDefinitions(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return defs, nil
+}
+
+// decodeDefinitions decodes either a single MockDefinition or a list of them.
+func decodeDefinitions(data []byte) ([]MockDefinition, error) {
+	var defs []MockDefinition
+	var err error
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &defs)
+	} else {
+		defs = make([]MockDefinition, 1)
+		err = json.Unmarshal(data, &defs[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: decode mock definitions: %w", err)
+	}
+
+	return defs, nil
+}
+
+// definitionMocks converts every definition into a mock,
+// failing if any of them is invalid.
+func definitionMocks(defs []MockDefinition) ([]sourcedMock, error) {
+	mocks := make([]sourcedMock, len(defs))
+	for i, def := range defs {
+		mock, err := def.MockedRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		op := def.Operation
+		if op == "" {
+			op = OperationQuery
+		}
+
+		mocks[i] = sourcedMock{
+			identifier: def.Identifier,
+			operation:  op,
+			mock:       mock,
+			name:       def.Name,
+		}
+		if len(def.Headers) > 0 {
+			mocks[i].headers = make(http.Header)
+			for name, value := range def.Headers {
+				mocks[i].headers.Set(name, value)
+			}
+		}
+	}
+
+	return mocks, nil
+}
+
+// RegisterDefinitions implements Server for server.
+func (s *server) RegisterDefinitions(defs ...MockDefinition) error {
+	mocks, err := definitionMocks(defs)
+	if err != nil {
+		return err
+	}
+
+	return s.registerMocks(mocks)
+}
+
+// LoadMocks implements Server for server.
+func (s *server) LoadMocks(path string) error {
+	mocks, err := loadMocks(path)
+	if err != nil {
+		return err
+	}
+
+	return s.registerMocks(mocks)
+}
+
+// LoadMocksFS implements Server for server.
+func (s *server) LoadMocksFS(fsys fs.FS) error {
+	mocks, err := loadMocksFS(fsys)
+	if err != nil {
+		return err
+	}
+
+	return s.registerMocks(mocks)
+}
+
+// loadMocks decodes every mock defined in the file at path,
+// or in the files in the directory at path.
+func loadMocks(path string) ([]sourcedMock, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: load mocks: %w", err)
+	} else if info.IsDir() {
+		return loadMocksFS(os.DirFS(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: load mocks: %w", err)
+	}
+
+	defs, err := decodeDefinitionFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	mocks, err := definitionMocks(defs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return mocks, nil
+}
+
+// loadMocksFS decodes every mock defined in the files in fsys.
+func loadMocksFS(fsys fs.FS) ([]sourcedMock, error) {
+	var mocks []sourcedMock
+
+	// WalkDir visits the files in lexical order,
+	// so the mocks are always registered in the same order.
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if d.IsDir() || !isDefinitionFile(path) {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		defs, err := decodeDefinitionFile(path, data)
+		if err != nil {
+			return err
+		}
+
+		fileMocks, err := definitionMocks(defs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		mocks = append(mocks, fileMocks...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: load mocks: %w", err)
+	}
+
+	return mocks, nil
+}
+
+// MockedRequest converts the definition into a MockedRequest
+// that may be registered into a server.
+func (d MockDefinition) MockedRequest() (MockedRequest, error) {
+	if d.Identifier == "" {
+		return nil, errors.New("goraphql_mock_server: mock definition without an identifier")
+	}
+
+	switch d.Operation {
+	case "", OperationQuery, OperationMutation:
+	default:
+		return nil, fmt.Errorf("goraphql_mock_server: invalid operation '%s' for '%s'", d.Operation, d.Identifier)
+	}
+
+	var payload any
+	if len(d.Response) > 0 {
+		if err := json.Unmarshal(d.Response, &payload); err != nil {
+			return nil, fmt.Errorf("goraphql_mock_server: invalid response for '%s': %w", d.Identifier, err)
+		}
+	}
+
+	var matcher VariablesMatcher
+	match := MatchNone
+	if d.Variables != nil && d.Variables.Match != "" {
+		match = d.Variables.Match
+	}
+
+	switch match {
+	case MatchNone:
+		matcher = NoVariable{}
+	case MatchAny:
+		matcher = AnyVariables{}
+	case MatchKeys:
+		if len(d.Variables.Optional) > 0 || d.Variables.Extra {
+			matcher = KeyVariables{Required: d.Variables.Keys, Optional: d.Variables.Optional, Extra: d.Variables.Extra}
+		} else {
+			matcher = KeyOnlyVariables(d.Variables.Keys)
+		}
+	case MatchExact:
+		// Round-trip the values through JSON,
+		// so they are typed exactly as the request's decoded variables.
+		data, err := json.Marshal(d.Variables.Values)
+		if err != nil {
+			return nil, fmt.Errorf("goraphql_mock_server: invalid variables for '%s': %w", d.Identifier, err)
+		}
+
+		var values map[string]any
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("goraphql_mock_server: invalid variables for '%s': %w", d.Identifier, err)
+		}
+
+		matcher = Exact(values)
+	case MatchCustom:
+		return nil, fmt.Errorf("goraphql_mock_server: variables of '%s' are compared by the custom type %s, which can't be registered from a definition", d.Identifier, d.Variables.Type)
+	default:
+		return nil, fmt.Errorf("goraphql_mock_server: invalid variable match '%s' for '%s'", match, d.Identifier)
+	}
+
+	return definedMock{
+		RawResponse:      RawResponse{Payload: payload},
+		VariablesMatcher: matcher,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoadMocks checks that mocks are loaded from JSON and YAML files.
+func TestLoadMocks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"queries.json": &fstest.MapFile{
+			Data: []byte(`[{
+				"identifier": "ListFoos",
+				"variables": {"match": "exact", "values": {"num": 1}},
+				"response": {"ListFoos": {"foo": 123}}
+			}]`),
+		},
+		"mutations/create.yaml": &fstest.MapFile{
+			Data: []byte(`
+- identifier: CreateFoo
+  operation: mutation
+  variables:
+    match: keys
+    keys: [foo]
+  response:
+    CreateFoo:
+      id: abc
+`),
+		},
+		"README.md": &fstest.MapFile{
+			Data: []byte("not a mock"),
+		},
+	}
+
+	s := New()
+	defer s.Close()
+
+	err := s.LoadMocksFS(fsys)
+	if !assert.NoError(t, err, "failed to load the mocks") {
+		return
+	}
+
+	client := graphql.NewClient(s.URL())
+
+	type testCase struct {
+		// The GraphQL request to be sent to the mock server.
+		request string
+		// Variables used to customize the request.
+		variables map[string]any
+		// The expected response, or nil if the request should fail.
+		want map[string]any
+	}
+
+	testCases := []testCase{{
+		request: `query ($num:Integer!) { ListFoos(num:$num) { foo } }`,
+		variables: map[string]any{
+			"num": 1,
+		},
+		want: map[string]any{
+			"ListFoos": map[string]any{"foo": 123.0},
+		},
+	}, {
+		request: `query ($num:Integer!) { ListFoos(num:$num) { foo } }`,
+		variables: map[string]any{
+			"num": 2,
+		},
+	}, {
+		request: `mutation ($foo:String!) { CreateFoo(foo:$foo) { id } }`,
+		variables: map[string]any{
+			"foo": "bar",
+		},
+		want: map[string]any{
+			"CreateFoo": map[string]any{"id": "abc"},
+		},
+	}, {
+		request: `query ($foo:String!) { CreateFoo(foo:$foo) { id } }`,
+		variables: map[string]any{
+			"foo": "bar",
+		},
+	}}
+
+	for _, tc := range testCases {
+		req := graphql.NewRequest(tc.request)
+		for k, v := range tc.variables {
+			req.Var(k, v)
+		}
+
+		var resp map[string]any
+		err := client.Run(context.Background(), req, &resp)
+		if tc.want == nil {
+			assert.Error(t, err, "request '%s' should have failed", tc.request)
+		} else if assert.NoError(t, err, "failed to send request '%s'", tc.request) {
+			assert.Equal(t, tc.want, resp, "unexpected response for '%s'", tc.request)
+		}
+	}
+
+	err = s.LoadMocksFS(fstest.MapFS{
+		"invalid.yml": &fstest.MapFile{
+			Data: []byte("identifier: Foo\noperation: subscription\n"),
+		},
+	})
+	assert.Error(t, err, "an invalid operation was loaded")
+}
@@ -0,0 +1,132 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// expectedVariables retrieves the variables expected by the matcher,
+// so they may be compared to the request's variables.
+// keysOnly reports whether only the keys of the returned map are relevant.
+// It returns false if the expected variables can't be determined.
+func expectedVariables(m VariablesMatcher) (want map[string]any, keysOnly bool, ok bool) {
+	switch m := m.(type) {
+	case NoVariable:
+		return map[string]any{}, true, true
+	case KeyOnlyVariables:
+		want = make(map[string]any, len(m))
+		for _, k := range m {
+			want[k] = nil
+		}
+		return want, true, true
+	case ExactVariables:
+		want, ok = normalizeVariables(m.Variables)
+		return want, false, ok
+	default:
+		return nil, false, false
+	}
+}
+
+// normalizeVariables converts v into a map typed exactly like a request's decoded variables.
+func normalizeVariables(v any) (map[string]any, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+
+	var normalized map[string]any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, false
+	}
+
+	return normalized, true
+}
+
+// variablesDiff describes every difference between the expected and the received variables.
+type variablesDiff struct {
+	// The expected variables missing from the request.
+	Missing []string `json:"missing,omitempty"`
+	// The request's variables that weren't expected.
+	Unexpected []string `json:"unexpected,omitempty"`
+	// The variables whose values differ from the expected ones.
+	Values []valueDiff `json:"values,omitempty"`
+}
+
+// valueDiff describes a variable whose value differs from the expected one.
+type valueDiff struct {
+	// The variable's name.
+	Variable string `json:"variable"`
+	// The expected value.
+	Expected any `json:"expected"`
+	// The received value.
+	Got any `json:"got"`
+}
+
+// compareVariables finds every difference between the expected and the received variables.
+// If keysOnly is set, only the keys of the variables are compared.
+func compareVariables(want, got map[string]any, keysOnly bool) variablesDiff {
+	var diff variablesDiff
+
+	for _, k := range sortedKeys(want) {
+		gotValue, ok := got[k]
+		if !ok {
+			diff.Missing = append(diff.Missing, k)
+		} else if !keysOnly && !reflect.DeepEqual(want[k], gotValue) {
+			diff.Values = append(diff.Values, valueDiff{Variable: k, Expected: want[k], Got: gotValue})
+		}
+	}
+
+	for _, k := range sortedKeys(got) {
+		if _, ok := want[k]; !ok {
+			diff.Unexpected = append(diff.Unexpected, k)
+		}
+	}
+
+	return diff
+}
+
+// lines describes every difference, one per line.
+func (d variablesDiff) lines() []string {
+	var lines []string
+
+	for _, k := range d.Missing {
+		lines = append(lines, fmt.Sprintf("missing variable \"%s\"", k))
+	}
+	for _, v := range d.Values {
+		lines = append(lines, fmt.Sprintf("variable \"%s\": expected %s, got %s", v.Variable, encodeValue(v.Expected), encodeValue(v.Got)))
+	}
+	for _, k := range d.Unexpected {
+		lines = append(lines, fmt.Sprintf("unexpected variable \"%s\"", k))
+	}
+
+	return lines
+}
+
+// diffVariables describes every difference between the expected and the received variables.
+// If keysOnly is set, only the keys of the variables are compared.
+func diffVariables(want, got map[string]any, keysOnly bool) []string {
+	return compareVariables(want, got, keysOnly).lines()
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// encodeValue encodes the value as JSON, to be printed in messages.
+func encodeValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
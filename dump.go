@@ -0,0 +1,270 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// dumpResponseLength is the maximum length of the responses summarized by Dump.
+const dumpResponseLength = 80
+
+// Dump implements Server for server.
+func (s *server) Dump() string {
+	type entry struct {
+		reg     *registration
+		index   int
+		calls   int
+		expired bool
+	}
+
+	s.mu.RLock()
+	var entries []entry
+	for _, id := range s.sortedIdentifiers() {
+		for i, reg := range s.registrations[id] {
+			entries = append(entries, entry{reg: reg.snapshot(), index: i, calls: reg.calls, expired: reg.expired()})
+		}
+	}
+	def := s.defaults[""]
+	s.mu.RUnlock()
+
+	if len(entries) == 0 && def == nil {
+		return "no mocks registered\n"
+	}
+
+	// Summarize the responses without holding the lock,
+	// as mocks may do anything when generating their responses.
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d mocks registered:\n", len(entries))
+	for i, e := range entries {
+		if i == 0 || entries[i-1].reg.identifier != e.reg.identifier {
+			fmt.Fprintf(&b, "'%s':\n", e.reg.identifier)
+		}
+
+		fmt.Fprintf(&b, "\t#%d %s", e.index, e.reg.operation)
+		if e.reg.name != "" {
+			fmt.Fprintf(&b, " '%s'", e.reg.name)
+		}
+		fmt.Fprintf(&b, " (%T)", e.reg.mock)
+		if e.reg.scope != "" {
+			fmt.Fprintf(&b, " in scope %s", e.reg.scope)
+		}
+		fmt.Fprintf(&b, ", called %d times", e.calls)
+		if e.expired {
+			b.WriteString(", expired")
+		}
+		b.WriteString("\n")
+
+		fmt.Fprintf(&b, "\t\tvariables: %s\n", describeVariables(unwrapMatcher(e.reg.mock)))
+		names := make([]string, 0, len(e.reg.headers))
+		for name := range e.reg.headers {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "\t\theader %s: %s\n", name, e.reg.headers.Get(name))
+		}
+		fmt.Fprintf(&b, "\t\tresponse: %s\n", summarizeResponse(e.reg.mock))
+	}
+
+	if def != nil {
+		fmt.Fprintf(&b, "default (%T):\n\t\tresponse: %s\n", def.mock, summarizeResponse(def.mock))
+	}
+
+	return b.String()
+}
+
+// String implements fmt.Stringer for server, describing its mocks like Dump.
+func (s *server) String() string {
+	return s.Dump()
+}
+
+// describeVariables describes how the matcher compares the request's variables.
+func describeVariables(matcher VariablesMatcher) string {
+	switch m := matcher.(type) {
+	case AnyVariables:
+		return "any"
+	case KeyVariables:
+		desc := fmt.Sprintf("keys %v", m.Required)
+		if len(m.Optional) > 0 {
+			desc += fmt.Sprintf(", optionally %v", m.Optional)
+		}
+		if m.Extra {
+			desc += ", and any other key"
+		}
+		return desc
+	}
+
+	want, keysOnly, ok := expectedVariables(matcher)
+	switch {
+	case !ok:
+		return fmt.Sprintf("custom (%T)", matcher)
+	case keysOnly && len(want) == 0:
+		return "none"
+	case keysOnly:
+		return fmt.Sprintf("keys %v", sortedKeys(want))
+	default:
+		return "exactly " + encodeValue(want)
+	}
+}
+
+// summarizeResponse describes the mock's response, truncated to dumpResponseLength.
+func summarizeResponse(mock MockedRequest) (summary string) {
+	if _, ok := mock.(requestMock); ok {
+		return "generated for each request"
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			summary = fmt.Sprintf("failed to generate: %v", p)
+		}
+	}()
+
+	var res any
+	if m, ok := mock.(ErrorResponder); ok {
+		var err error
+		if res, err = m.ResponseE(); err != nil {
+			return fmt.Sprintf("error %q", err.Error())
+		}
+	} else {
+		res = mock.Response()
+	}
+
+	summary = encodeValue(res)
+	if runes := []rune(summary); len(runes) > dumpResponseLength {
+		summary = string(runes[:dumpResponseLength]) + "..."
+	}
+
+	return summary
+}
+
+// DumpMocks implements Server for server.
+func (s *server) DumpMocks() ([]byte, error) {
+	s.mu.RLock()
+	var regs []*registration
+	for _, id := range s.sortedIdentifiers() {
+		for _, reg := range s.registrations[id] {
+			regs = append(regs, reg.snapshot())
+		}
+	}
+	s.mu.RUnlock()
+
+	// Build the definitions without holding the lock,
+	// as mocks may do anything when generating their responses.
+	defs := make([]MockDefinition, 0, len(regs))
+	for _, reg := range regs {
+		def, err := dumpRegistration(reg)
+		if err != nil {
+			return nil, err
+		}
+
+		defs = append(defs, def)
+	}
+
+	data, err := json.MarshalIndent(defs, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: dump mocks: %w", err)
+	}
+
+	return data, nil
+}
+
+// dumpRegistration converts a registered mock into its MockDefinition.
+func dumpRegistration(reg *registration) (MockDefinition, error) {
+	def := MockDefinition{
+		Identifier: reg.identifier,
+		Name:       reg.name,
+		Operation:  reg.operation,
+	}
+
+	// Only the first value expected for each header may be represented in a definition.
+	for name := range reg.headers {
+		if def.Headers == nil {
+			def.Headers = make(map[string]string)
+		}
+		def.Headers[name] = reg.headers.Get(name)
+	}
+
+	data, err := json.Marshal(reg.mock.Response())
+	if err != nil {
+		return MockDefinition{}, fmt.Errorf("goraphql_mock_server: dump response for '%s': %w", reg.identifier, err)
+	}
+	def.Response = data
+
+	matcher := unwrapMatcher(reg.mock)
+	want, keysOnly, ok := expectedVariables(matcher)
+	kv, isKeys := matcher.(KeyVariables)
+	switch {
+	case matcher == (AnyVariables{}):
+		def.Variables = &VariablesDefinition{Match: MatchAny}
+	case isKeys:
+		def.Variables = &VariablesDefinition{Match: MatchKeys, Keys: kv.Required, Optional: kv.Optional, Extra: kv.Extra}
+	case !ok:
+		def.Variables = &VariablesDefinition{
+			Match: MatchCustom,
+			Type:  fmt.Sprintf("%T", reg.mock),
+		}
+	case keysOnly && len(want) == 0:
+		def.Variables = &VariablesDefinition{Match: MatchNone}
+	case keysOnly:
+		def.Variables = &VariablesDefinition{Match: MatchKeys, Keys: sortedKeys(want)}
+	default:
+		def.Variables = &VariablesDefinition{Match: MatchExact, Values: want}
+	}
+
+	return def, nil
+}
+
+// describable checks whether the matcher may be described by a VariablesDefinition.
+func describable(matcher VariablesMatcher) bool {
+	switch matcher.(type) {
+	case AnyVariables, KeyVariables:
+		return true
+	}
+
+	_, _, ok := expectedVariables(matcher)
+	return ok
+}
+
+// unwrapMatcher retrieves the matcher embedded into the mock,
+// so it may be described by expectedVariables.
+// Mocks are usually structs embedding one of the partial implementations in this package,
+// so the first embedded field that is a VariablesMatcher is returned.
+func unwrapMatcher(mock MockedRequest) VariablesMatcher {
+	switch mock := mock.(type) {
+	case definedMock:
+		return mock.VariablesMatcher
+	case funcMock:
+		return AnyVariables{}
+	case *expectedMock:
+		if mock.variables == nil {
+			return AnyVariables{}
+		}
+		return mock.variables
+	}
+
+	v := reflect.ValueOf(mock)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return mock
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.Anonymous || !field.IsExported() {
+			continue
+		}
+
+		if matcher, ok := v.Field(i).Interface().(VariablesMatcher); ok {
+			if describable(matcher) {
+				return matcher
+			}
+		}
+	}
+
+	return mock
+}
@@ -0,0 +1,198 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// customMatcher is a mock whose variables are compared by a custom type.
+type customMatcher struct {
+	RawResponse
+}
+
+// CompareVariables implements MockedRequest for customMatcher.
+func (customMatcher) CompareVariables(got map[string]any) bool {
+	return true
+}
+
+// TestDumpMocks checks that the registered mocks are exported as definitions
+// that may be registered into another server.
+func TestDumpMocks(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+	s.RegisterQuery("GetFoo", struct {
+		StringResponse
+		ExactVariables
+	}{
+		StringResponse: StringResponse(`{"GetFoo": {"foo": 1}}`),
+		ExactVariables: ExactVariables{Variables: map[string]any{"id": 1}},
+	})
+	s.RegisterMutation("CreateFoo", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"CreateFoo": {"id": "abc"}}`),
+	})
+
+	data, err := s.DumpMocks()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var defs []MockDefinition
+	err = json.Unmarshal(data, &defs)
+	assert.NoError(t, err)
+
+	if assert.Len(t, defs, 3) {
+		assert.Equal(t, "CreateFoo", defs[0].Identifier)
+		assert.Equal(t, OperationMutation, defs[0].Operation)
+		assert.Equal(t, &VariablesDefinition{Match: MatchNone}, defs[0].Variables)
+		assert.JSONEq(t, `{"CreateFoo": {"id": "abc"}}`, string(defs[0].Response))
+
+		assert.Equal(t, "GetFoo", defs[1].Identifier)
+		assert.Equal(t, &VariablesDefinition{Match: MatchExact, Values: map[string]any{"id": 1.0}}, defs[1].Variables)
+
+		assert.Equal(t, "ListFoos", defs[2].Identifier)
+		assert.Equal(t, &VariablesDefinition{Match: MatchKeys, Keys: []string{"num"}}, defs[2].Variables)
+	}
+
+	other := New()
+	defer other.Close()
+
+	err = other.RegisterDefinitions(defs...)
+	assert.NoError(t, err)
+
+	var resp map[string]any
+	err = graphql.NewClient(other.URL()).Run(
+		context.Background(),
+		graphql.NewRequest(`mutation { CreateFoo(foo: "bar") { id } }`),
+		&resp,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"CreateFoo": map[string]any{"id": "abc"}}, resp)
+}
+
+// TestDumpMocksExact checks that mocks matching exact variables are exported with their values.
+func TestDumpMocksExact(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	err := s.RegisterDefinitions(MockDefinition{
+		Identifier: "GetFoo",
+		Variables:  &VariablesDefinition{Match: MatchExact, Values: map[string]any{"id": 1}},
+		Response:   json.RawMessage(`{"GetFoo": {"foo": 1}}`),
+	})
+	assert.NoError(t, err)
+
+	s.RegisterQuery("Custom", customMatcher{})
+
+	data, err := s.DumpMocks()
+	assert.NoError(t, err)
+
+	var defs []MockDefinition
+	err = json.Unmarshal(data, &defs)
+	assert.NoError(t, err)
+
+	if assert.Len(t, defs, 2) {
+		assert.Equal(t, &VariablesDefinition{Match: MatchCustom, Type: "goraphql_mock_server.customMatcher"}, defs[0].Variables)
+		assert.Equal(t, &VariablesDefinition{Match: MatchExact, Values: map[string]any{"id": 1.0}}, defs[1].Variables)
+	}
+}
+
+// TestDumpMocksAny checks that mocks matching any variables, or optional keys, are exported as such.
+func TestDumpMocksAny(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	err := s.RegisterDefinitions(MockDefinition{
+		Identifier: "GetFoo",
+		Variables:  &VariablesDefinition{Match: MatchAny},
+		Response:   json.RawMessage(`{"GetFoo": {"foo": 1}}`),
+	})
+	assert.NoError(t, err)
+	s.RegisterQuery("ListFoos", struct {
+		StringResponse
+		AnyVariables
+	}{StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`)})
+	s.Expect("ListBars").Reply(JSON(`{"ListBars": {"bar": 123}}`))
+	s.RegisterQuery("ListBazs", Match(KeyOnlyVariables{"num"}.WithOptional("page")).Respond(JSON(`{"ListBazs": []}`)))
+
+	data, err := s.DumpMocks()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var defs []MockDefinition
+	err = json.Unmarshal(data, &defs)
+	assert.NoError(t, err)
+
+	if assert.Len(t, defs, 4) {
+		for i, def := range defs {
+			if def.Identifier != "ListBazs" {
+				assert.Equal(t, &VariablesDefinition{Match: MatchAny}, def.Variables, "test %d", i)
+				continue
+			}
+
+			assert.Equal(t, &VariablesDefinition{Match: MatchKeys, Keys: []string{"num"}, Optional: []string{"page"}}, def.Variables, "test %d", i)
+			mock, err := def.MockedRequest()
+			if assert.NoError(t, err, "test %d", i) {
+				assert.True(t, mock.CompareVariables(map[string]any{"num": 1.0, "page": 2.0}), "test %d", i)
+			}
+		}
+	}
+
+	req := graphql.NewRequest(`query ($id: ID!) { GetFoo(id: $id) { foo } }`)
+	req.Var("id", 1)
+
+	var resp map[string]any
+	err = graphql.NewClient(s.URL()).Run(context.Background(), req, &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"GetFoo": map[string]any{"foo": 1.0}}, resp)
+}
+
+// TestDump checks that the registered mocks are described in a human-readable form.
+func TestDump(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	assert.Equal(t, "no mocks registered\n", s.Dump())
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	}, Named("first page"), MatchHeader("Authorization", "Bearer token"))
+	s.RegisterQuery("ListFoos", Match(AnyVariables{}).Respond(RawResponse{Payload: strings.Repeat("a", 100)}))
+	s.RegisterMutationFunc("CreateFoo", func(req Request) (any, error) {
+		return nil, nil
+	})
+	s.SetDefault(Match(nil).Respond(JSON(`{}`)))
+
+	_ = graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest(`mutation { CreateFoo { id } }`), nil)
+
+	expected := `3 mocks registered:
+'CreateFoo':
+	#0 mutation (goraphql_mock_server.funcMock), called 1 times
+		variables: any
+		response: generated for each request
+'ListFoos':
+	#0 query 'first page' (goraphql_mock_server.SimpleMockedRequest), called 0 times
+		variables: keys [num]
+		header Authorization: Bearer token
+		response: {"ListFoos":{"foo":123}}
+	#1 query (*goraphql_mock_server.expectedMock), called 0 times
+		variables: any
+		response: "` + strings.Repeat("a", 79) + `...
+default (*goraphql_mock_server.expectedMock):
+		response: {}
+`
+	assert.Equal(t, expected, s.Dump())
+	assert.Equal(t, expected, fmt.Sprint(s))
+}
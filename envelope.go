@@ -0,0 +1,53 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"net/http"
+)
+
+// Envelope wraps a GraphQL response (i.e., its data, errors and extensions)
+// into the payload actually sent to the client, encoded as JSON.
+type Envelope func(res Response) any
+
+// WithEnvelope wraps every GraphQL response sent by the server,
+// either by a mock or describing a failure, into a nonstandard envelope,
+// so clients of gateways that don't send the standard shell may be tested.
+// For example, to send {"result": {"data": ...}, "meta": {...}}:
+//
+//	WithEnvelope(func(res Response) any {
+//		return map[string]any{"result": res, "meta": map[string]any{"version": 2}}
+//	})
+//
+// Responses that emulate other servers (e.g., Hasura) are sent as is.
+func WithEnvelope(envelope Envelope) ServerOptions {
+	return func(s *server) {
+		s.envelope = envelope
+	}
+}
+
+// envelopeKey is the key of the server's envelope in the context of the requests it receives.
+type envelopeKey struct{}
+
+// withEnvelope wraps the handler so every GraphQL response it sends is wrapped into the envelope.
+// The envelope is kept in the request's context, so it's found even by handlers
+// that render the response into another writer (e.g., to throttle it) or whose writers can't be unwrapped.
+func withEnvelope(envelope Envelope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), envelopeKey{}, envelope)))
+	})
+}
+
+// envelopeOf retrieves the envelope of the server that received the request, if any.
+func envelopeOf(r *http.Request) Envelope {
+	envelope, _ := r.Context().Value(envelopeKey{}).(Envelope)
+	return envelope
+}
+
+// wrap wraps the GraphQL response into the server's envelope, if any.
+func (s *server) wrap(res Response) any {
+	if s.envelope == nil {
+		return res
+	}
+
+	return s.envelope(res)
+}
@@ -0,0 +1,114 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEnvelope checks that every GraphQL response is wrapped into the server's envelope.
+func TestEnvelope(t *testing.T) {
+	s := New(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithEnvelope(func(res Response) any {
+			return map[string]any{"result": res, "meta": map[string]any{"version": 2}}
+		}),
+	)
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	type testCase struct {
+		// The query sent to the mock server.
+		query string
+		// The expected body of the response.
+		want string
+	}
+
+	testCases := []testCase{{
+		query: `query { ListFoos { foo } }`,
+		want:  `{"result": {"data": {"ListFoos": {"foo": 123}}}, "meta": {"version": 2}}`,
+	}, {
+		query: `query { ListBars { bar } }`,
+		want:  `{"result": {"data": null, "errors": [{"message": "goraphql_mock_server: mocked request not found", "path": null, "extensions": null}]}, "meta": {"version": 2}}`,
+	}}
+
+	for i, tc := range testCases {
+		resp, err := http.Post(s.URL(), "application/json", strings.NewReader(`{"query": "`+tc.query+`"}`))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+		assert.JSONEq(t, tc.want, string(body), "test %d", i)
+	}
+}
+
+// plainWriter wraps a http.ResponseWriter without exposing it (i.e., without Unwrap),
+// like the writers of some third-party middlewares.
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+// TestEnvelopeFaults checks that responses rendered into other writers,
+// by faults or by middlewares, are still wrapped into the server's envelope.
+func TestEnvelopeFaults(t *testing.T) {
+	type testCase struct {
+		// The fault applied to the mock.
+		opt RegisterOptions
+		// Whether the response is sent through a writer that can't be unwrapped.
+		plainWriter bool
+	}
+
+	testCases := []testCase{{
+		opt: Throttle(8, time.Millisecond),
+	}, {
+		opt: TruncateResponse(1 << 20),
+	}, {
+		opt: Malformed(MalformedJSON),
+	}, {
+		opt:         Named("no fault"),
+		plainWriter: true,
+	}, {
+		opt:         Malformed(MalformedJSON),
+		plainWriter: true,
+	}}
+
+	for i, tc := range testCases {
+		opts := []ServerOptions{
+			WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+			WithEnvelope(func(res Response) any {
+				return map[string]any{"result": res}
+			}),
+		}
+		if tc.plainWriter {
+			opts = append(opts, WithMiddleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(plainWriter{ResponseWriter: w}, r)
+				})
+			}))
+		}
+
+		s := New(opts...)
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		}, tc.opt)
+
+		resp, err := http.Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+		if assert.NoError(t, err, "test %d", i) {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.True(t, strings.HasPrefix(string(body), `{"result":{"data":{"ListFoos":{"foo":123}}`), "test %d: unexpected body %s", i, body)
+		}
+		s.Close()
+	}
+}
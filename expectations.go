@@ -0,0 +1,224 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ExpectationsWereMet implements Server for server.
+func (s *server) ExpectationsWereMet() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var unmet []string
+
+	for _, id := range s.sortedIdentifiers() {
+		for _, reg := range s.registrations[id] {
+			if msg := reg.unmetCalls(s); msg != "" {
+				unmet = append(unmet, msg)
+			}
+		}
+	}
+
+	unmet = append(unmet, s.orderViolations...)
+	unmet = append(unmet, s.ambiguities...)
+	unmet = append(unmet, describeUnmatched(s.unmatched.list())...)
+
+	return unmetExpectations(unmet)
+}
+
+// describeUnmatched describes every request that didn't match any mock, as an unmet expectation.
+func describeUnmatched(unmatched []RecordedRequest) []string {
+	var unmet []string
+	for _, entry := range unmatched {
+		unmet = append(unmet, fmt.Sprintf("request '%s' with variables %v didn't match any mock", compactQuery(entry.Query), entry.Variables))
+	}
+
+	return unmet
+}
+
+// unmetExpectations creates the error describing every unmet expectation.
+// It returns nil if every expectation was met.
+func unmetExpectations(unmet []string) error {
+	if len(unmet) == 0 {
+		return nil
+	}
+
+	return errors.New("goraphql_mock_server: expectations were not met:\n\t- " + strings.Join(unmet, "\n\t- "))
+}
+
+// AssertExpectations implements Server for server.
+func (s *server) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+
+	return assertExpectations(t, s.ExpectationsWereMet())
+}
+
+// assertExpectations fails the test if err describes any unmet expectation.
+func assertExpectations(t testing.TB, err error) bool {
+	t.Helper()
+
+	if err != nil {
+		t.Error(err)
+		return false
+	}
+
+	return true
+}
+
+// AssertNumberOfCalls implements Server for server.
+func (s *server) AssertNumberOfCalls(t testing.TB, identifier string, n int) bool {
+	t.Helper()
+
+	s.mu.RLock()
+	calls := s.calls(identifier)
+	s.mu.RUnlock()
+
+	return assertNumberOfCalls(t, identifier, n, calls)
+}
+
+// assertNumberOfCalls fails the test if the identifier wasn't called exactly n times.
+func assertNumberOfCalls(t testing.TB, identifier string, n int, calls int) bool {
+	t.Helper()
+
+	if calls != n {
+		t.Errorf("goraphql_mock_server: expected '%s' to be called %d times, but it was called %d times", identifier, n, calls)
+		return false
+	}
+
+	return true
+}
+
+// AssertCalledWith implements Server for server.
+func (s *server) AssertCalledWith(t testing.TB, identifier string, matcher VariablesMatcher) bool {
+	t.Helper()
+
+	return assertCalledWith(t, identifier, matcher, s.RequestsFor(identifier))
+}
+
+// assertCalledWith fails the test if none of the calls to the identifier have variables matching matcher.
+func assertCalledWith(t testing.TB, identifier string, matcher VariablesMatcher, calls []RecordedRequest) bool {
+	t.Helper()
+
+	if len(calls) == 0 {
+		t.Errorf("goraphql_mock_server: expected '%s' to be called with matching variables, but it was never called", identifier)
+		return false
+	}
+
+	for _, call := range calls {
+		if matcher.CompareVariables(call.Variables) {
+			return true
+		}
+	}
+
+	msg := fmt.Sprintf("goraphql_mock_server: none of the %d calls to '%s' matched the expected variables", len(calls), identifier)
+
+	want, keysOnly, ok := expectedVariables(matcher)
+	if !ok {
+		for i, call := range calls {
+			msg += fmt.Sprintf("\n\tcall #%d: %s", i, encodeValue(call.Variables))
+		}
+
+		t.Error(msg)
+		return false
+	}
+
+	// Report the call with the least differences.
+	var closest []string
+	closestIdx := -1
+	for i, call := range calls {
+		diff := diffVariables(want, call.Variables, keysOnly)
+		if closestIdx == -1 || len(diff) < len(closest) {
+			closest = diff
+			closestIdx = i
+		}
+	}
+
+	msg += fmt.Sprintf("\nclosest call (#%d): %s", closestIdx, encodeValue(calls[closestIdx].Variables))
+	if len(closest) == 0 {
+		closest = []string{"the variables only differ in their Go types (e.g., numbers are decoded as float64)"}
+	}
+	for _, line := range closest {
+		msg += "\n\t- " + line
+	}
+
+	t.Error(msg)
+	return false
+}
+
+// sortedIdentifiers returns every registered identifier, sorted.
+// s.mu must be held by the caller.
+func (s *server) sortedIdentifiers() []string {
+	identifiers := make([]string, 0, len(s.registrations))
+	for id := range s.registrations {
+		identifiers = append(identifiers, id)
+	}
+	sort.Strings(identifiers)
+
+	return identifiers
+}
+
+// compactQuery collapses every sequence of whitespaces in the query,
+// so it may be printed in a single line.
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// AssertBackoff implements Server for server.
+func (s *server) AssertBackoff(t testing.TB, identifier string, minGap time.Duration) bool {
+	t.Helper()
+
+	return assertBackoff(t, identifier, minGap, s.requests())
+}
+
+// assertBackoff fails the test if any of the calls to the identifier was retried less than minGap
+// after the previous attempt, or if none was retried.
+// Rejected attempts identical to some call (e.g., rate-limited ones) are also considered.
+func assertBackoff(t testing.TB, identifier string, minGap time.Duration, history []RecordedRequest) bool {
+	t.Helper()
+
+	keys := make(map[requestKey]bool)
+	for _, entry := range history {
+		if entry.Matched && entry.Identifier == identifier {
+			keys[entry.key()] = true
+		}
+	}
+	var calls []RecordedRequest
+	for _, entry := range history {
+		if (entry.Matched && entry.Identifier == identifier) || (entry.Rejected && keys[entry.key()]) {
+			calls = append(calls, entry)
+		}
+	}
+
+	var retries int
+	var violations []string
+	for _, call := range calls {
+		if call.Attempt <= 1 {
+			continue
+		}
+
+		retries++
+		if call.SincePrevious < minGap {
+			violations = append(violations, fmt.Sprintf("attempt #%d of '%s' was sent %s after the previous one",
+				call.Attempt,
+				compactQuery(call.Query),
+				call.SincePrevious,
+			))
+		}
+	}
+
+	switch {
+	case retries == 0:
+		t.Errorf("goraphql_mock_server: expected '%s' to be retried at least %s apart, but it was never retried", identifier, minGap)
+		return false
+	case len(violations) > 0:
+		t.Errorf("goraphql_mock_server: expected '%s' to be retried at least %s apart, but:\n\t%s", identifier, minGap, strings.Join(violations, "\n\t"))
+		return false
+	default:
+		return true
+	}
+}
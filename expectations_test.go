@@ -0,0 +1,480 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExpectationsWereMet checks that required mocks and unmatched requests are reported.
+func TestExpectationsWereMet(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, Required())
+	s.RegisterQuery("ListBars", DummyResponse{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+	}, Required())
+	s.RegisterQuery("ListOptional", DummyResponse{
+		StringResponse: StringResponse(`{"ListOptional": {"baz": 789}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+	send := func(query string) {
+		var resp map[string]any
+		_ = client.Run(context.Background(), graphql.NewRequest(query), &resp)
+	}
+
+	send("query { ListFoos { foo } }")
+	send("query { ListUnknown { id } }")
+
+	err := s.ExpectationsWereMet()
+	if assert.Error(t, err, "the expectations should have failed") {
+		assert.Contains(t, err.Error(), "'ListBars'", "the uncalled mock wasn't reported")
+		assert.Contains(t, err.Error(), "query { ListUnknown { id } }", "the unmatched request wasn't reported")
+		assert.NotContains(t, err.Error(), "'ListFoos'", "the called mock was reported")
+		assert.NotContains(t, err.Error(), "'ListOptional'", "the optional mock was reported")
+	}
+
+	mockT := &testing.T{}
+	assert.False(t, s.AssertExpectations(mockT), "AssertExpectations should have failed")
+	assert.True(t, mockT.Failed(), "AssertExpectations didn't fail the test")
+
+	ok := New()
+	defer ok.Close()
+
+	ok.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, Required())
+
+	client = graphql.NewClient(ok.URL())
+	send("query { ListFoos { foo } }")
+	assert.NoError(t, ok.ExpectationsWereMet(), "the expectations should have been met")
+	assert.True(t, ok.AssertExpectations(t), "AssertExpectations should have succeeded")
+}
+
+// TestNamedMocks checks that named mocks are identified by their name.
+func TestNamedMocks(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 1}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"page"},
+	}, Named("paginated ListFoos"))
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 2}}`),
+	}, Named("unpaginated ListFoos"), Required())
+
+	req := graphql.NewRequest(`query ($page: Int) { ListFoos(page: $page) { foo } }`)
+	req.Var("page", 1)
+	err := graphql.NewClient(s.URL()).Run(context.Background(), req, nil)
+	assert.NoError(t, err)
+
+	requests := s.Requests()
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "paginated ListFoos", requests[0].MockName)
+	}
+
+	err = s.ExpectationsWereMet()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "query mock 'unpaginated ListFoos' was never called")
+	}
+}
+
+// TestNumberOfCalls checks that the number of calls to each mock is verified.
+func TestNumberOfCalls(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	type testCase struct {
+		// The option used to register the mock.
+		opt RegisterOptions
+		// How many times the mock is called.
+		calls int
+		// Whether the expectations should be met.
+		ok bool
+	}
+
+	testCases := []testCase{{
+		opt:   Times(2),
+		calls: 2,
+		ok:    true,
+	}, {
+		opt:   Times(2),
+		calls: 1,
+		ok:    false,
+	}, {
+		opt:   Times(2),
+		calls: 3,
+		ok:    false,
+	}, {
+		opt:   MinTimes(2),
+		calls: 3,
+		ok:    true,
+	}, {
+		opt:   MinTimes(2),
+		calls: 1,
+		ok:    false,
+	}, {
+		opt:   MaxTimes(2),
+		calls: 0,
+		ok:    true,
+	}, {
+		opt:   MaxTimes(2),
+		calls: 3,
+		ok:    false,
+	}}
+
+	for _, tc := range testCases {
+		s := New()
+
+		s.RegisterQuery("ListFoos", DummyResponse{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		}, tc.opt)
+
+		client := graphql.NewClient(s.URL())
+		for range tc.calls {
+			var resp map[string]any
+			err := client.Run(context.Background(), graphql.NewRequest("query { ListFoos { foo } }"), &resp)
+			assert.NoError(t, err, "failed to send the query")
+		}
+
+		err := s.ExpectationsWereMet()
+		if tc.ok {
+			assert.NoError(t, err, "unexpected error after %d calls", tc.calls)
+		} else {
+			assert.Error(t, err, "expected an error after %d calls", tc.calls)
+		}
+
+		assert.True(t, s.AssertNumberOfCalls(t, "ListFoos", tc.calls), "unexpected number of calls")
+
+		mockT := &testing.T{}
+		assert.False(t, s.AssertNumberOfCalls(mockT, "ListFoos", tc.calls+1), "AssertNumberOfCalls should have failed")
+
+		s.Close()
+	}
+}
+
+// recorderT implements testing.TB, recording the reported errors and logs
+// so failed assertions may be tested.
+type recorderT struct {
+	testing.TB
+	// Every error reported to the test.
+	errors []string
+	// Every message logged by the test.
+	logs []string
+	// Every function registered to be called when the test finishes.
+	cleanups []func()
+}
+
+// Helper implements testing.TB for recorderT.
+func (*recorderT) Helper() {}
+
+// Log implements testing.TB for recorderT.
+func (r *recorderT) Log(args ...any) {
+	r.logs = append(r.logs, fmt.Sprint(args...))
+}
+
+// Logf implements testing.TB for recorderT.
+func (r *recorderT) Logf(format string, args ...any) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+// Cleanup implements testing.TB for recorderT.
+func (r *recorderT) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+// finish calls every registered cleanup function, in reverse order.
+func (r *recorderT) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+// Error implements testing.TB for recorderT.
+func (r *recorderT) Error(args ...any) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+// Errorf implements testing.TB for recorderT.
+func (r *recorderT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// TestAssertCalledWith checks that calls are searched by their variables.
+func TestAssertCalledWith(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num", "foo"},
+	})
+
+	client := graphql.NewClient(s.URL())
+	for _, num := range []int{1, 2} {
+		req := graphql.NewRequest(`query ($num:Integer!, $foo:String!) { ListFoos(num:$num, foo:$foo) { foo } }`)
+		req.Var("num", num)
+		req.Var("foo", "bar")
+
+		var resp map[string]any
+		err := client.Run(context.Background(), req, &resp)
+		assert.NoError(t, err, "failed to send the query")
+	}
+
+	type testCase struct {
+		// The identifier being asserted.
+		identifier string
+		// The matcher used in the assertion.
+		matcher VariablesMatcher
+		// Messages expected in the failure, or nil if the assertion should succeed.
+		errors []string
+	}
+
+	testCases := []testCase{{
+		identifier: "ListFoos",
+		matcher:    ExactVariables{Variables: map[string]any{"num": 2.0, "foo": "bar"}},
+	}, {
+		identifier: "ListFoos",
+		matcher:    ExactVariables{Variables: map[string]any{"num": 2, "foo": "bar"}},
+		errors:     []string{"closest call (#1)", "differ in their Go types"},
+	}, {
+		identifier: "ListFoos",
+		matcher:    KeyOnlyVariables{"num", "foo"},
+	}, {
+		identifier: "ListFoos",
+		matcher:    ExactVariables{Variables: map[string]any{"num": 3, "foo": "bar"}},
+		errors:     []string{`variable "num": expected 3, got 1`},
+	}, {
+		identifier: "ListFoos",
+		matcher:    ExactVariables{Variables: map[string]any{"num": 2}},
+		errors:     []string{"closest call (#1)", `unexpected variable "foo"`},
+	}, {
+		identifier: "ListFoos",
+		matcher:    KeyOnlyVariables{"num", "baz"},
+		errors:     []string{`missing variable "baz"`, `unexpected variable "foo"`},
+	}, {
+		identifier: "ListBars",
+		matcher:    NoVariable{},
+		errors:     []string{"never called"},
+	}}
+
+	for _, tc := range testCases {
+		rec := &recorderT{TB: t}
+		ok := s.AssertCalledWith(rec, tc.identifier, tc.matcher)
+
+		if tc.errors == nil {
+			assert.True(t, ok, "the assertion failed for %v", tc.matcher)
+			assert.Empty(t, rec.errors, "unexpected errors for %v", tc.matcher)
+		} else if assert.False(t, ok, "the assertion succeeded for %v", tc.matcher) && assert.Len(t, rec.errors, 1, "expected a single error") {
+			for _, msg := range tc.errors {
+				assert.Contains(t, rec.errors[0], msg, "missing message for %v", tc.matcher)
+			}
+		}
+	}
+}
+
+// TestInOrder checks that calls out of the declared order are reported.
+func TestInOrder(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	type testCase struct {
+		// The operations called, in order.
+		calls []string
+		// Whether the expectations should be met.
+		ok bool
+	}
+
+	testCases := []testCase{{
+		calls: []string{"Login", "ListFoos", "Logout"},
+		ok:    true,
+	}, {
+		calls: []string{"Login", "ListFoos", "ListFoos", "Logout", "ListFoos"},
+		ok:    true,
+	}, {
+		calls: []string{"Login", "Logout", "ListFoos"},
+		ok:    false,
+	}, {
+		calls: []string{"ListFoos", "Login", "Logout"},
+		ok:    false,
+	}}
+
+	for _, tc := range testCases {
+		s := New()
+
+		for _, id := range []string{"Login", "ListFoos", "Logout"} {
+			s.RegisterQuery(id, DummyResponse{
+				StringResponse: StringResponse(`{"` + id + `": {}}`),
+			})
+		}
+		s.InOrder("Login", "ListFoos", "Logout")
+
+		client := graphql.NewClient(s.URL())
+		for _, id := range tc.calls {
+			var resp map[string]any
+			err := client.Run(context.Background(), graphql.NewRequest("query { "+id+" { id } }"), &resp)
+			assert.NoError(t, err, "failed to send the query")
+		}
+
+		err := s.ExpectationsWereMet()
+		if tc.ok {
+			assert.NoError(t, err, "unexpected error for calls %v", tc.calls)
+		} else if assert.Error(t, err, "expected an error for calls %v", tc.calls) {
+			assert.Contains(t, err.Error(), "Login -> ListFoos -> Logout", "the expected order wasn't reported")
+		}
+
+		s.Close()
+	}
+}
+
+// TestAssertBackoff checks that retries sent too soon are reported.
+func TestAssertBackoff(t *testing.T) {
+	type testCase struct {
+		// The server's history.
+		calls []RecordedRequest
+		// Messages expected in the failure, or nil if the assertion should succeed.
+		errors []string
+	}
+
+	query := "query { ListFoos { foo } }"
+	testCases := []testCase{{
+		calls: []RecordedRequest{
+			{Query: query, Attempt: 1, Matched: true, Identifier: "ListFoos"},
+			{Query: query, Attempt: 2, SincePrevious: time.Second, Matched: true, Identifier: "ListFoos"},
+			{Query: query, Attempt: 3, SincePrevious: 2 * time.Second, Matched: true, Identifier: "ListFoos"},
+		},
+	}, {
+		calls: []RecordedRequest{
+			{Query: query, Attempt: 1, Matched: true, Identifier: "ListFoos"},
+			{Query: query, Attempt: 2, SincePrevious: time.Second, Matched: true, Identifier: "ListFoos"},
+			{Query: query, Attempt: 3, SincePrevious: 10 * time.Millisecond, Matched: true, Identifier: "ListFoos"},
+		},
+		errors: []string{"at least 1s apart", "attempt #3 of 'query { ListFoos { foo } }' was sent 10ms after the previous one"},
+	}, {
+		calls: []RecordedRequest{
+			{Query: query, Attempt: 1, Rejected: true},
+			{Query: query, Attempt: 2, SincePrevious: 10 * time.Millisecond, Rejected: true},
+			{Query: query, Attempt: 3, SincePrevious: 2 * time.Second, Matched: true, Identifier: "ListFoos"},
+		},
+		errors: []string{"attempt #2 of 'query { ListFoos { foo } }' was sent 10ms after the previous one"},
+	}, {
+		calls: []RecordedRequest{
+			{Query: "query { Login }", Attempt: 2, SincePrevious: 10 * time.Millisecond, Rejected: true},
+			{Query: query, Attempt: 1, Matched: true, Identifier: "ListFoos"},
+			{Query: query, Attempt: 2, SincePrevious: time.Second, Matched: true, Identifier: "ListFoos"},
+			{Query: query, Attempt: 3, SincePrevious: 10 * time.Millisecond, Matched: true, Identifier: "Other"},
+		},
+	}, {
+		calls: []RecordedRequest{
+			{Query: query, Attempt: 1, Matched: true, Identifier: "ListFoos"},
+		},
+		errors: []string{"never retried"},
+	}}
+
+	for i, tc := range testCases {
+		rec := &recorderT{TB: t}
+		ok := assertBackoff(rec, "ListFoos", time.Second, tc.calls)
+
+		if tc.errors == nil {
+			assert.True(t, ok, "test %d: the assertion failed", i)
+			assert.Empty(t, rec.errors, "test %d: unexpected errors", i)
+		} else if assert.False(t, ok, "test %d: the assertion succeeded", i) && assert.Len(t, rec.errors, 1, "test %d: expected a single error", i) {
+			for _, msg := range tc.errors {
+				assert.Contains(t, rec.errors[0], msg, "test %d: missing message", i)
+			}
+		}
+	}
+}
+
+// TestRetryAttempts checks that identical requests are recorded as retries.
+func TestRetryAttempts(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+
+	client := graphql.NewClient(s.URL())
+	for _, num := range []int{1, 2, 1} {
+		req := graphql.NewRequest(`query ($num:Integer!) { ListFoos(num:$num) { foo } }`)
+		req.Var("num", num)
+
+		var resp map[string]any
+		err := client.Run(context.Background(), req, &resp)
+		assert.NoError(t, err, "failed to send the query")
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	calls := s.RequestsFor("ListFoos")
+	if assert.Len(t, calls, 3) {
+		assert.Equal(t, []int{1, 1, 2}, []int{calls[0].Attempt, calls[1].Attempt, calls[2].Attempt})
+		assert.Zero(t, calls[0].SincePrevious)
+		assert.Zero(t, calls[1].SincePrevious)
+		assert.Equal(t, calls[2].Time.Sub(calls[0].Time), calls[2].SincePrevious)
+		assert.GreaterOrEqual(t, calls[2].SincePrevious, 20*time.Millisecond)
+	}
+
+	assert.True(t, s.AssertBackoff(t, "ListFoos", 20*time.Millisecond))
+}
+
+// TestRetryAttemptsEvicted checks that retries are detected even after the previous attempts are evicted from the history,
+// that only as many distinct requests as the history's size are tracked,
+// and that clearing the history forgets them.
+func TestRetryAttemptsEvicted(t *testing.T) {
+	s := New(WithMaxHistory(2))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+
+	client := graphql.NewClient(s.URL())
+	send := func(num int) RecordedRequest {
+		req := graphql.NewRequest(`query ($num:Integer!) { ListFoos(num:$num) { foo } }`)
+		req.Var("num", num)
+
+		var resp map[string]any
+		err := client.Run(context.Background(), req, &resp)
+		assert.NoError(t, err, "failed to send the query")
+
+		requests := s.Requests()
+		return requests[len(requests)-1]
+	}
+
+	assert.Equal(t, 1, send(1).Attempt)
+	assert.Equal(t, 1, send(2).Attempt)
+	assert.Equal(t, 2, send(1).Attempt)
+	assert.Equal(t, 3, send(1).Attempt)
+	assert.Equal(t, 1, send(3).Attempt)
+	assert.Equal(t, 1, send(2).Attempt, "the least recently received request wasn't evicted")
+	assert.Equal(t, 1, send(1).Attempt, "the least recently received request wasn't evicted")
+
+	s.Reset()
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+	assert.Equal(t, 1, send(1).Attempt, "the attempts weren't reset")
+}
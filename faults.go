@@ -0,0 +1,370 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Delay makes the mock respond only after the duration elapses,
+// so clients' timeouts, loading states and races may be tested.
+//
+// If the client cancels the request or the server is closed while waiting,
+// the request is dropped without a response.
+func Delay(d time.Duration) RegisterOptions {
+	return func(r *registration) {
+		r.delay += d
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if s.sleep(req.Context(), d) {
+					next.ServeHTTP(w, req)
+				}
+			})
+		})
+	}
+}
+
+// Hang makes the mock never respond,
+// only releasing the request once the client cancels it (or the server is closed),
+// so clients' deadlines and cancellation may be tested.
+func Hang() RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				select {
+				case <-req.Context().Done():
+				case <-s.done:
+				}
+			})
+		})
+	}
+}
+
+// ResetConnection makes the mock abruptly close the connection instead of responding,
+// so clients' handling of transport failures may be tested.
+//
+// If possible, the connection is reset (i.e., closed with a TCP RST),
+// otherwise it's simply closed.
+func ResetConnection() RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				conn, _, err := http.NewResponseController(w).Hijack()
+				if err != nil {
+					// The connection can't be hijacked (e.g., on HTTP/2),
+					// so let net/http abort it.
+					panic(http.ErrAbortHandler)
+				}
+
+				if tcp, ok := conn.(*net.TCPConn); ok {
+					_ = tcp.SetLinger(0)
+				}
+				_ = conn.Close()
+			})
+		})
+	}
+}
+
+// TruncateResponse makes the mock send only the first n bytes of its response's body,
+// closing the connection afterwards.
+// The response still advertises the length of the complete body,
+// so clients see an unexpected end of the body.
+func TruncateResponse(n int) RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				res := httptest.NewRecorder()
+				next.ServeHTTP(res, req)
+
+				body := res.Body.Bytes()
+				for k, v := range res.Header() {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				w.WriteHeader(res.Code)
+
+				if n < len(body) {
+					body = body[:n]
+				}
+				_, _ = w.Write(body)
+				_ = http.NewResponseController(w).Flush()
+
+				panic(http.ErrAbortHandler)
+			})
+		})
+	}
+}
+
+// Throttle makes the mock write its response's body in chunks of chunkSize bytes,
+// pausing for interval between them,
+// so clients' streaming readers and read timeouts may be tested.
+//
+// If the client cancels the request or the server is closed while writing,
+// the rest of the body is dropped.
+func Throttle(chunkSize int, interval time.Duration) RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				res := httptest.NewRecorder()
+				next.ServeHTTP(res, req)
+
+				body := res.Body.Bytes()
+				for k, v := range res.Header() {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				w.WriteHeader(res.Code)
+
+				rc := http.NewResponseController(w)
+				for len(body) > 0 {
+					n := min(chunkSize, len(body))
+					if _, err := w.Write(body[:n]); err != nil {
+						return
+					}
+					_ = rc.Flush()
+
+					body = body[n:]
+					if len(body) > 0 && !s.sleep(req.Context(), interval) {
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// ContentType makes the mock send its response with the Content-Type header set to contentType
+// (e.g., "text/html"), instead of "application/json",
+// so clients that branch on the header may be tested.
+// If contentType is empty, the response is sent without a Content-Type.
+func ContentType(contentType string) RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if contentType == "" {
+					// A nil value keeps net/http from detecting the content type.
+					w.Header()["Content-Type"] = nil
+				} else {
+					w.Header().Set("Content-Type", contentType)
+				}
+
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+}
+
+// Redirect makes the mock redirect requests to location with the status (e.g., http.StatusTemporaryRedirect),
+// so clients' redirect following (and whether they keep the request's method and body) may be tested.
+// location may be either a path in this server (e.g., "/v2/graphql") or the URL of another server.
+//
+// Requests already sent to location are answered with the mock's response,
+// so the redirected request is matched (and counted) again.
+//
+// If status isn't a redirect (3xx) status, or if location isn't a valid URL, the mock fails to be registered
+// (i.e., RegisterQueryE and RegisterMutationE return an error, and the other methods panic).
+func Redirect(status int, location string) RegisterOptions {
+	if status < 300 || status > 399 {
+		return func(r *registration) {
+			r.fail(fmt.Errorf("goraphql_mock_server: invalid redirect status %d", status))
+		}
+	}
+
+	target, err := url.Parse(location)
+	if err != nil {
+		return func(r *registration) {
+			r.fail(fmt.Errorf("goraphql_mock_server: invalid redirect location '%s': %w", location, err))
+		}
+	}
+
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				sameHost := target.Host == "" || target.Host == req.Host
+				if sameHost && target.Path == req.URL.Path {
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				http.Redirect(w, req, location, status)
+			})
+		})
+	}
+}
+
+// Malformation is a way in which Malformed breaks a mock's response.
+type Malformation int
+
+const (
+	// MalformedJSON sends a response that isn't valid JSON (its last character is dropped).
+	MalformedJSON Malformation = iota
+	// MalformedData sends a response whose data field isn't an object,
+	// but a list containing the mock's response.
+	MalformedData
+	// MalformedEnvelope sends the mock's response without the data/errors envelope.
+	MalformedEnvelope
+)
+
+// Malformed makes the mock send a broken response, with a 200,
+// so clients' handling of broken servers may be tested.
+func Malformed(kind Malformation) RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, matched *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch kind {
+				case MalformedData, MalformedEnvelope:
+					res, err := matched.response(req)
+					if err != nil {
+						matched.respondFailure(s, w, req, err)
+					} else if kind == MalformedData {
+						respond(w, http.StatusOK, map[string]any{"data": []any{res.Data}})
+					} else {
+						respond(w, http.StatusOK, res.Data)
+					}
+				default:
+					res := httptest.NewRecorder()
+					next.ServeHTTP(res, req)
+
+					body := bytes.TrimSpace(res.Body.Bytes())
+					if len(body) > 0 {
+						body = body[:len(body)-1]
+					}
+
+					for k, v := range res.Header() {
+						w.Header()[k] = v
+					}
+					w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+					w.WriteHeader(res.Code)
+					_, _ = w.Write(body)
+				}
+			})
+		})
+	}
+}
+
+// errFailFirst is the error sent for the requests failed by FailFirst.
+var errFailFirst = errors.New("goraphql_mock_server: mock configured to fail")
+
+// FailFirst makes the first n requests matching the mock fail,
+// answering them with the HTTP status and a GraphQL error,
+// and the following ones with the mock's response, so clients' retries may be tested.
+// Use http.StatusOK to fail with only a GraphQL error.
+func FailFirst(n int, status int) RegisterOptions {
+	return func(r *registration) {
+		var mu sync.Mutex
+		failures := 0
+
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				fail := failures < n
+				if fail {
+					failures++
+				}
+				mu.Unlock()
+
+				if fail {
+					respondError(w, req, status, errFailFirst, nil)
+				} else {
+					next.ServeHTTP(w, req)
+				}
+			})
+		})
+	}
+}
+
+// WithLatency delays every GraphQL request by base plus a random duration up to jitter,
+// so a whole suite may run under simulated network conditions without configuring each mock.
+//
+// The latency is added before the request is handled,
+// and it's dropped without a response if the client cancels it or the server is closed while waiting.
+func WithLatency(base, jitter time.Duration) ServerOptions {
+	return func(s *server) {
+		s.faults = append(s.faults, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				d := base
+				if jitter > 0 {
+					d += time.Duration(s.randInt64N(int64(jitter)))
+				}
+
+				if s.sleep(r.Context(), d) {
+					next.ServeHTTP(w, r)
+				}
+			})
+		})
+	}
+}
+
+// errInjected is the error sent for requests failed by WithErrorRate.
+var errInjected = errors.New("goraphql_mock_server: injected error")
+
+// WithErrorRate fails a fraction p (between 0 and 1) of the GraphQL requests,
+// randomly chosen, answering them with the HTTP status and a GraphQL error,
+// so retry logic may be soak tested.
+//
+// Use WithSeed to fail the same requests on every run.
+func WithErrorRate(p float64, status int) ServerOptions {
+	return func(s *server) {
+		s.faults = append(s.faults, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if s.randFloat64() < p {
+					s.logger.Warn("goraphql_mock_server: injected error", "status", status)
+					respondError(w, r, status, errInjected, nil)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+}
+
+// WithSeed seeds the random numbers used to simulate faults
+// (e.g., in WithErrorRate and WithLatency),
+// so they are reproducible across runs, as long as the requests are sent in the same order.
+func WithSeed(seed uint64) ServerOptions {
+	return func(s *server) {
+		s.rng = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// randFloat64 returns a random number in [0, 1).
+func (s *server) randFloat64() float64 {
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+
+	return s.rng.Float64()
+}
+
+// randInt64N returns a random number in [0, n).
+func (s *server) randInt64N(n int64) int64 {
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+
+	return s.rng.Int64N(n)
+}
+
+// sleep waits for the duration to elapse,
+// returning false if either the context is done or the server is closed before that.
+func (s *server) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-s.done:
+		return false
+	}
+}
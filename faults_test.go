@@ -0,0 +1,484 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDelay checks that delayed mocks respond only after their delay.
+func TestDelay(t *testing.T) {
+	s := New()
+
+	const delay = 100 * time.Millisecond
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, Delay(delay))
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+	}, Delay(time.Hour))
+
+	client := graphql.NewClient(s.URL())
+
+	start := time.Now()
+	var resp map[string]any
+	err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), delay, "the response wasn't delayed")
+	assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp)
+
+	// The client gives up before the delay elapses.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = client.Run(ctx, graphql.NewRequest(`query { ListBars { bar } }`), nil)
+	assert.Error(t, err)
+
+	// Pending delays don't block closing the server.
+	go func() {
+		_ = client.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), nil)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	start = time.Now()
+	s.Close()
+	assert.Less(t, time.Since(start), time.Second, "closing the server was blocked by the delay")
+}
+
+// TestWithLatency checks that every request is delayed by the configured latency.
+func TestWithLatency(t *testing.T) {
+	const base = 50 * time.Millisecond
+	const jitter = 20 * time.Millisecond
+
+	s := New(WithLatency(base, jitter))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), nil)
+		elapsed := time.Since(start)
+
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, elapsed, base, "request %d wasn't delayed", i)
+	}
+}
+
+// TestHang checks that hanging mocks only release the request once the client cancels it.
+func TestHang(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, Hang())
+
+	client := graphql.NewClient(s.URL())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.Run(ctx, graphql.NewRequest(`query { ListFoos { foo } }`), nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	requests := s.RequestsFor("ListFoos")
+	assert.Len(t, requests, 1, "the hanging request wasn't recorded")
+}
+
+// TestTransportFaults checks that mocks may fail at the transport level.
+func TestTransportFaults(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, ResetConnection())
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+	}, TruncateResponse(10))
+
+	type testCase struct {
+		// The GraphQL request to be sent to the mock server.
+		request string
+		// The expected prefix of the body, or empty if no response is expected.
+		body string
+	}
+
+	testCases := []testCase{{
+		request: `{"query": "query { ListFoos { foo } }"}`,
+	}, {
+		request: `{"query": "query { ListBars { bar } }"}`,
+		body:    `{"data":{"`,
+	}}
+
+	for i, tc := range testCases {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(tc.request))
+		if tc.body == "" {
+			assert.Error(t, err, "test %d: the connection wasn't reset", i)
+			continue
+		} else if !assert.NoError(t, err, "test %d: failed to send the request", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "test %d: the body wasn't truncated", i)
+		assert.Equal(t, tc.body, string(body), "test %d", i)
+	}
+}
+
+// TestMalformed checks that mocks may send broken responses.
+func TestMalformed(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	type testCase struct {
+		// How the response is broken.
+		kind Malformation
+		// The expected body.
+		body string
+	}
+
+	testCases := []testCase{{
+		kind: MalformedJSON,
+		body: `{"data":{"ListFoos":{"foo":123}}`,
+	}, {
+		kind: MalformedData,
+		body: `{"data":[{"ListFoos":{"foo":123}}]}`,
+	}, {
+		kind: MalformedEnvelope,
+		body: `{"ListFoos":{"foo":123}}`,
+	}}
+
+	for i, tc := range testCases {
+		s.Reset()
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		}, Malformed(tc.kind))
+
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+		if !assert.NoError(t, err, "test %d: failed to send the request", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d: failed to read the response", i)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "test %d", i)
+		assert.Equal(t, tc.body, strings.TrimSpace(string(body)), "test %d", i)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "application/json", "test %d", i)
+	}
+}
+
+// TestMalformedReplaced checks that malformed responses are generated by the mock matched by the request,
+// even while the mock is replaced concurrently.
+func TestMalformedReplaced(t *testing.T) {
+	s := New(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		// Give the mock time to be replaced between matching the request and sending the response.
+		WithRequestCallback(func(RecordedRequest) { time.Sleep(time.Millisecond) }),
+	)
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 0}}`),
+	}, Malformed(MalformedData))
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 1; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			s.ReplaceQuery("ListFoos", 0, SimpleMockedRequest{
+				StringResponse: StringResponse(fmt.Sprintf(`{"ListFoos": {"foo": %d}}`, i)),
+			})
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+		if !assert.NoError(t, err, "request %d: failed to send the request", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "request %d: failed to read the response", i)
+		assert.True(t, strings.HasPrefix(string(body), `{"data":[{"ListFoos":`), "request %d: unexpected body %s", i, body)
+	}
+	close(stop)
+	<-done
+}
+
+// TestWithErrorRate checks that a seeded fraction of the requests fails reproducibly.
+func TestWithErrorRate(t *testing.T) {
+	const requests = 200
+
+	run := func() []int {
+		s := New(WithErrorRate(0.25, http.StatusServiceUnavailable), WithSeed(42))
+		defer s.Close()
+
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		})
+
+		statuses := make([]int, requests)
+		for i := range statuses {
+			resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+			if assert.NoError(t, err, "request %d", i) {
+				resp.Body.Close()
+				statuses[i] = resp.StatusCode
+			}
+		}
+
+		return statuses
+	}
+
+	first := run()
+	assert.Equal(t, first, run(), "the failures weren't reproduced")
+
+	failed := 0
+	for _, status := range first {
+		if status == http.StatusServiceUnavailable {
+			failed++
+		}
+	}
+	assert.InDelta(t, requests/4, failed, requests/10, "unexpected number of failures")
+}
+
+// TestFailFirst checks that the first requests matching the mock fail.
+func TestFailFirst(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, FailFirst(2, http.StatusBadGateway))
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+	}, FailFirst(1, http.StatusOK))
+
+	type testCase struct {
+		// The GraphQL request to be sent to the mock server.
+		request string
+		// The expected HTTP status.
+		status int
+		// Whether a GraphQL error is expected in the response.
+		graphqlErr bool
+	}
+
+	testCases := []testCase{{
+		request:    `{"query": "query { ListFoos { foo } }"}`,
+		status:     http.StatusBadGateway,
+		graphqlErr: true,
+	}, {
+		request:    `{"query": "query { ListFoos { foo } }"}`,
+		status:     http.StatusBadGateway,
+		graphqlErr: true,
+	}, {
+		request: `{"query": "query { ListFoos { foo } }"}`,
+		status:  http.StatusOK,
+	}, {
+		request:    `{"query": "query { ListBars { bar } }"}`,
+		status:     http.StatusOK,
+		graphqlErr: true,
+	}, {
+		request: `{"query": "query { ListBars { bar } }"}`,
+		status:  http.StatusOK,
+	}}
+
+	for i, tc := range testCases {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(tc.request))
+		if !assert.NoError(t, err, "test %d: failed to send the request", i) {
+			continue
+		}
+
+		var res Response
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d: failed to decode the response", i)
+		assert.Equal(t, tc.status, resp.StatusCode, "test %d", i)
+		if tc.graphqlErr {
+			assert.Nil(t, res.Data, "test %d", i)
+			assert.Len(t, res.Errors, 1, "test %d", i)
+		} else {
+			assert.NotNil(t, res.Data, "test %d", i)
+			assert.Empty(t, res.Errors, "test %d", i)
+		}
+	}
+}
+
+// TestThrottle checks that throttled mocks write their response in chunks.
+func TestThrottle(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	const interval = 10 * time.Millisecond
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, Throttle(8, interval))
+
+	resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	start := time.Now()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data": {"ListFoos": {"foo": 123}}}`, string(body))
+
+	// The 34 bytes long body is written in 5 chunks.
+	assert.GreaterOrEqual(t, time.Since(start), 3*interval, "the body wasn't throttled")
+}
+
+// TestContentType checks that mocks may be sent with an unexpected Content-Type.
+func TestContentType(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	type testCase struct {
+		// The options used to register the mock.
+		opts []RegisterOptions
+		// The expected Content-Type.
+		contentType []string
+	}
+
+	testCases := []testCase{{
+		contentType: []string{"application/json"},
+	}, {
+		opts:        []RegisterOptions{ContentType("text/html")},
+		contentType: []string{"text/html"},
+	}, {
+		opts: []RegisterOptions{ContentType("")},
+	}}
+
+	for i, tc := range testCases {
+		s.Reset()
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		}, tc.opts...)
+
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+		if !assert.NoError(t, err, "test %d: failed to send the request", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d: failed to read the response", i)
+		assert.Equal(t, tc.contentType, resp.Header.Values("Content-Type"), "test %d", i)
+		assert.JSONEq(t, `{"data": {"ListFoos": {"foo": 123}}}`, string(body), "test %d", i)
+	}
+}
+
+// TestRedirect checks that mocks may redirect requests before responding.
+func TestRedirect(t *testing.T) {
+	other := New()
+	defer other.Close()
+
+	other.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+	})
+
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, Redirect(http.StatusPermanentRedirect, "/v2/graphql"))
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 123}}`),
+	}, Redirect(http.StatusTemporaryRedirect, other.URL()))
+
+	client := graphql.NewClient(s.URL())
+
+	var resp map[string]any
+	err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp)
+
+	requests := s.RequestsFor("ListFoos")
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, http.StatusPermanentRedirect, requests[0].Response.Status)
+		assert.Equal(t, "/v2/graphql", requests[1].URL)
+		assert.Equal(t, requests[0].Query, requests[1].Query, "the body wasn't kept")
+	}
+
+	resp = nil
+	err = client.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"ListBars": map[string]any{"bar": 456.0}}, resp)
+	assert.Len(t, other.RequestsFor("ListBars"), 1)
+
+}
+
+// TestRedirectInvalid checks that mocks redirecting with an invalid status or location fail to be registered.
+func TestRedirectInvalid(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	type testCase struct {
+		// The redirect's status.
+		status int
+		// The redirect's location.
+		location string
+		// The expected error.
+		err string
+	}
+
+	testCases := []testCase{{
+		status:   http.StatusOK,
+		location: "/",
+		err:      "goraphql_mock_server: invalid redirect status 200",
+	}, {
+		status:   http.StatusFound,
+		location: "http://[::1",
+		err:      "goraphql_mock_server: invalid redirect location 'http://[::1'",
+	}}
+
+	mock := SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}
+	for i, tc := range testCases {
+		err := s.RegisterQueryE("ListFoos", mock, Redirect(tc.status, tc.location))
+		if assert.Error(t, err, "test %d", i) {
+			assert.Contains(t, err.Error(), tc.err, "test %d", i)
+		}
+		assert.Empty(t, s.(*server).registrations["ListFoos"], "test %d: the mock was registered", i)
+
+		assert.PanicsWithValue(t, err.Error(), func() {
+			s.RegisterQuery("ListFoos", mock, Redirect(tc.status, tc.location))
+		}, "test %d", i)
+		assert.Panics(t, func() {
+			s.Expect("ListFoos").Reply(JSON(`{"ListFoos": {"foo": 123}}`)).With(Redirect(tc.status, tc.location))
+		}, "test %d", i)
+		s.Unregister("ListFoos")
+	}
+}
@@ -0,0 +1,171 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+)
+
+// joinGraphPattern matches the @join__graph directives in a supergraph SDL,
+// capturing the subgraph's name and its URL.
+var joinGraphPattern = regexp.MustCompile(`(@join__graph\(\s*name:\s*"([^"]*)"\s*,?\s*url:\s*")([^"]*)(")`)
+
+// Subgraph describes a subgraph of a federated graph, mocked by a Federation.
+type Subgraph struct {
+	// The subgraph's name, as in the @join__graph directives of the supergraph SDL (e.g., "users").
+	Name string
+	// The subgraph's schema, in SDL, sent to routers that introspect the subgraph
+	// (i.e., that query "_service { sdl }").
+	SDL string
+}
+
+// Federation manages a mock server for each subgraph of an Apollo Federation (v2) supergraph,
+// so routers and gateways may be tested entirely against mocks.
+//
+// Each subgraph's mock server is a regular Server,
+// where the queries sent by the router (e.g., for "_entities") must be registered.
+type Federation interface {
+	Cluster
+
+	// Supergraph returns the supergraph SDL,
+	// with the URL of every subgraph replaced by the address of its mock server.
+	Supergraph() string
+
+	// SupergraphURL returns the address that serves the supergraph SDL (as returned by Supergraph)
+	// to GET requests, so it may be fetched by the router or gateway.
+	// POST requests are answered as an Uplink,
+	// so routers may also poll it for the supergraph SDL (with APOLLO_UPLINK_ENDPOINTS).
+	SupergraphURL() string
+}
+
+// federation implements Federation.
+type federation struct {
+	Cluster
+	// The supergraph SDL, pointing to the mock servers.
+	supergraph string
+	// Serves the supergraph SDL.
+	server *httptest.Server
+	// Answers the routers polling for the supergraph SDL.
+	uplink *Uplink
+}
+
+// NewFederation starts a new mocked GraphQL server for each of the subgraphs,
+// configuring every one of them with the same options,
+// and serves the supergraph SDL (composed, e.g., by rover) pointing to them.
+// supergraph may be empty if the router composes the supergraph by introspecting the subgraphs.
+//
+// Every subgraph's server answers the router's "_service { sdl }" queries with its SDL.
+// These queries are answered before reaching the mocks,
+// so they are neither matched against nor recorded by the server.
+//
+// Be sure to call Close() when done with the federation!
+func NewFederation(supergraph string, subgraphs []Subgraph, opts ...ServerOptions) Federation {
+	c := cluster{
+		servers: make(map[string]Server, len(subgraphs)),
+	}
+
+	for _, subgraph := range subgraphs {
+		if _, ok := c.servers[subgraph.Name]; ok {
+			c.Close()
+			panic(fmt.Sprintf("goraphql_mock_server: duplicated subgraph name '%s' in federation", subgraph.Name))
+		}
+
+		subgraphOpts := append(opts[:len(opts):len(opts)], WithMiddleware(serviceSDL(subgraph.SDL)))
+		c.names = append(c.names, subgraph.Name)
+		c.servers[subgraph.Name] = New(subgraphOpts...)
+	}
+
+	urls := c.URLs()
+	supergraph = joinGraphPattern.ReplaceAllStringFunc(supergraph, func(directive string) string {
+		match := joinGraphPattern.FindStringSubmatch(directive)
+		url, ok := urls[match[2]]
+		if !ok {
+			return directive
+		}
+
+		return match[1] + url + match[4]
+	})
+
+	f := &federation{
+		Cluster:    &c,
+		supergraph: supergraph,
+		uplink:     newUplink(supergraph),
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			f.uplink.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, f.supergraph)
+	}))
+
+	return f
+}
+
+// Close implements Federation for federation.
+func (f *federation) Close() {
+	f.Cluster.Close()
+	f.server.Close()
+}
+
+// Supergraph implements Federation for federation.
+func (f *federation) Supergraph() string {
+	return f.supergraph
+}
+
+// SupergraphURL implements Federation for federation.
+func (f *federation) SupergraphURL() string {
+	return f.server.URL
+}
+
+// serviceSDL creates a middleware that answers the "_service { sdl }" queries
+// sent by routers to introspect a subgraph.
+func serviceSDL(sdl string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				respondError(w, r, http.StatusInternalServerError, fmt.Errorf("goraphql_mock_server: read request body: %w", err), nil)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var req Request
+			if err := json.Unmarshal(body, &req); err != nil || !isServiceQuery(req.Query) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			respondResponse(w, r, http.StatusOK, map[string]any{
+				"_service": map[string]string{"sdl": sdl},
+			})
+		})
+	}
+}
+
+// isServiceQuery checks whether the query only requests the subgraph's "_service" field.
+func isServiceQuery(query string) bool {
+	if !strings.Contains(query, "_service") {
+		return false
+	}
+
+	doc, err := parseQuery(query)
+	if err != nil || len(doc.operations) != 1 || doc.operations[0].kind != string(OperationQuery) {
+		return false
+	}
+
+	for _, sel := range doc.operations[0].selections {
+		if sel.kind != selectionField || (sel.name != "_service" && sel.name != "__typename") {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,67 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFederation checks that every subgraph is introspectable,
+// and that the supergraph SDL points to the subgraphs' mock servers.
+func TestFederation(t *testing.T) {
+	const supergraph = `schema @link(url: "https://specs.apollo.dev/join/v0.3", for: EXECUTION) { query: Query }
+
+enum join__Graph {
+  PRODUCTS @join__graph(name: "products", url: "http://localhost:4001/graphql")
+  REVIEWS @join__graph(name: "reviews", url: "http://localhost:4002/graphql")
+}`
+
+	f := NewFederation(supergraph, []Subgraph{{
+		Name: "products",
+		SDL:  `type Product @key(fields: "upc") { upc: String! name: String }`,
+	}, {
+		Name: "reviews",
+		SDL:  `type Review { body: String! product: Product }`,
+	}})
+	defer f.Close()
+
+	assert.Equal(t, []string{"products", "reviews"}, f.Names(), "unexpected subgraph names")
+	assert.Contains(t, f.Supergraph(), `PRODUCTS @join__graph(name: "products", url: "`+f.Server("products").URL()+`")`)
+	assert.Contains(t, f.Supergraph(), `REVIEWS @join__graph(name: "reviews", url: "`+f.Server("reviews").URL()+`")`)
+
+	resp, err := http.Get(f.SupergraphURL())
+	if assert.NoError(t, err, "failed to fetch the supergraph") {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "failed to read the supergraph")
+		assert.Equal(t, f.Supergraph(), string(body), "unexpected supergraph")
+	}
+
+	f.Server("products").RegisterQuery("_entities", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"_entities": [{"__typename": "Product", "name": "Table"}]}`),
+	})
+
+	client := graphql.NewClient(f.Server("products").URL())
+
+	var service map[string]map[string]string
+	err = client.Run(context.Background(), graphql.NewRequest(`query SubgraphIntrospectQuery { _service { sdl } }`), &service)
+	if assert.NoError(t, err, "failed to introspect the subgraph") {
+		assert.Equal(t, `type Product @key(fields: "upc") { upc: String! name: String }`, service["_service"]["sdl"])
+	}
+
+	var entities map[string]any
+	err = client.Run(context.Background(), graphql.NewRequest(`query { _entities(representations: []) { ... on Product { name } } }`), &entities)
+	if assert.NoError(t, err, "failed to resolve the entities") {
+		assert.Equal(t, map[string]any{"_entities": []any{map[string]any{"__typename": "Product", "name": "Table"}}}, entities)
+	}
+
+	requests := f.Server("products").Requests()
+	if assert.Len(t, requests, 2, "unexpected requests") {
+		assert.True(t, requests[0].Rejected, "the introspection query reached the mocks")
+		assert.False(t, requests[1].Rejected, "the entities query didn't reach the mocks")
+	}
+}
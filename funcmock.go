@@ -0,0 +1,117 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// QueryFunc generates the response to a request matched by a mock registered with RegisterQueryFunc.
+//
+// The returned value is sent as the response's data.
+// If an error is returned, its message is sent as a GraphQL error, along with any data.
+// Return a ResponseError to set the error's path and extensions.
+type QueryFunc func(req Request) (any, error)
+
+// funcMock implements MockedRequest for the mocks registered with RegisterQueryFunc,
+// matching any variables.
+// Its response depends on the request, so it's generated by respond instead of by Response.
+type funcMock struct {
+	fn QueryFunc
+}
+
+// Response implements MockedRequest for funcMock.
+// Since there's no request to respond to, it's always nil.
+func (m funcMock) Response() any {
+	return nil
+}
+
+// CompareVariables implements MockedRequest for funcMock.
+func (m funcMock) CompareVariables(v map[string]any) bool {
+	return true
+}
+
+// respond generates the response to the request.
+func (m funcMock) respond(req Request) Response {
+	req.Variables = req.variables()
+	return dataResponse(m.fn(req))
+}
+
+// dataResponse creates the response sending the data, along with the error, if any.
+// A ResponseError is sent as is, so mocks may set its path and extensions;
+// any other error is sent as its message.
+func dataResponse(data any, err error) Response {
+	if err == nil {
+		return Response{Data: data}
+	}
+
+	var resErr ResponseError
+	if !errors.As(err, &resErr) {
+		resErr = ResponseError{Message: err.Error()}
+	}
+
+	return Response{Data: data, Errors: []ResponseError{resErr}}
+}
+
+// RegisterQueryFunc implements Server for server.
+func (s *server) RegisterQueryFunc(identifier string, fn QueryFunc, opts ...RegisterOptions) {
+	s.register(identifier, OperationQuery, funcMock{fn: fn}, opts)
+}
+
+// RegisterMutationFunc implements Server for server.
+func (s *server) RegisterMutationFunc(identifier string, fn QueryFunc, opts ...RegisterOptions) {
+	s.register(identifier, OperationMutation, funcMock{fn: fn}, opts)
+}
+
+// RegisterQueryFunc implements Server for scopedServer.
+func (sc *scopedServer) RegisterQueryFunc(identifier string, fn QueryFunc, opts ...RegisterOptions) {
+	sc.mustRegisterIn(sc.id, identifier, OperationQuery, funcMock{fn: fn}, opts)
+}
+
+// RegisterMutationFunc implements Server for scopedServer.
+func (sc *scopedServer) RegisterMutationFunc(identifier string, fn QueryFunc, opts ...RegisterOptions) {
+	sc.mustRegisterIn(sc.id, identifier, OperationMutation, funcMock{fn: fn}, opts)
+}
+
+// requestMock is implemented by mocks whose response depends on the request (e.g., funcMock).
+type requestMock interface {
+	respond(req Request) Response
+}
+
+// response generates the mock's response to the request.
+// Mocks that panic while generating their response (e.g., an invalid StringResponse)
+// fail with an error, instead of crashing the handler.
+func (r *registration) response(req *http.Request) (res Response, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("goraphql_mock_server: failed to generate the response of %s: %v", r.logName(), p)
+		}
+	}()
+
+	if r.static != nil {
+		return Response{Data: r.static.data}, nil
+	} else if m, ok := r.mock.(requestMock); ok {
+		return m.respond(mockRequest(req)), nil
+	}
+
+	// Mocks built by Match and Expect respond like their responder.
+	var responder Responder = r.mock
+	if m, ok := r.mock.(*expectedMock); ok {
+		responder = m.responder
+	}
+
+	switch m := responder.(type) {
+	case ErrorResponder:
+		return dataResponse(m.ResponseE()), nil
+	case ContextResponder:
+		return Response{Data: m.ResponseContext(req.Context())}, nil
+	default:
+		return Response{Data: m.Response()}, nil
+	}
+}
+
+// respondFailure answers a request whose response couldn't be generated with a 500 and a GraphQL error.
+func (r *registration) respondFailure(s *server, w http.ResponseWriter, req *http.Request, err error) {
+	s.logger.Warn("goraphql_mock_server: failed to generate response", "mock", r.logName(), "error", err)
+	respondError(w, req, http.StatusInternalServerError, err, nil)
+}
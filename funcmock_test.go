@@ -0,0 +1,139 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRegisterQueryFunc checks that mocks registered with a closure respond according to the request.
+func TestRegisterQueryFunc(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQueryFunc("user", func(req Request) (any, error) {
+		if req.Variables["id"] == "missing" {
+			return map[string]any{"user": nil}, errors.New("user not found")
+		}
+
+		return map[string]any{"user": map[string]any{"id": req.Variables["id"]}}, nil
+	})
+	s.RegisterMutationFunc("deleteUser", func(req Request) (any, error) {
+		return map[string]any{"deleteUser": true}, nil
+	})
+
+	type testCase struct {
+		// The query sent to the server.
+		query string
+		// The value of the "id" variable.
+		id string
+		// The expected response.
+		expected map[string]any
+		// The expected error, if any.
+		err string
+	}
+
+	testCases := []testCase{{
+		query:    `query ($id: ID!) { user(id: $id) { id } }`,
+		id:       "1",
+		expected: map[string]any{"user": map[string]any{"id": "1"}},
+	}, {
+		query:    `query ($id: ID!) { user(id: $id) { id } }`,
+		id:       "2",
+		expected: map[string]any{"user": map[string]any{"id": "2"}},
+	}, {
+		query: `query ($id: ID!) { user(id: $id) { id } }`,
+		id:    "missing",
+		err:   "graphql: user not found",
+	}, {
+		query:    `mutation ($id: ID!) { deleteUser(id: $id) }`,
+		id:       "1",
+		expected: map[string]any{"deleteUser": true},
+	}}
+
+	for i, tc := range testCases {
+		req := graphql.NewRequest(tc.query)
+		req.Var("id", tc.id)
+
+		var resp map[string]any
+		err := s.GraphQLClient().Run(context.Background(), req, &resp)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err, "test %d", i)
+		} else if assert.NoError(t, err, "test %d", i) {
+			assert.Equal(t, tc.expected, resp, "test %d", i)
+		}
+	}
+
+	assert.Equal(t, map[string]int{"user": 3, "deleteUser": 1}, s.Counts())
+}
+
+// fileResponse implements ErrorResponder, failing if its file doesn't exist.
+type fileResponse struct {
+	KeyOnlyVariables
+	path string
+}
+
+// Response implements MockedRequest for fileResponse.
+func (r fileResponse) Response() any {
+	res, _ := r.ResponseE()
+	return res
+}
+
+// ResponseE implements ErrorResponder for fileResponse.
+func (r fileResponse) ResponseE() (any, error) {
+	if r.path != "user.json" {
+		return nil, ResponseError{Message: "fixture not found", Path: []string{"user"}, Extensions: map[string]any{"code": "NOT_FOUND"}}
+	}
+
+	return map[string]any{"user": map[string]any{"id": "1"}}, nil
+}
+
+// TestErrorResponder checks that the errors returned by ResponseE are sent as GraphQL errors.
+func TestErrorResponder(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("user", fileResponse{path: "user.json"})
+	s.RegisterQuery("missing", fileResponse{path: "missing.json"})
+	s.RegisterQuery("failing", Match(nil).Respond(fileResponse{path: "failing.json"}))
+
+	type testCase struct {
+		// The query sent to the server.
+		query string
+		// The expected response body.
+		expected string
+	}
+
+	testCases := []testCase{{
+		query:    `query { user { id } }`,
+		expected: `{"data": {"user": {"id": "1"}}}`,
+	}, {
+		query:    `query { missing { id } }`,
+		expected: `{"data": null, "errors": [{"message": "fixture not found", "path": ["user"], "extensions": {"code": "NOT_FOUND"}}]}`,
+	}, {
+		query:    `query { failing { id } }`,
+		expected: `{"data": null, "errors": [{"message": "fixture not found", "path": ["user"], "extensions": {"code": "NOT_FOUND"}}]}`,
+	}}
+
+	for i, tc := range testCases {
+		body, _ := json.Marshal(Request{Query: tc.query})
+		resp, err := s.Client().Post(s.URL(), "application/json", bytes.NewReader(body))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "test %d", i)
+		assert.JSONEq(t, tc.expected, string(data), "test %d", i)
+	}
+}
@@ -0,0 +1,189 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// harVersion is the version of the HAR format written by WriteHAR.
+const harVersion = "1.2"
+
+// harFile is the root of a HAR (HTTP Archive) file.
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+// harLog lists every entry in a HAR file.
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+// harCreator describes the application that created a HAR file.
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// harEntry describes a single exchange (a request and its response) in a HAR file.
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+// harRequest describes a request in a HAR file.
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// harResponse describes a response in a HAR file.
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// harNameValue is a generic name/value pair (e.g., a header) in a HAR file.
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harPostData describes the body of a request in a HAR file.
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// harContent describes the body of a response in a HAR file.
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// harTimings describes how long each phase of an exchange took, in milliseconds.
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// WriteHAR implements Server for server.
+func (s *server) WriteHAR(w io.Writer) error {
+	return writeHAR(w, s.requests())
+}
+
+// writeHAR writes the requests, along with their responses, to w as a HAR file.
+func writeHAR(w io.Writer, history []RecordedRequest) error {
+	har := harFile{
+		Log: harLog{
+			Version: harVersion,
+			Creator: harCreator{
+				Name:    "goraphql_mock_server",
+				Version: harVersion,
+			},
+			Entries: make([]harEntry, 0, len(history)),
+		},
+	}
+
+	for _, req := range history {
+		har.Log.Entries = append(har.Log.Entries, newHAREntry(req))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(har); err != nil {
+		return fmt.Errorf("goraphql_mock_server: write HAR: %w", err)
+	}
+
+	return nil
+}
+
+// newHAREntry converts a recorded request (and its response) into a HAR entry.
+func newHAREntry(req RecordedRequest) harEntry {
+	entry := harEntry{
+		StartedDateTime: req.Time.Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			PostData: &harPostData{
+				MimeType: req.Header.Get("Content-Type"),
+				Text:     string(req.body),
+			},
+			HeadersSize: -1,
+			BodySize:    len(req.body),
+		},
+		Response: harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+
+	if res := req.Response; res != nil {
+		wait := float64(res.Duration) / float64(time.Millisecond)
+
+		entry.Time = wait
+		entry.Timings.Wait = wait
+		entry.Response.Status = res.Status
+		entry.Response.StatusText = http.StatusText(res.Status)
+		entry.Response.Headers = harHeaders(res.Header)
+		entry.Response.Content = harContent{
+			Size:     len(res.Body),
+			MimeType: res.Header.Get("Content-Type"),
+			Text:     res.Body,
+		}
+		entry.Response.BodySize = len(res.Body)
+	}
+
+	return entry
+}
+
+// harHeaders converts HTTP headers into HAR name/value pairs, sorted by their name.
+func harHeaders(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+
+	return pairs
+}
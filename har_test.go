@@ -0,0 +1,93 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWriteHAR checks that the recorded traffic is exported as a HAR file.
+func TestWriteHAR(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+
+	type testCase struct {
+		// The GraphQL request to be sent to the mock server.
+		request string
+		// The expected HTTP status of the response.
+		status int
+		// The expected body of the response.
+		body string
+	}
+
+	testCases := []testCase{{
+		request: `query { ListFoos { foo } }`,
+		status:  http.StatusOK,
+		body:    `{"data":{"ListFoos":{"foo":123}}}`,
+	}, {
+		request: `query { ListBars { bar } }`,
+		status:  http.StatusNotFound,
+		body:    `{"data":null,"errors":[{"message":"goraphql_mock_server: mocked request not found","path":null,"extensions":null}]}`,
+	}}
+
+	for _, tc := range testCases {
+		_ = client.Run(context.Background(), graphql.NewRequest(tc.request), nil)
+	}
+
+	var buf bytes.Buffer
+	err := s.WriteHAR(&buf)
+	assert.NoError(t, err)
+
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method   string `json:"method"`
+					URL      string `json:"url"`
+					PostData struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &har)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1.2", har.Log.Version)
+	if !assert.Len(t, har.Log.Entries, len(testCases)) {
+		return
+	}
+
+	for i, tc := range testCases {
+		entry := har.Log.Entries[i]
+
+		var req Request
+		err := json.Unmarshal([]byte(entry.Request.PostData.Text), &req)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.MethodPost, entry.Request.Method)
+		assert.Equal(t, "/", entry.Request.URL)
+		assert.Equal(t, tc.request, req.Query)
+		assert.Equal(t, tc.status, entry.Response.Status)
+		assert.JSONEq(t, tc.body, entry.Response.Content.Text)
+	}
+}
@@ -0,0 +1,108 @@
+package goraphql_mock_server
+
+import (
+	"net/http"
+)
+
+// Headers used by Hasura to authorize requests.
+const (
+	// HasuraAdminSecretHeader holds the secret that authorizes requests as an admin.
+	HasuraAdminSecretHeader = "X-Hasura-Admin-Secret"
+	// HasuraRoleHeader holds the role the request is executed as.
+	HasuraRoleHeader = "X-Hasura-Role"
+)
+
+// Codes of the errors sent by Hasura, in their extensions.
+const (
+	// HasuraAccessDenied is sent for requests that aren't authorized.
+	HasuraAccessDenied = "access-denied"
+	// HasuraValidationFailed is sent for queries that aren't valid in the (role's) schema.
+	HasuraValidationFailed = "validation-failed"
+	// HasuraConstraintViolation is sent for mutations that violate a database constraint.
+	HasuraConstraintViolation = "constraint-violation"
+	// HasuraPermissionError is sent for mutations that the role isn't allowed to perform.
+	HasuraPermissionError = "permission-error"
+)
+
+// Messages sent by Hasura for requests with a missing or an invalid admin secret.
+const (
+	hasuraMissingSecret = "x-hasura-admin-secret/x-hasura-access-key required, but not found"
+	hasuraInvalidSecret = "invalid x-hasura-admin-secret/x-hasura-access-key"
+)
+
+// hasuraErrorBody is an error response, shaped like the ones sent by Hasura (i.e., without any data).
+type hasuraErrorBody struct {
+	Errors []hasuraError `json:"errors"`
+}
+
+// hasuraError is an error, shaped like the ones sent by Hasura.
+type hasuraError struct {
+	Message    string                `json:"message"`
+	Extensions hasuraErrorExtensions `json:"extensions"`
+}
+
+// hasuraErrorExtensions are the extensions of an error sent by Hasura.
+type hasuraErrorExtensions struct {
+	Path string `json:"path"`
+	Code string `json:"code"`
+}
+
+// HasuraAdminSecret makes the mock only match requests authorized with the admin secret.
+func HasuraAdminSecret(secret string) RegisterOptions {
+	return MatchHeader(HasuraAdminSecretHeader, secret)
+}
+
+// HasuraRole makes the mock only match requests executed as the role.
+func HasuraRole(role string) RegisterOptions {
+	return MatchHeader(HasuraRoleHeader, role)
+}
+
+// HasuraError makes the mock answer with an error shaped like the ones sent by Hasura
+// (e.g., with the code HasuraConstraintViolation) instead of its response.
+// Like Hasura, the error is sent with a 200 and without any data.
+func HasuraError(code, message string) RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				respondHasuraError(w, code, message)
+			})
+		})
+	}
+}
+
+// WithHasuraAdminSecret rejects every GraphQL request without the admin secret
+// in its X-Hasura-Admin-Secret header, with the same error sent by Hasura,
+// so clients' authorization may be tested.
+//
+// Rejected requests are recorded as Rejected.
+func WithHasuraAdminSecret(secret string) ServerOptions {
+	return func(s *server) {
+		s.faults = append(s.faults, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch got := r.Header.Get(HasuraAdminSecretHeader); got {
+				case secret:
+					next.ServeHTTP(w, r)
+				case "":
+					s.logger.Warn("goraphql_mock_server: missing Hasura admin secret")
+					respondHasuraError(w, HasuraAccessDenied, hasuraMissingSecret)
+				default:
+					s.logger.Warn("goraphql_mock_server: invalid Hasura admin secret")
+					respondHasuraError(w, HasuraAccessDenied, hasuraInvalidSecret)
+				}
+			})
+		})
+	}
+}
+
+// respondHasuraError sends an error shaped like the ones sent by Hasura.
+func respondHasuraError(w http.ResponseWriter, code, message string) {
+	respond(w, http.StatusOK, hasuraErrorBody{
+		Errors: []hasuraError{{
+			Message: message,
+			Extensions: hasuraErrorExtensions{
+				Path: "$",
+				Code: code,
+			},
+		}},
+	})
+}
@@ -0,0 +1,113 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHasura checks that requests are matched by their Hasura headers, and that Hasura-shaped errors are sent.
+func TestHasura(t *testing.T) {
+	s := New(WithHasuraAdminSecret("secret"), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("users", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"users": [{"id": 1, "email": "foo@example.com"}]}`),
+	}, HasuraRole("admin"))
+	s.RegisterQuery("users", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"users": [{"id": 1}]}`),
+	}, HasuraRole("user"))
+	s.RegisterMutation("insert_users", SimpleMockedRequest{}, HasuraError(HasuraConstraintViolation, `Uniqueness violation. duplicate key value violates unique constraint "users_email_key"`))
+
+	type testCase struct {
+		// The request's body.
+		body string
+		// The request's headers.
+		headers map[string]string
+		// The expected response.
+		expected string
+	}
+
+	testCases := []testCase{{
+		body:     `{"query": "query { users { id email } }"}`,
+		headers:  map[string]string{"X-Hasura-Admin-Secret": "secret", "X-Hasura-Role": "admin"},
+		expected: `{"data": {"users": [{"id": 1, "email": "foo@example.com"}]}}`,
+	}, {
+		body:     `{"query": "query { users { id } }"}`,
+		headers:  map[string]string{"X-Hasura-Admin-Secret": "secret", "X-Hasura-Role": "user"},
+		expected: `{"data": {"users": [{"id": 1}]}}`,
+	}, {
+		body:     `{"query": "mutation { insert_users(objects: [{email: \"foo@example.com\"}]) { affected_rows } }"}`,
+		headers:  map[string]string{"X-Hasura-Admin-Secret": "secret"},
+		expected: `{"errors": [{"message": "Uniqueness violation. duplicate key value violates unique constraint \"users_email_key\"", "extensions": {"path": "$", "code": "constraint-violation"}}]}`,
+	}, {
+		body:     `{"query": "query { users { id } }"}`,
+		expected: `{"errors": [{"message": "x-hasura-admin-secret/x-hasura-access-key required, but not found", "extensions": {"path": "$", "code": "access-denied"}}]}`,
+	}, {
+		body:     `{"query": "query { users { id } }"}`,
+		headers:  map[string]string{"X-Hasura-Admin-Secret": "wrong"},
+		expected: `{"errors": [{"message": "invalid x-hasura-admin-secret/x-hasura-access-key", "extensions": {"path": "$", "code": "access-denied"}}]}`,
+	}}
+
+	for i, tc := range testCases {
+		req, err := http.NewRequest(http.MethodPost, s.URL(), strings.NewReader(tc.body))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+		for name, value := range tc.headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := s.Client().Do(req)
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "test %d", i)
+		assert.JSONEq(t, tc.expected, string(body), "test %d", i)
+	}
+
+	assert.True(t, s.AssertCalledWithHeader(t, "users", HasuraRoleHeader, "user"))
+
+	rec := &recorderT{TB: t}
+	assert.False(t, s.AssertCalledWithHeader(rec, "users", HasuraRoleHeader, "anonymous"))
+	if assert.Len(t, rec.errors, 1) {
+		assert.Contains(t, rec.errors[0], `call #0: header "X-Hasura-Role": expected "anonymous", got "admin"`)
+		assert.Contains(t, rec.errors[0], `call #1: header "X-Hasura-Role": expected "anonymous", got "user"`)
+	}
+}
+
+// TestMatchHeaderMismatch checks that mocks that only failed to match the request's headers are reported as candidates.
+func TestMatchHeaderMismatch(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	err := s.RegisterDefinitions(MockDefinition{
+		Identifier: "users",
+		Headers:    map[string]string{"X-Hasura-Role": "admin"},
+		Response:   []byte(`{"users": []}`),
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req := Request{
+		Query:  "query { users { id } }",
+		header: http.Header{"X-Hasura-Role": []string{"user"}},
+	}
+	assert.Equal(t, []string{
+		`query mock #0 for 'users' (goraphql_mock_server.definedMock): header "X-Hasura-Role": expected "admin", got "user"`,
+	}, describeMismatches(s.(*server).mismatches(req, "")))
+
+	dump, err := s.DumpMocks()
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(dump), `"headers": {`)
+	}
+}
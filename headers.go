@@ -0,0 +1,83 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// MatchHeader makes the mock only match requests whose header name has the value,
+// so requests may be told apart by their headers (e.g., by the user's role).
+//
+// This may be used multiple times, in which case every header must match.
+func MatchHeader(name, value string) RegisterOptions {
+	return func(r *registration) {
+		if r.headers == nil {
+			r.headers = make(http.Header)
+		}
+		r.headers.Add(name, value)
+	}
+}
+
+// matchesHeaders checks whether the request's headers have every value expected by the mock.
+func (r *registration) matchesHeaders(header http.Header) bool {
+	return len(r.headerMismatches(header)) == 0
+}
+
+// headerMismatches describes every header expected by the mock that the request doesn't have.
+func (r *registration) headerMismatches(header http.Header) []string {
+	var mismatches []string
+
+	for name, values := range r.headers {
+		for _, want := range values {
+			if got := header.Values(name); !slices.Contains(got, want) {
+				mismatches = append(mismatches, describeHeaderMismatch(name, want, got))
+			}
+		}
+	}
+	sort.Strings(mismatches)
+
+	return mismatches
+}
+
+// describeHeaderMismatch describes that the header doesn't have the expected value.
+func describeHeaderMismatch(name, want string, got []string) string {
+	if len(got) == 0 {
+		return fmt.Sprintf("header %q: expected %q, got nothing", name, want)
+	}
+
+	return fmt.Sprintf("header %q: expected %q, got %q", name, want, strings.Join(got, ", "))
+}
+
+// AssertCalledWithHeader implements Server for server.
+func (s *server) AssertCalledWithHeader(t testing.TB, identifier, name, value string) bool {
+	t.Helper()
+
+	return assertCalledWithHeader(t, identifier, name, value, s.RequestsFor(identifier))
+}
+
+// assertCalledWithHeader fails the test if none of the calls to the identifier had the header's value.
+func assertCalledWithHeader(t testing.TB, identifier, name, value string, calls []RecordedRequest) bool {
+	t.Helper()
+
+	if len(calls) == 0 {
+		t.Errorf("goraphql_mock_server: expected '%s' to be called with header %q: %q, but it was never called", identifier, name, value)
+		return false
+	}
+
+	msg := fmt.Sprintf("goraphql_mock_server: none of the %d calls to '%s' had the expected header", len(calls), identifier)
+	for i, call := range calls {
+		got := call.Header.Values(name)
+		if slices.Contains(got, value) {
+			return true
+		}
+
+		msg += fmt.Sprintf("\n\tcall #%d: %s", i, describeHeaderMismatch(name, value, got))
+	}
+
+	t.Error(msg)
+	return false
+}
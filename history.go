@@ -0,0 +1,409 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+)
+
+// RecordedRequest describes a request received by the mock server.
+type RecordedRequest struct {
+	// The request's query.
+	Query string `json:"query"`
+	// The type of the requested operation, if supported.
+	Operation OperationType `json:"operation,omitempty"`
+	// The name of the requested operation, if it's named.
+	OperationName string `json:"operationName,omitempty"`
+	// The request's variables.
+	Variables map[string]any `json:"variables,omitempty"`
+	// The request's extensions, if any.
+	Extensions map[string]any `json:"extensions,omitempty"`
+	// The ID of the request's persisted query, if any.
+	// Query is empty if the persisted query isn't known to the server.
+	PersistedQueryID string `json:"persistedQueryId,omitempty"`
+	// The request's HTTP method.
+	Method string `json:"method"`
+	// The request's URL, as received by the server.
+	URL string `json:"url"`
+	// The request's HTTP headers.
+	Header http.Header `json:"header,omitempty"`
+	// The ID of the request, if the server was started WithRequestID.
+	RequestID string `json:"requestId,omitempty"`
+	// The trace context propagated by the request, if any.
+	Trace *TraceContext `json:"trace,omitempty"`
+	// When the request was received.
+	Time time.Time `json:"time"`
+	// How many identical requests (i.e., with the same query and variables) were received
+	// up to and including this one, so retries may be identified.
+	Attempt int `json:"attempt"`
+	// How long after the previous identical request this request was received.
+	// It's zero for the first attempt.
+	SincePrevious time.Duration `json:"sincePrevious,omitempty"`
+	// Whether the request matched any registered mock.
+	Matched bool `json:"matched"`
+	// Whether the request was answered before being matched
+	// (e.g., rejected by a simulated fault, a rate limit or a middleware, or because it was too large or invalid).
+	// Rejected requests aren't reported as unmatched, and their Response describes how they were answered.
+	Rejected bool `json:"rejected,omitempty"`
+	// The identifier of the registered mock that matched the request, if any.
+	Identifier string `json:"identifier,omitempty"`
+	// The registered mock that matched the request, if any.
+	Mock MockedRequest `json:"-"`
+	// The name of the registered mock that matched the request, if it was Named.
+	MockName string `json:"mockName,omitempty"`
+	// The response sent by the server.
+	// It's only available after the response is completely sent,
+	// so it's nil in the callbacks of WithRequestCallback and WithRequestChannel.
+	Response *RecordedResponse `json:"response,omitempty"`
+
+	// The request's raw body.
+	body []byte
+	// The request's variables, as sent by the client.
+	rawVariables []byte
+	// Uniquely identifies the entry in the server's history.
+	seq uint64
+	// The scope, created by Server.Scope, that received the request.
+	scope string
+}
+
+// RawBody returns the request's body exactly as sent by the client,
+// including any field dropped while decoding the request.
+// It must not be modified.
+func (r RecordedRequest) RawBody() []byte {
+	return r.body
+}
+
+// RecordedResponse describes a response sent by the mock server.
+type RecordedResponse struct {
+	// The response's HTTP status.
+	// It's zero if no response was sent (e.g., if the connection was reset).
+	Status int `json:"status"`
+	// The response's HTTP headers.
+	Header http.Header `json:"header,omitempty"`
+	// The response's body, as written by the server.
+	Body string `json:"body"`
+	// How long it took to process the request and send the response.
+	Duration time.Duration `json:"duration"`
+}
+
+// responseRecorder wraps a http.ResponseWriter, keeping a copy of the response.
+type responseRecorder struct {
+	http.ResponseWriter
+	// The response's HTTP status.
+	status int
+	// A copy of the response's body, taken from bufferPool.
+	body *bytes.Buffer
+}
+
+// WriteHeader implements http.ResponseWriter for responseRecorder.
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter for responseRecorder.
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(p)
+	return rr.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, so it may be used by a http.ResponseController.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// exchange tracks a request through the server's handlers, so it's recorded even if it's rejected before being matched.
+type exchange struct {
+	// When the request was received.
+	received time.Time
+	// The request's raw body.
+	body []byte
+	// The error found while reading the request's body, if any.
+	err error
+	// The body handed to the next handlers, replaying body (or failing with err).
+	replay io.ReadCloser
+	// The request's entry in the server's history, once it's recorded by the handler.
+	entry *RecordedRequest
+}
+
+// exchangeKey is the context key of the request's exchange.
+type exchangeKey struct{}
+
+// exchangeOf retrieves the exchange tracking the request.
+func exchangeOf(r *http.Request) *exchange {
+	ex, _ := r.Context().Value(exchangeKey{}).(*exchange)
+	if ex == nil {
+		// The request didn't go through withHistory (e.g., it was replaced by a middleware).
+		ex = &exchange{received: time.Now()}
+	}
+
+	return ex
+}
+
+// failedBody is the body of a request whose original body couldn't be read.
+type failedBody struct {
+	// The error found while reading the original body.
+	err error
+}
+
+// Read implements io.Reader for failedBody.
+func (b *failedBody) Read([]byte) (int, error) {
+	return 0, b.err
+}
+
+// Close implements io.Closer for failedBody.
+func (*failedBody) Close() error {
+	return nil
+}
+
+// withHistory records every request handled by next, along with its response.
+// Requests rejected before reaching the server's handler (e.g., by a fault) are recorded as Rejected.
+func (s *server) withHistory(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ex := &exchange{received: time.Now()}
+		rr := &responseRecorder{ResponseWriter: w, body: getBuffer()}
+
+		size := r.ContentLength
+		if s.maxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxBodySize)
+			if size > s.maxBodySize {
+				// Don't allocate the whole body just to reject it.
+				size = -1
+			}
+		}
+
+		// Read the body up front, so it's available even if the request is rejected before it's decoded.
+		ex.body, ex.err = readBody(r.Body, size)
+		if ex.err != nil {
+			ex.replay = &failedBody{err: ex.err}
+		} else {
+			ex.replay = io.NopCloser(bytes.NewReader(ex.body))
+		}
+		r.Body = ex.replay
+
+		defer func() {
+			entry := ex.entry
+			if entry == nil {
+				req, _ := decodeRequest(r.Header.Get("Content-Type"), ex.body, s.maxVariablesSize)
+				rejected := newRecordedRequest(r, req, ex.received)
+				rejected.body = ex.body
+				rejected.scope = s.requestScope(r)
+				rejected.Rejected = true
+				rejected = s.record(rejected)
+				entry = &rejected
+			}
+			s.recordResponse(*entry, rr)
+		}()
+
+		next.ServeHTTP(rr, r.WithContext(context.WithValue(r.Context(), exchangeKey{}, ex)))
+	})
+}
+
+// newRecordedRequest initializes the history entry for a request received at the specified time.
+func newRecordedRequest(r *http.Request, req Request, received time.Time) RecordedRequest {
+	op, name, _ := parseOperation(req.Query)
+	if req.OperationName != "" {
+		name = req.OperationName
+	}
+
+	return RecordedRequest{
+		Query:            req.Query,
+		Operation:        op,
+		OperationName:    name,
+		Variables:        req.Variables,
+		Extensions:       req.Extensions,
+		rawVariables:     req.rawVariables,
+		PersistedQueryID: req.persistedID,
+		Method:           r.Method,
+		URL:              r.URL.String(),
+		Header:           r.Header.Clone(),
+		Trace:            parseTraceContext(r.Header),
+		Time:             received,
+	}
+}
+
+// Requests implements Server for server.
+func (s *server) Requests() []RecordedRequest {
+	return s.requests()
+}
+
+// RequestsFor implements Server for server.
+func (s *server) RequestsFor(identifier string) []RecordedRequest {
+	return s.filterHistory(func(entry RecordedRequest) bool {
+		return entry.Matched && entry.Identifier == identifier
+	})
+}
+
+// UnmatchedRequests implements Server for server.
+func (s *server) UnmatchedRequests() []RecordedRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.unmatched.list()
+}
+
+// record stores the request in the server's history,
+// returning its updated entry.
+func (s *server) record(entry RecordedRequest) RecordedRequest {
+	// Unmatched requests are never sampled out, so they're always reported by ExpectationsWereMet.
+	store := !entry.Matched || s.historySampling <= 0 || s.randFloat64() < s.historySampling
+	key := entry.key()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	entry.seq = s.seq
+
+	prev, ok := s.attempts.get(key)
+	entry.Attempt = prev.count + 1
+	if ok {
+		entry.SincePrevious = entry.Time.Sub(prev.time)
+	}
+	s.attempts.put(key, lastAttempt{count: entry.Attempt, time: entry.Time}, s.history.limit)
+
+	if store {
+		evicted, ok := s.history.push(entry)
+		if ok && s.spill != nil {
+			if err := s.spill.write(evicted); err != nil {
+				s.logger.Warn("goraphql_mock_server: failed to spill request from the history", "error", err)
+			}
+		}
+	}
+	s.total++
+	switch {
+	case entry.Rejected:
+		// Rejected requests were already answered, so they aren't reported as unmatched.
+	case !entry.Matched:
+		s.unmatched.push(entry)
+	case entry.Identifier != "":
+		// Requests answered by the schema mock aren't counted for any identifier.
+		s.counts[entry.Identifier]++
+	}
+	if entry.scope != "" {
+		s.scopeCountersOf(entry.scope).add(entry)
+	}
+
+	return entry
+}
+
+// requestKey identifies identical requests (i.e., sent to the same scope, with the same query and variables),
+// so retries may be detected.
+type requestKey struct {
+	// The scope that received the requests.
+	scope string
+	// The requests' query.
+	query string
+	// The requests' variables, exactly as sent by the clients.
+	variables string
+}
+
+// lastAttempt describes the last of the identical requests received by the server.
+type lastAttempt struct {
+	// How many identical requests were received.
+	count int
+	// When the last one was received.
+	time time.Time
+}
+
+// key identifies the requests identical to this one.
+// The variables are compared as sent, so they don't have to be encoded (or decoded) again.
+func (req RecordedRequest) key() requestKey {
+	variables := req.rawVariables
+	if variables == nil && req.Variables != nil {
+		// The request wasn't received by the server (e.g., it was loaded from a dump).
+		variables, _ = json.Marshal(req.Variables)
+	}
+
+	return requestKey{
+		scope:     req.scope,
+		query:     req.Query,
+		variables: string(variables),
+	}
+}
+
+// recordResponse stores the response sent for the recorded request.
+func (s *server) recordResponse(entry RecordedRequest, rr *responseRecorder) {
+	res := &RecordedResponse{
+		Status:   rr.status,
+		Header:   rr.Header().Clone(),
+		Body:     rr.body.String(),
+		Duration: time.Since(entry.Time),
+	}
+	size := rr.body.Len()
+	putBuffer(rr.body)
+	rr.body = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.add(entry, size, res.Duration)
+	if entry.scope != "" {
+		s.scopeCountersOf(entry.scope).stats.add(entry, size, res.Duration)
+	}
+
+	// Unmatched requests are also stored separately, so they're reported even if evicted from the history.
+	for _, ring := range []*requestRing{&s.history, &s.unmatched} {
+		if stored := ring.find(entry.seq); stored != nil {
+			stored.Response = res
+		}
+	}
+}
+
+// Counts implements Server for server.
+func (s *server) Counts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.counts)
+}
+
+// TotalCount implements Server for server.
+func (s *server) TotalCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.total
+}
+
+// requests returns a copy of the server's history.
+func (s *server) requests() []RecordedRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.history.list()
+}
+
+// filterHistory returns every request in the server's history for which keep returns true.
+func (s *server) filterHistory(keep func(entry RecordedRequest) bool) []RecordedRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var requests []RecordedRequest
+	for i := 0; i < s.history.len(); i++ {
+		if entry := *s.history.at(i); keep(entry) {
+			requests = append(requests, entry)
+		}
+	}
+
+	return requests
+}
+
+// clearHistory removes every request from the server's history.
+func (s *server) clearHistory() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history.clear()
+	s.unmatched.clear()
+	s.attempts.clear()
+}
@@ -0,0 +1,342 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequests checks that the server records the requests it receives.
+func TestRequests(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+
+	client := graphql.NewClient(s.URL())
+	start := time.Now()
+
+	type testCase struct {
+		// The GraphQL request to be sent to the mock server.
+		request string
+		// Variables used to customize the request.
+		variables map[string]any
+	}
+
+	testCases := []testCase{{
+		request: `query ListFoos($num:Integer!) { ListFoos(num:$num) { foo } }`,
+		variables: map[string]any{
+			"num": 1,
+		},
+	}, {
+		request: `query { ListBars { bar } }`,
+	}, {
+		request: `query ($num:Integer!) { ListFoos(num:$num) { foo } }`,
+		variables: map[string]any{
+			"num": 2,
+		},
+	}}
+
+	for _, tc := range testCases {
+		req := graphql.NewRequest(tc.request)
+		req.Header.Set("X-Test", "history")
+		for k, v := range tc.variables {
+			req.Var(k, v)
+		}
+
+		var resp map[string]any
+		_ = client.Run(context.Background(), req, &resp)
+	}
+
+	requests := s.Requests()
+	if assert.Len(t, requests, len(testCases), "unexpected number of recorded requests") {
+		for i, tc := range testCases {
+			assert.Equal(t, tc.request, requests[i].Query, "unexpected query in request %d", i)
+			assert.Equal(t, OperationQuery, requests[i].Operation, "unexpected operation in request %d", i)
+			assert.Equal(t, "history", requests[i].Header.Get("X-Test"), "unexpected header in request %d", i)
+			assert.False(t, requests[i].Time.Before(start), "unexpected time in request %d", i)
+		}
+
+		assert.Equal(t, "ListFoos", requests[0].OperationName, "unexpected operation name")
+		assert.Empty(t, requests[2].OperationName, "unexpected name for an anonymous operation")
+		assert.False(t, requests[1].Matched, "the unregistered query matched a mock")
+		assert.NotNil(t, requests[0].Mock, "the matched mock wasn't recorded")
+	}
+
+	assert.Equal(t, map[string]int{"ListFoos": 2}, s.Counts(), "unexpected counts")
+	assert.Equal(t, 3, s.TotalCount(), "unexpected total count")
+
+	requests = s.UnmatchedRequests()
+	if assert.Len(t, requests, 1, "unexpected number of unmatched requests") {
+		assert.Equal(t, testCases[1].request, requests[0].Query, "unexpected unmatched request")
+	}
+
+	requests = s.RequestsFor("ListFoos")
+	if assert.Len(t, requests, 2, "unexpected number of requests for ListFoos") {
+		assert.Equal(t, map[string]any{"num": 1.0}, requests[0].Variables, "unexpected variables in the first request")
+		assert.Equal(t, map[string]any{"num": 2.0}, requests[1].Variables, "unexpected variables in the second request")
+	}
+}
+
+// clientMock implements RawBodyMatcher, only matching requests sent by a specific client
+// in the non-standard "client" field of the body.
+type clientMock struct {
+	SimpleMockedRequest
+	// The client that must have sent the request.
+	client string
+}
+
+// CompareRawBody implements RawBodyMatcher for clientMock.
+func (m clientMock) CompareRawBody(body []byte) bool {
+	var req struct {
+		Client string `json:"client"`
+	}
+
+	return json.Unmarshal(body, &req) == nil && req.Client == m.client
+}
+
+// TestRawBody checks that the raw body is available to matchers and in the history.
+func TestRawBody(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", clientMock{
+		SimpleMockedRequest: SimpleMockedRequest{StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`)},
+		client:              "web",
+	})
+
+	type testCase struct {
+		// The raw body sent to the mock server.
+		body string
+		// Whether the request should match the mock.
+		matched bool
+	}
+
+	testCases := []testCase{{
+		body:    `{"query": "query { ListFoos { foo } }", "client": "web"}`,
+		matched: true,
+	}, {
+		body:    `{"query": "query { ListFoos { foo } }", "client": "mobile"}`,
+		matched: false,
+	}, {
+		body:    `{"query": "query { ListFoos { foo } }"}`,
+		matched: false,
+	}}
+
+	for i, tc := range testCases {
+		resp, err := http.Post(s.URL(), "application/json", strings.NewReader(tc.body))
+		if assert.NoError(t, err, "test %d", i) {
+			resp.Body.Close()
+		}
+	}
+
+	requests := s.Requests()
+	if assert.Len(t, requests, len(testCases), "unexpected number of recorded requests") {
+		for i, tc := range testCases {
+			assert.Equal(t, tc.matched, requests[i].Matched, "test %d", i)
+			assert.Equal(t, tc.body, string(requests[i].RawBody()), "test %d", i)
+		}
+	}
+}
+
+// TestRequestFields checks that the operation name, the extensions and any unknown field
+// are decoded from both JSON and form-encoded requests.
+func TestRequestFields(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	var received []Request
+	s.RegisterQueryFunc("ListFoos", func(req Request) (any, error) {
+		received = append(received, req)
+		return map[string]any{"ListFoos": nil}, nil
+	})
+
+	type testCase struct {
+		// The Content-Type of the request.
+		contentType string
+		// The raw body sent to the mock server.
+		body string
+	}
+
+	testCases := []testCase{{
+		contentType: "application/json",
+		body:        `{"query": "query A { ListFoos { foo } } query B { ListFoos { bar } }", "operationName": "B", "extensions": {"trace": true}, "client": "web"}`,
+	}, {
+		contentType: formContentType,
+		body: url.Values{
+			"query":         {"query A { ListFoos { foo } } query B { ListFoos { bar } }"},
+			"operationName": {"B"},
+			"extensions":    {`{"trace": true}`},
+			"client":        {"web"},
+		}.Encode(),
+	}}
+
+	for i, tc := range testCases {
+		resp, err := http.Post(s.URL(), tc.contentType, strings.NewReader(tc.body))
+		if assert.NoError(t, err, "test %d", i) {
+			resp.Body.Close()
+		}
+	}
+
+	if assert.Len(t, received, len(testCases), "unexpected number of requests") {
+		for i, req := range received {
+			assert.Equal(t, "B", req.OperationName, "test %d", i)
+			assert.Equal(t, map[string]any{"trace": true}, req.Extensions, "test %d", i)
+
+			client, ok := req.Field("client")
+			assert.True(t, ok, "test %d", i)
+			assert.JSONEq(t, `"web"`, string(client), "test %d", i)
+
+			_, ok = req.Field("query")
+			assert.False(t, ok, "test %d", i)
+		}
+	}
+
+	for i, entry := range s.Requests() {
+		assert.Equal(t, "B", entry.OperationName, "test %d", i)
+		assert.Equal(t, map[string]any{"trace": true}, entry.Extensions, "test %d", i)
+	}
+}
+
+// TestMaxVariablesSize checks that huge variables are only decoded when some matcher needs them decoded.
+func TestMaxVariablesSize(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))), WithMaxVariablesSize(64))
+	defer s.Close()
+
+	type listVars struct {
+		IDs []int `json:"ids"`
+	}
+
+	ids := make([]int, 100)
+	for i := range ids {
+		ids[i] = i
+	}
+
+	Register(s, OperationQuery, "ListFoos", listVars{IDs: ids}, map[string]any{"ListFoos": nil})
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListBars": null}`),
+		KeyOnlyVariables: KeyOnlyVariables{"ids"},
+	})
+
+	type testCase struct {
+		// The query sent to the mock server.
+		query string
+		// Whether the variables should have been decoded (and recorded).
+		decoded bool
+	}
+
+	testCases := []testCase{{
+		query:   `query { ListFoos { foo } }`,
+		decoded: false,
+	}, {
+		query:   `query { ListBars { bar } }`,
+		decoded: true,
+	}}
+
+	client := s.GraphQLClient()
+	for i, tc := range testCases {
+		req := graphql.NewRequest(tc.query)
+		req.Var("ids", ids)
+
+		var resp map[string]any
+		assert.NoError(t, client.Run(context.Background(), req, &resp), "test %d", i)
+	}
+
+	requests := s.Requests()
+	if assert.Len(t, requests, len(testCases), "unexpected number of recorded requests") {
+		for i, tc := range testCases {
+			assert.True(t, requests[i].Matched, "test %d", i)
+			assert.Equal(t, tc.decoded, requests[i].Variables != nil, "test %d", i)
+			assert.Contains(t, string(requests[i].RawBody()), `"ids":[0,1,2`, "test %d", i)
+		}
+	}
+
+	// Undecoded variables must still tell retries apart from requests with other variables.
+	for i, attempt := range []int{1, 1, 2} {
+		req := graphql.NewRequest(`query { ListBazs { baz } }`)
+		req.Var("ids", ids[i%2:])
+
+		var resp map[string]any
+		assert.Error(t, client.Run(context.Background(), req, &resp), "test %d", i)
+
+		requests := s.Requests()
+		assert.Nil(t, requests[len(requests)-1].Variables, "test %d", i)
+		assert.Equal(t, attempt, requests[len(requests)-1].Attempt, "test %d", i)
+	}
+}
+
+// TestRejectedRequests checks that requests rejected before being matched are recorded, along with their responses.
+func TestRejectedRequests(t *testing.T) {
+	type testCase struct {
+		// Options used to create the server.
+		opts []ServerOptions
+		// The body of the requests sent to the server.
+		bodies []string
+		// The status expected for each request.
+		statuses []int
+	}
+
+	query := `{"query": "query { ListFoos { foo } }"}`
+	testCases := []testCase{{
+		opts:     []ServerOptions{WithRateLimit(1, time.Hour)},
+		bodies:   []string{query, query},
+		statuses: []int{http.StatusOK, http.StatusTooManyRequests},
+	}, {
+		opts:     []ServerOptions{WithErrorRate(1, http.StatusServiceUnavailable)},
+		bodies:   []string{query},
+		statuses: []int{http.StatusServiceUnavailable},
+	}, {
+		opts:     []ServerOptions{WithMaxBodySize(8)},
+		bodies:   []string{query},
+		statuses: []int{http.StatusRequestEntityTooLarge},
+	}, {
+		bodies:   []string{`{"query": `},
+		statuses: []int{http.StatusInternalServerError},
+	}, {
+		opts:     []ServerOptions{WithMaxDepth(1)},
+		bodies:   []string{query},
+		statuses: []int{http.StatusBadRequest},
+	}}
+
+	for i, tc := range testCases {
+		opts := append([]ServerOptions{WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))}, tc.opts...)
+		s := New(opts...)
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		})
+
+		for j, body := range tc.bodies {
+			resp, err := http.Post(s.URL(), "application/json", strings.NewReader(body))
+			if assert.NoError(t, err, "test %d: request %d failed", i, j) {
+				resp.Body.Close()
+				assert.Equal(t, tc.statuses[j], resp.StatusCode, "test %d: unexpected status for request %d", i, j)
+			}
+		}
+
+		requests := s.Requests()
+		if assert.Len(t, requests, len(tc.bodies), "test %d: unexpected requests", i) {
+			last := requests[len(requests)-1]
+			assert.True(t, last.Rejected, "test %d: the request wasn't rejected", i)
+			if assert.NotNil(t, last.Response, "test %d: the response wasn't recorded", i) {
+				assert.Equal(t, tc.statuses[len(tc.statuses)-1], last.Response.Status, "test %d: unexpected recorded status", i)
+			}
+		}
+		assert.Equal(t, len(tc.bodies), s.TotalCount(), "test %d: unexpected total count", i)
+		assert.Equal(t, 1, s.Stats().Rejected, "test %d: unexpected rejected requests", i)
+		assert.Empty(t, s.UnmatchedRequests(), "test %d: the rejected request was reported as unmatched", i)
+
+		s.Close()
+	}
+}
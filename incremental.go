@@ -0,0 +1,120 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// incrementalBoundary separates the parts of an incremental response.
+const incrementalBoundary = "-"
+
+// incrementalContentType is the Content-Type of incremental responses.
+const incrementalContentType = `multipart/mixed; boundary="` + incrementalBoundary + `"; deferSpec=20220824`
+
+// Patch is a subsequent payload of an incremental response,
+// delivering the result of a @defer or @stream directive.
+type Patch struct {
+	// The path to the object (for @defer) or to the list (for @stream) that the patch applies to.
+	Path []any `json:"path"`
+	// The label of the directive, if any.
+	Label string `json:"label,omitempty"`
+	// The fields of a deferred fragment.
+	Data any `json:"data,omitempty"`
+	// The items of a streamed list.
+	Items []any `json:"items,omitempty"`
+	// The errors raised while resolving the patch (e.g., to simulate a failure mid-stream).
+	Errors []ResponseError `json:"errors,omitempty"`
+}
+
+// incrementalPayload is a subsequent payload of an incremental response.
+type incrementalPayload struct {
+	Incremental []Patch `json:"incremental"`
+	HasNext     bool    `json:"hasNext"`
+}
+
+// Incremental makes the mock send its response incrementally, as a multipart/mixed response:
+// the mock's response is sent as the initial payload, followed by each patch in order,
+// so clients' handling of @defer and @stream may be tested.
+//
+// To simulate a failure mid-stream, send a patch with Errors, or use IncrementalThenDrop.
+func Incremental(patches ...Patch) RegisterOptions {
+	return incremental(patches, false)
+}
+
+// IncrementalThenDrop works like Incremental,
+// but closes the connection after sending the patches, without ever finishing the response,
+// so clients' handling of partial results may be tested.
+func IncrementalThenDrop(patches ...Patch) RegisterOptions {
+	return incremental(patches, true)
+}
+
+// incremental sends the mock's response incrementally, followed by each patch,
+// dropping the connection at the end if requested.
+func incremental(patches []Patch, drop bool) RegisterOptions {
+	return func(r *registration) {
+		r.decorators = append(r.decorators, func(s *server, _ *registration, next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				res := httptest.NewRecorder()
+				next.ServeHTTP(res, req)
+
+				// Send failed responses as they are.
+				var initial map[string]any
+				if res.Code != http.StatusOK || json.Unmarshal(res.Body.Bytes(), &initial) != nil {
+					for k, v := range res.Header() {
+						w.Header()[k] = v
+					}
+					w.WriteHeader(res.Code)
+					_, _ = w.Write(res.Body.Bytes())
+					return
+				}
+
+				for k, v := range res.Header() {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Content-Type", incrementalContentType)
+				w.WriteHeader(http.StatusOK)
+
+				rc := http.NewResponseController(w)
+				_, _ = fmt.Fprintf(w, "--%s", incrementalBoundary)
+
+				initial["hasNext"] = len(patches) > 0 || drop
+				if err := writePart(w, initial); err != nil {
+					return
+				}
+				_ = rc.Flush()
+
+				for i, patch := range patches {
+					payload := incrementalPayload{
+						Incremental: []Patch{patch},
+						HasNext:     i < len(patches)-1 || drop,
+					}
+					if err := writePart(w, payload); err != nil {
+						return
+					}
+					_ = rc.Flush()
+				}
+
+				if drop {
+					panic(http.ErrAbortHandler)
+				}
+
+				_, _ = io.WriteString(w, "--\r\n")
+			})
+		})
+	}
+}
+
+// writePart writes the payload as a part of an incremental response, followed by the boundary,
+// so the client may process the part as soon as it's received.
+func writePart(w io.Writer, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("goraphql_mock_server: encode incremental payload: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "\r\nContent-Type: application/json; charset=utf-8\r\n\r\n%s\r\n--%s", data, incrementalBoundary)
+	return err
+}
@@ -0,0 +1,99 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIncremental checks that incremental responses send every patch, and are either finished or dropped.
+func TestIncremental(t *testing.T) {
+	type testCase struct {
+		// The option making the mock incremental.
+		opt RegisterOptions
+		// The expected parts of the response.
+		parts []string
+		// Whether the response is expected to be dropped.
+		dropped bool
+	}
+
+	patches := []Patch{{
+		Path: []any{"ListFoos"},
+		Data: map[string]any{"bar": 456},
+	}, {
+		Path:   []any{"ListFoos", "bazs"},
+		Label:  "bazs",
+		Errors: []ResponseError{{Message: "failed to list bazs"}},
+	}}
+
+	testCases := []testCase{{
+		opt: Incremental(patches...),
+		parts: []string{
+			`{"data": {"ListFoos": {"foo": 123}}, "hasNext": true}`,
+			`{"incremental": [{"path": ["ListFoos"], "data": {"bar": 456}}], "hasNext": true}`,
+			`{"incremental": [{"path": ["ListFoos", "bazs"], "label": "bazs", "errors": [{"message": "failed to list bazs", "path": null, "extensions": null}]}], "hasNext": false}`,
+		},
+	}, {
+		opt: IncrementalThenDrop(patches[0]),
+		parts: []string{
+			`{"data": {"ListFoos": {"foo": 123}}, "hasNext": true}`,
+			`{"incremental": [{"path": ["ListFoos"], "data": {"bar": 456}}], "hasNext": true}`,
+		},
+		dropped: true,
+	}}
+
+	for i, tc := range testCases {
+		s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		}, tc.opt)
+
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo ... @defer { bar } } }"}`))
+		if !assert.NoError(t, err, "test %d", i) {
+			s.Close()
+			continue
+		}
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "test %d", i)
+		mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, "multipart/mixed", mediaType, "test %d", i)
+
+		mr := multipart.NewReader(resp.Body, params["boundary"])
+		var parts []string
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				assert.False(t, tc.dropped, "test %d: the response wasn't dropped", i)
+				break
+			} else if err != nil {
+				assert.True(t, tc.dropped, "test %d: unexpected error: %v", i, err)
+				break
+			}
+
+			body, err := io.ReadAll(part)
+			if err != nil {
+				assert.True(t, tc.dropped, "test %d: unexpected error: %v", i, err)
+				break
+			}
+			parts = append(parts, string(body))
+		}
+		resp.Body.Close()
+
+		if assert.Len(t, parts, len(tc.parts), "test %d", i) {
+			for j, part := range parts {
+				assert.JSONEq(t, tc.parts[j], part, "test %d: part %d", i, j)
+			}
+		}
+
+		s.Close()
+	}
+}
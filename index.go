@@ -0,0 +1,182 @@
+package goraphql_mock_server
+
+import (
+	"sort"
+	"sync"
+)
+
+// identifierIndex finds the identifiers that may match a request without checking every registered one,
+// so matching stays fast even with thousands of registered identifiers.
+//
+// Since mocks match requests whose query contains their identifier,
+// the identifiers are searched in the query by an Aho-Corasick automaton,
+// whose cost depends on the length of the query rather than on the number of identifiers.
+//
+// The index is rebuilt, when needed, after the registered mocks change.
+// It's only used while s.mu is held, so the registered mocks don't change while it's in use.
+type identifierIndex struct {
+	// Guards every field below, since the index may be rebuilt while s.mu is only read-locked.
+	mu sync.Mutex
+	// Whether the index reflects the registered mocks.
+	built bool
+	// Every indexed identifier, sorted.
+	identifiers []string
+	// The states of the automaton. The first one is the root.
+	nodes []indexNode
+	// The identifiers with mocks for persisted queries, which match requests regardless of their query.
+	persisted []int
+}
+
+// indexNode is a state of the Aho-Corasick automaton, reached after reading a prefix of some identifier.
+type indexNode struct {
+	// The state reached after reading each byte, if the longer prefix is also a prefix of some identifier.
+	next map[byte]int
+	// The state of the longest proper suffix of this state's prefix that's also a prefix of some identifier.
+	fail int
+	// The state of the longest proper suffix of this state's prefix that's a whole identifier,
+	// or -1 if there's none.
+	dict int
+	// The identifier equal to this state's prefix, or -1 if there's none.
+	identifier int
+}
+
+// invalidate marks the index as outdated, so it's rebuilt the next time it's used.
+// s.mu must be write-locked by the caller.
+func (idx *identifierIndex) invalidate() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.built = false
+}
+
+// build indexes every identifier in the registrations.
+// idx.mu must be held by the caller.
+func (idx *identifierIndex) build(registrations map[string][]*registration) {
+	idx.identifiers = make([]string, 0, len(registrations))
+	for id := range registrations {
+		idx.identifiers = append(idx.identifiers, id)
+	}
+	sort.Strings(idx.identifiers)
+
+	idx.nodes = []indexNode{{dict: -1, identifier: -1}}
+	idx.persisted = nil
+	for i, id := range idx.identifiers {
+		for _, reg := range registrations[id] {
+			if reg.persistedID != "" {
+				idx.persisted = append(idx.persisted, i)
+				break
+			}
+		}
+
+		state := 0
+		for j := 0; j < len(id); j++ {
+			next, ok := idx.nodes[state].next[id[j]]
+			if !ok {
+				next = len(idx.nodes)
+				idx.nodes = append(idx.nodes, indexNode{dict: -1, identifier: -1})
+				if idx.nodes[state].next == nil {
+					idx.nodes[state].next = make(map[byte]int)
+				}
+				idx.nodes[state].next[id[j]] = next
+			}
+			state = next
+		}
+		idx.nodes[state].identifier = i
+	}
+
+	// Link every state to its failure and dictionary states, in breadth-first order,
+	// so the states of shorter prefixes are always linked first.
+	queue := []int{0}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for c, child := range idx.nodes[state].next {
+			fail := 0
+			if state != 0 {
+				fail = idx.nodes[state].fail
+				for {
+					if next, ok := idx.nodes[fail].next[c]; ok {
+						fail = next
+						break
+					} else if fail == 0 {
+						break
+					}
+					fail = idx.nodes[fail].fail
+				}
+			}
+
+			idx.nodes[child].fail = fail
+			if idx.nodes[fail].identifier >= 0 {
+				idx.nodes[child].dict = fail
+			} else {
+				idx.nodes[child].dict = idx.nodes[fail].dict
+			}
+			queue = append(queue, child)
+		}
+	}
+
+	idx.built = true
+}
+
+// candidates returns, sorted, every identifier found in the query,
+// along with every identifier with mocks for persisted queries.
+// Only the mocks registered for these identifiers may match a request with the query.
+// s.mu must be held by the caller.
+func (s *server) candidates(query string) []string {
+	idx := &s.index
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.built {
+		idx.build(s.registrations)
+	}
+
+	found := make(map[int]struct{})
+	state := 0
+	for j := 0; j < len(query); j++ {
+		for {
+			if next, ok := idx.nodes[state].next[query[j]]; ok {
+				state = next
+				break
+			} else if state == 0 {
+				break
+			}
+			state = idx.nodes[state].fail
+		}
+
+		match := state
+		if idx.nodes[match].identifier < 0 {
+			match = idx.nodes[match].dict
+		}
+		for match > 0 {
+			if _, ok := found[idx.nodes[match].identifier]; ok {
+				// Every shorter identifier in the chain was already found as well.
+				break
+			}
+			found[idx.nodes[match].identifier] = struct{}{}
+			match = idx.nodes[match].dict
+		}
+	}
+
+	for _, i := range idx.persisted {
+		found[i] = struct{}{}
+	}
+	// An empty identifier is found in every query.
+	if root := idx.nodes[0]; root.identifier >= 0 {
+		found[root.identifier] = struct{}{}
+	}
+
+	indexes := make([]int, 0, len(found))
+	for i := range found {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	identifiers := make([]string, len(indexes))
+	for j, i := range indexes {
+		identifiers[j] = idx.identifiers[i]
+	}
+
+	return identifiers
+}
@@ -0,0 +1,101 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIdentifierIndex checks that the index finds every identifier contained in the query,
+// even if the identifiers overlap.
+func TestIdentifierIndex(t *testing.T) {
+	type testCase struct {
+		// The request's query.
+		query string
+		// The identifiers expected to be found.
+		want []string
+	}
+
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))).(*server)
+	defer s.Close()
+
+	identifiers := []string{"user", "users", "ser", "sers(", "listUsers", "he", "she", "his", "hers", "persisted"}
+	for _, id := range identifiers {
+		s.RegisterQuery(id, SimpleMockedRequest{})
+	}
+	s.RegisterPersistedQuery("abc123", SimpleMockedRequest{})
+
+	testCases := []testCase{{
+		query: `query { listUsers(first: 10) { id } }`,
+		want:  []string{"abc123", "listUsers", "sers(", "ser"},
+	}, {
+		query: `query { users { id } }`,
+		want:  []string{"abc123", "ser", "user", "users"},
+	}, {
+		query: `ushers`,
+		want:  []string{"abc123", "he", "hers", "she"},
+	}, {
+		query: `query { foo }`,
+		want:  []string{"abc123"},
+	}}
+
+	for i, tc := range testCases {
+		s.mu.RLock()
+		got := s.candidates(tc.query)
+		s.mu.RUnlock()
+
+		sort.Strings(tc.want)
+		assert.Equal(t, tc.want, got, "test %d", i)
+
+		// Check the index against a plain search.
+		var plain []string
+		for _, id := range append(identifiers, "abc123") {
+			if strings.Contains(tc.query, id) || id == "abc123" {
+				plain = append(plain, id)
+			}
+		}
+		sort.Strings(plain)
+		assert.Equal(t, plain, got, "test %d", i)
+	}
+
+	s.Unregister("listUsers")
+	s.mu.RLock()
+	got := s.candidates(testCases[0].query)
+	s.mu.RUnlock()
+	assert.Equal(t, []string{"abc123", "ser", "sers("}, got, "the index wasn't rebuilt")
+}
+
+// TestIdentifierIndexMatch checks that requests are matched through the index with many registered identifiers.
+func TestIdentifierIndexMatch(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	for i := 0; i < 2000; i++ {
+		id := fmt.Sprintf("op%04d", i)
+		s.RegisterQuery(id, SimpleMockedRequest{
+			StringResponse: StringResponse(fmt.Sprintf(`{"%s": %d}`, id, i)),
+		})
+	}
+
+	client := s.GraphQLClient()
+	for _, i := range []int{0, 1234, 1999} {
+		id := fmt.Sprintf("op%04d", i)
+
+		var resp map[string]any
+		err := client.Run(context.Background(), graphql.NewRequest(fmt.Sprintf(`query { %s }`, id)), &resp)
+		if assert.NoError(t, err, "test %d", i) {
+			assert.Equal(t, map[string]any{id: float64(i)}, resp, "test %d", i)
+		}
+	}
+
+	var resp map[string]any
+	err := client.Run(context.Background(), graphql.NewRequest(`query { op2000 }`), &resp)
+	assert.Error(t, err, "an unregistered identifier matched")
+}
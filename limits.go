@@ -0,0 +1,155 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// errQueryTooComplex is the error sent for queries exceeding the limits set by WithMaxDepth or WithMaxCost.
+var errQueryTooComplex = errors.New("goraphql_mock_server: query too complex")
+
+// complexityExtensions is sent in the extensions of the error for queries exceeding the limits,
+// describing the query's cost and depth.
+type complexityExtensions struct {
+	Code     string `json:"code"`
+	Cost     int    `json:"cost"`
+	MaxCost  int    `json:"maxCost,omitempty"`
+	Depth    int    `json:"depth"`
+	MaxDepth int    `json:"maxDepth,omitempty"`
+}
+
+// complexityCode identifies the error for queries exceeding the limits, in its extensions.
+const complexityCode = "QUERY_TOO_COMPLEX"
+
+// paginationArguments are the arguments that multiply the cost of a field's selections.
+var paginationArguments = []string{"first", "last", "limit"}
+
+// WithMaxDepth rejects queries whose fields are nested deeper than depth
+// with a 400 (Bad Request) and a GraphQL error describing the query's complexity,
+// so clients that split their queries may be tested.
+//
+// The depth counts the nested fields, so "{ foo { bar } }" has a depth of 2.
+// Fragments are expanded as if their fields were selected directly.
+func WithMaxDepth(depth int) ServerOptions {
+	return func(s *server) {
+		s.maxDepth = depth
+	}
+}
+
+// WithMaxCost rejects queries whose cost is greater than cost
+// with a 400 (Bad Request) and a GraphQL error describing the query's complexity,
+// so clients that split their queries may be tested.
+//
+// Every field costs 1, and the cost of the fields selected by a field paginated
+// with a "first", "last" or "limit" argument (e.g., "foos(first: 10) { id }")
+// is multiplied by that argument.
+// Fragments are expanded as if their fields were selected directly.
+func WithMaxCost(cost int) ServerOptions {
+	return func(s *server) {
+		s.maxCost = cost
+	}
+}
+
+// checkComplexity checks whether the request exceeds the configured limits, rejecting it if so.
+// Requests that can't be parsed are never rejected.
+func (s *server) checkComplexity(w http.ResponseWriter, r *http.Request, req Request) bool {
+	if s.maxDepth <= 0 && s.maxCost <= 0 {
+		return true
+	}
+
+	doc, err := parseQuery(req.Query)
+	if err != nil {
+		s.logger.Debug("goraphql_mock_server: failed to parse query, skipping its complexity", "error", err)
+		return true
+	}
+
+	var ext complexityExtensions
+	for _, op := range doc.operations {
+		c := complexity{doc: doc, variables: req.variables(), visiting: make(map[string]bool)}
+		cost, depth := c.selections(op.selections)
+		ext.Cost = max(ext.Cost, cost)
+		ext.Depth = max(ext.Depth, depth)
+	}
+
+	if (s.maxDepth <= 0 || ext.Depth <= s.maxDepth) && (s.maxCost <= 0 || ext.Cost <= s.maxCost) {
+		return true
+	}
+
+	ext.Code = complexityCode
+	ext.MaxCost = s.maxCost
+	ext.MaxDepth = s.maxDepth
+
+	s.logger.Warn("goraphql_mock_server: query too complex",
+		"query", compactQuery(req.Query),
+		"cost", ext.Cost,
+		"depth", ext.Depth,
+	)
+	respondError(w, r, http.StatusBadRequest, errQueryTooComplex, ext)
+	return false
+}
+
+// complexity calculates the cost and the depth of an operation.
+type complexity struct {
+	doc *document
+	// The request's variables, used to resolve the pagination arguments.
+	variables map[string]any
+	// The fragments being expanded, so cyclic fragments aren't expanded forever.
+	visiting map[string]bool
+}
+
+// selections calculates the cost and the depth of a selection set.
+func (c complexity) selections(sels []selection) (cost, depth int) {
+	for _, sel := range sels {
+		var selCost, selDepth int
+
+		switch sel.kind {
+		case selectionField:
+			childCost, childDepth := c.selections(sel.selections)
+			selCost = 1 + childCost*c.multiplier(sel.arguments)
+			selDepth = 1 + childDepth
+		case selectionInlineFragment:
+			selCost, selDepth = c.selections(sel.selections)
+		case selectionFragmentSpread:
+			frag, ok := c.doc.fragments[sel.name]
+			if !ok || c.visiting[sel.name] {
+				continue
+			}
+
+			c.visiting[sel.name] = true
+			selCost, selDepth = c.selections(frag.selections)
+			delete(c.visiting, sel.name)
+		}
+
+		cost += selCost
+		depth = max(depth, selDepth)
+	}
+
+	return cost, depth
+}
+
+// multiplier retrieves how many elements a field paginates over,
+// from its pagination arguments. It's 1 for fields that aren't paginated.
+func (c complexity) multiplier(args []argument) int {
+	for _, arg := range args {
+		for _, name := range paginationArguments {
+			if arg.name != name {
+				continue
+			}
+
+			switch arg.value.kind {
+			case valueInt:
+				if n, err := strconv.Atoi(arg.value.raw); err == nil && n > 0 {
+					return n
+				}
+			case valueVariable:
+				// Variables decoded from JSON are always float64.
+				if n, ok := c.variables[arg.value.raw].(float64); ok && n >= 1 {
+					return int(n)
+				}
+			}
+		}
+	}
+
+	return 1
+}
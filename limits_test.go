@@ -0,0 +1,133 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestComplexity checks the cost and the depth calculated for queries.
+func TestComplexity(t *testing.T) {
+	type testCase struct {
+		// The query being analyzed.
+		query string
+		// The request's variables.
+		variables map[string]any
+		// The expected cost.
+		cost int
+		// The expected depth.
+		depth int
+	}
+
+	testCases := []testCase{{
+		query: `query { ListFoos { foo bar } }`,
+		cost:  3,
+		depth: 2,
+	}, {
+		query: `query { ListFoos(first: 10) { foo bar } }`,
+		cost:  21,
+		depth: 2,
+	}, {
+		query:     `query ($num: Int) { ListFoos(last: $num) { foo } }`,
+		variables: map[string]any{"num": float64(5)},
+		cost:      6,
+		depth:     2,
+	}, {
+		query: `query { ListFoos { ...FooFields ... on Foo { baz { id } } } } fragment FooFields on Foo { foo bar }`,
+		cost:  5,
+		depth: 3,
+	}, {
+		query: `query { ListFoos { ...A } } fragment A on Foo { foo ...A }`,
+		cost:  2,
+		depth: 2,
+	}}
+
+	for i, tc := range testCases {
+		doc, err := parseQuery(tc.query)
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		c := complexity{doc: doc, variables: tc.variables, visiting: make(map[string]bool)}
+		cost, depth := c.selections(doc.operations[0].selections)
+		assert.Equal(t, tc.cost, cost, "test %d: cost", i)
+		assert.Equal(t, tc.depth, depth, "test %d: depth", i)
+	}
+}
+
+// TestComplexityLimits checks that queries exceeding the limits are rejected, describing their complexity.
+func TestComplexityLimits(t *testing.T) {
+	s := New(WithMaxDepth(2), WithMaxCost(10), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	type testCase struct {
+		// The query sent to the server.
+		query string
+		// The expected status code.
+		status int
+		// The expected extensions, if the query is rejected.
+		extensions map[string]any
+	}
+
+	testCases := []testCase{{
+		query:  `query { ListFoos { foo } }`,
+		status: http.StatusOK,
+	}, {
+		query:  `query { ListFoos { foo { bar } } }`,
+		status: http.StatusBadRequest,
+		extensions: map[string]any{
+			"code":     "QUERY_TOO_COMPLEX",
+			"cost":     float64(3),
+			"maxCost":  float64(10),
+			"depth":    float64(3),
+			"maxDepth": float64(2),
+		},
+	}, {
+		query:  `query { ListFoos(first: 100) { foo } }`,
+		status: http.StatusBadRequest,
+		extensions: map[string]any{
+			"code":     "QUERY_TOO_COMPLEX",
+			"cost":     float64(101),
+			"maxCost":  float64(10),
+			"depth":    float64(2),
+			"maxDepth": float64(2),
+		},
+	}, {
+		// Queries that can't be parsed aren't rejected.
+		query:  `query { ListFoos { foo { bar { baz } }`,
+		status: http.StatusOK,
+	}}
+
+	for i, tc := range testCases {
+		body, _ := json.Marshal(Request{Query: tc.query})
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(string(body)))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		var res struct {
+			Errors []struct {
+				Message    string         `json:"message"`
+				Extensions map[string]any `json:"extensions"`
+			} `json:"errors"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+
+		assert.Equal(t, tc.status, resp.StatusCode, "test %d", i)
+		if tc.extensions != nil && assert.Len(t, res.Errors, 1, "test %d", i) {
+			assert.Equal(t, "goraphql_mock_server: query too complex", res.Errors[0].Message, "test %d", i)
+			assert.Equal(t, tc.extensions, res.Errors[0].Extensions, "test %d", i)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// eventStreamContentType is the Content-Type of the responses to live queries, sent over SSE.
+const eventStreamContentType = "text/event-stream"
+
+// LiveResponse implements a Response() that may be updated while clients are connected,
+// re-sending the updated response to every live query (i.e., a query with the @live directive)
+// answered by the mock, so clients implementing live queries may be tested.
+//
+// Since it's shared by every request answered by the mock, it must be created by NewLiveResponse
+// and embedded as a pointer (e.g., struct{ *LiveResponse; KeyOnlyVariables }).
+type LiveResponse struct {
+	// Guards every field below.
+	mu sync.Mutex
+	// The current response.
+	payload any
+	// Closed (and replaced) whenever the response is updated.
+	changed chan struct{}
+}
+
+// NewLiveResponse creates a LiveResponse, initially responding with the payload.
+func NewLiveResponse(payload any) *LiveResponse {
+	return &LiveResponse{
+		payload: payload,
+		changed: make(chan struct{}),
+	}
+}
+
+// Response partially implements MockedRequest for LiveResponse.
+func (l *LiveResponse) Response() any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.payload
+}
+
+// Set updates the response, re-sending it to every live query answered by the mock.
+func (l *LiveResponse) Set(payload any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.payload = payload
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// watch retrieves the current response, along with a channel closed when it's updated.
+func (l *LiveResponse) watch() (any, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.payload, l.changed
+}
+
+// liveMock is implemented by mocks whose response may be updated (i.e., that embed a LiveResponse).
+type liveMock interface {
+	watch() (any, <-chan struct{})
+}
+
+// isLiveRequest checks whether the request is for a live query that may be answered over SSE:
+// a query with the @live directive, from a client accepting an event stream.
+func isLiveRequest(r *http.Request, query string) bool {
+	if !strings.Contains(r.Header.Get("Accept"), eventStreamContentType) || !strings.Contains(query, "@live") {
+		return false
+	}
+
+	doc, err := parseQuery(query)
+	if err != nil {
+		return false
+	}
+
+	for _, op := range doc.operations {
+		if op.kind != string(OperationQuery) {
+			continue
+		}
+
+		for _, dir := range op.directives {
+			if dir.name == "live" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// streamLive answers a live query over SSE, as in the "distinct connections" mode of the GraphQL over SSE protocol.
+// The mock's response is sent as a "next" event, re-sent whenever it's updated (for a LiveResponse),
+// until the client disconnects.
+// If the server is closed first, a "complete" event is sent.
+func (r *registration) streamLive(s *server, w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	for {
+		// Mocks that can't be updated are only sent once.
+		var changed <-chan struct{}
+		res := Response{}
+		if live, ok := r.mock.(liveMock); ok {
+			res.Data, changed = live.watch()
+		} else if generated, err := r.response(req); err != nil {
+			s.logger.Warn("goraphql_mock_server: failed to generate response", "mock", r.logName(), "error", err)
+			res = Response{Errors: []ResponseError{{Message: err.Error()}}}
+		} else {
+			res = generated
+		}
+		if r.fields != nil {
+			res.Fields = r.fields
+		}
+
+		data, err := json.Marshal(s.wrap(res))
+		if err != nil {
+			s.logger.Warn("goraphql_mock_server: failed to encode live response", "error", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: next\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		_ = rc.Flush()
+
+		select {
+		case <-changed:
+		case <-req.Context().Done():
+			return
+		case <-s.done:
+			_, _ = fmt.Fprint(w, "event: complete\ndata:\n\n")
+			_ = rc.Flush()
+			return
+		}
+	}
+}
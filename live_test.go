@@ -0,0 +1,86 @@
+package goraphql_mock_server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readEvent reads the next event sent over SSE, returning its type and data.
+func readEvent(r *bufio.Reader) (string, string, error) {
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return event, data, err
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event, data, nil
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+}
+
+// TestLiveQuery checks that live queries are re-sent whenever their response is updated.
+func TestLiveQuery(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	live := NewLiveResponse(map[string]any{"counter": 1})
+	s.RegisterQuery("counter", struct {
+		*LiveResponse
+		NoVariable
+	}{LiveResponse: live})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL(), strings.NewReader(`{"query": "query @live { counter }"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.Client().Do(req)
+	if !assert.NoError(t, err, "failed to send the live query") {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := bufio.NewReader(resp.Body)
+	event, data, err := readEvent(events)
+	if assert.NoError(t, err, "failed to read the first event") {
+		assert.Equal(t, "next", event)
+		assert.JSONEq(t, `{"data": {"counter": 1}}`, data)
+	}
+
+	live.Set(map[string]any{"counter": 2})
+	event, data, err = readEvent(events)
+	if assert.NoError(t, err, "failed to read the updated event") {
+		assert.Equal(t, "next", event)
+		assert.JSONEq(t, `{"data": {"counter": 2}}`, data)
+	}
+
+	// Queries without @live are answered once, as usual.
+	resp, err = s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { counter }"}`))
+	if assert.NoError(t, err) {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"data": {"counter": 2}}`, string(body))
+	}
+}
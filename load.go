@@ -0,0 +1,56 @@
+package goraphql_mock_server
+
+import (
+	"math"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyCurve computes the latency of a request
+// from the number of requests in flight when it was received, including itself.
+type LatencyCurve func(inFlight int) time.Duration
+
+// LinearLatency adds step to the base latency for every request in flight besides the first.
+func LinearLatency(base, step time.Duration) LatencyCurve {
+	return func(inFlight int) time.Duration {
+		return base + time.Duration(inFlight-1)*step
+	}
+}
+
+// ExponentialLatency multiplies the base latency by factor for every request in flight besides the first,
+// up to limit.
+func ExponentialLatency(base time.Duration, factor float64, limit time.Duration) LatencyCurve {
+	return func(inFlight int) time.Duration {
+		d := float64(base) * math.Pow(factor, float64(inFlight-1))
+		if d > float64(limit) {
+			return limit
+		}
+
+		return time.Duration(d)
+	}
+}
+
+// WithLoadLatency delays every GraphQL request by the latency computed by the curve
+// from the number of requests in flight,
+// so clients' concurrency limiters and circuit breakers may be tested against a server that degrades under load.
+//
+// A request is in flight from the moment it's received until its response is sent.
+// Like in WithLatency, the latency is added before the request is handled,
+// and it's dropped without a response if the client cancels it or the server is closed while waiting.
+func WithLoadLatency(curve LatencyCurve) ServerOptions {
+	return func(s *server) {
+		var inFlight atomic.Int64
+
+		s.faults = append(s.faults, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+
+				if s.sleep(r.Context(), curve(int(n))) {
+					next.ServeHTTP(w, r)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLatencyCurves checks the latency computed by each curve.
+func TestLatencyCurves(t *testing.T) {
+	type testCase struct {
+		// The curve being tested.
+		curve LatencyCurve
+		// The number of requests in flight.
+		inFlight int
+		// The expected latency.
+		expected time.Duration
+	}
+
+	testCases := []testCase{{
+		curve:    LinearLatency(10*time.Millisecond, 5*time.Millisecond),
+		inFlight: 1,
+		expected: 10 * time.Millisecond,
+	}, {
+		curve:    LinearLatency(10*time.Millisecond, 5*time.Millisecond),
+		inFlight: 4,
+		expected: 25 * time.Millisecond,
+	}, {
+		curve:    ExponentialLatency(10*time.Millisecond, 2, time.Second),
+		inFlight: 1,
+		expected: 10 * time.Millisecond,
+	}, {
+		curve:    ExponentialLatency(10*time.Millisecond, 2, time.Second),
+		inFlight: 4,
+		expected: 80 * time.Millisecond,
+	}, {
+		curve:    ExponentialLatency(10*time.Millisecond, 2, time.Second),
+		inFlight: 20,
+		expected: time.Second,
+	}}
+
+	for i, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.curve(tc.inFlight), "test %d", i)
+	}
+}
+
+// TestWithLoadLatency checks that the latency is computed from the number of requests in flight.
+func TestWithLoadLatency(t *testing.T) {
+	var mu sync.Mutex
+	var observed []int
+	curve := func(inFlight int) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		observed = append(observed, inFlight)
+		return 100 * time.Millisecond
+	}
+
+	s := New(WithLoadLatency(curve), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	send := func() {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			send()
+		}()
+	}
+	wg.Wait()
+
+	// Once every request finished, the next one is the only one in flight.
+	send()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if assert.Len(t, observed, 4) {
+		assert.ElementsMatch(t, []int{1, 2, 3}, observed[:3])
+		assert.Equal(t, 1, observed[3])
+	}
+}
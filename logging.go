@@ -0,0 +1,61 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger sends structured events about the server's operation to the logger:
+//
+//   - every received request is logged with slog.LevelDebug;
+//   - every request that matched a mock is logged with slog.LevelDebug;
+//   - every request that didn't match any mock is logged with slog.LevelWarn;
+//   - invalid requests are logged with slog.LevelWarn;
+//   - internal errors (including the ones from the underlying http.Server)
+//     are logged with slog.LevelError.
+//
+// By default, only internal errors are logged, to slog.Default().
+func WithLogger(logger *slog.Logger) ServerOptions {
+	return func(s *server) {
+		s.logger = logger
+		s.server.Config.ErrorLog = slog.NewLogLogger(logger.Handler(), slog.LevelError)
+	}
+}
+
+// defaultLogger creates the logger used by servers without WithLogger,
+// which only logs errors to slog.Default().
+func defaultLogger() *slog.Logger {
+	return slog.New(levelHandler{
+		min:     slog.LevelError,
+		Handler: slog.Default().Handler(),
+	})
+}
+
+// levelHandler implements slog.Handler, discarding every record below a minimum level.
+type levelHandler struct {
+	// The minimum level of the handled records.
+	min slog.Level
+	// The wrapped handler.
+	slog.Handler
+}
+
+// Enabled implements slog.Handler for levelHandler.
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.min && h.Handler.Enabled(ctx, level)
+}
+
+// WithAttrs implements slog.Handler for levelHandler.
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{
+		min:     h.min,
+		Handler: h.Handler.WithAttrs(attrs),
+	}
+}
+
+// WithGroup implements slog.Handler for levelHandler.
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{
+		min:     h.min,
+		Handler: h.Handler.WithGroup(name),
+	}
+}
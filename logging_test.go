@@ -0,0 +1,60 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLogger checks that the server logs structured events.
+func TestLogger(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	s := New(WithLogger(logger))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+	for _, query := range []string{"query { ListFoos { foo } }", "query { ListBars { bar } }"} {
+		var resp map[string]any
+		_ = client.Run(context.Background(), graphql.NewRequest(query), &resp)
+	}
+
+	type event struct {
+		Level      string `json:"level"`
+		Msg        string `json:"msg"`
+		Identifier string `json:"identifier"`
+		Query      string `json:"query"`
+	}
+
+	var events []event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e event
+		if assert.NoError(t, dec.Decode(&e), "failed to decode the log") {
+			events = append(events, e)
+		}
+	}
+
+	if assert.Len(t, events, 4, "unexpected number of events") {
+		assert.Equal(t, "DEBUG", events[0].Level, "unexpected level for the first request")
+		assert.Equal(t, "ListFoos", events[1].Identifier, "unexpected match for the first request")
+		assert.Equal(t, "DEBUG", events[2].Level, "unexpected level for the second request")
+		assert.Equal(t, "WARN", events[3].Level, "unexpected level for the unmatched request")
+		assert.Equal(t, "query { ListBars { bar } }", events[3].Query, "unexpected query for the unmatched request")
+	}
+}
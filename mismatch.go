@@ -0,0 +1,93 @@
+package goraphql_mock_server
+
+import (
+	"strings"
+)
+
+// mismatch explains why a candidate mock didn't match a request.
+// A candidate is any mock whose identifier and operation match the request,
+// so only its variables or its headers failed to match.
+type mismatch struct {
+	// Describes the candidate mock.
+	Mock string `json:"mock"`
+	// The differences between the variables expected by the mock and the request's.
+	// It's nil if the mock's expected variables can't be determined (e.g., for custom matchers).
+	Variables *variablesDiff `json:"variables,omitempty"`
+	// Describes every header expected by the mock that the request doesn't have.
+	Headers []string `json:"headers,omitempty"`
+	// Whether the mock expired (e.g., by ExpiresAfter).
+	Expired bool `json:"expired,omitempty"`
+}
+
+// mismatchExtensions is sent in the extensions of the error for unmatched requests,
+// explaining why each candidate mock didn't match.
+type mismatchExtensions struct {
+	Candidates []mismatch `json:"candidates"`
+}
+
+// String describes the mismatch in a single line.
+func (m mismatch) String() string {
+	var lines []string
+	if m.Expired {
+		lines = append(lines, "expired")
+	}
+	if m.Variables != nil {
+		diff := m.Variables.lines()
+		if len(diff) == 0 {
+			diff = []string{"the variables only differ in their Go types"}
+		}
+		lines = append(lines, diff...)
+	} else if len(m.Headers) == 0 && !m.Expired {
+		lines = []string{"variables didn't match"}
+	}
+	lines = append(lines, m.Headers...)
+
+	return m.Mock + ": " + strings.Join(lines, "; ")
+}
+
+// mismatches explains why every candidate mock, visible from the scope, didn't match the request.
+func (s *server) mismatches(req Request, scope string) []mismatch {
+	op, ok := operationType(req.Query)
+	if !ok && req.persistedID == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var candidates []mismatch
+	for _, id := range s.candidates(req.Query) {
+		for _, reg := range s.registrations[id] {
+			if !reg.visibleFrom(scope) || !reg.matchesQuery(req, op) {
+				continue
+			}
+
+			variablesMatch := reg.compareVariables(req)
+			headers := reg.headerMismatches(req.header)
+			expired := reg.expired()
+			if variablesMatch && len(headers) == 0 && !expired {
+				continue
+			}
+
+			m := mismatch{Mock: reg.describe(s), Headers: headers, Expired: expired}
+			if want, keysOnly, ok := expectedVariables(unwrapMatcher(reg.mock)); ok && !variablesMatch {
+				diff := compareVariables(want, req.variables(), keysOnly)
+				m.Variables = &diff
+			}
+
+			candidates = append(candidates, m)
+		}
+	}
+
+	return candidates
+}
+
+// describeMismatches describes every mismatch, to be logged.
+func describeMismatches(candidates []mismatch) []string {
+	lines := make([]string, len(candidates))
+	for i, m := range candidates {
+		lines[i] = m.String()
+	}
+
+	return lines
+}
@@ -0,0 +1,315 @@
+// Package mockcontainer runs a mock GraphQL server with a lifecycle similar to testcontainers-go's containers,
+// so the mock drops into test suites built around containers.
+//
+// The server may run either in process (see goraphql_mock_server.New)
+// or as the standalone goraphql_mock_server command.
+// Either way, it's started by Run, which waits until it's ready,
+// exposes its address through Host, MappedPort and Endpoint,
+// forwards its logs to the LogConsumer of the request,
+// and is stopped by Terminate.
+package mockcontainer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	mock "github.com/SirGFM/goraphql_mock_server"
+)
+
+// Types of the logs received by a LogConsumer.
+const (
+	// StdoutLog is the type of logs written to the standard output.
+	StdoutLog = "STDOUT"
+	// StderrLog is the type of logs written to the standard error.
+	StderrLog = "STDERR"
+)
+
+// Log is a single line logged by the server.
+type Log struct {
+	// Where the line was logged to: either StdoutLog or StderrLog.
+	LogType string
+	// The logged line, without the line break.
+	Content []byte
+}
+
+// LogConsumer receives every line logged by the server.
+// Its method matches testcontainers-go's LogConsumer,
+// so consumers may be shared with containers by wrapping them in an adapter.
+type LogConsumer interface {
+	Accept(Log)
+}
+
+// Request describes how the server is run.
+type Request struct {
+	// The path to the standalone goraphql_mock_server command.
+	// If empty, the server runs in process.
+	Binary string
+	// The arguments passed to the command (e.g., "-mocks", "testdata/mocks").
+	// Ignored for servers running in process.
+	Args []string
+	// The options of the server running in process.
+	// Ignored for servers running the command.
+	Options []mock.ServerOptions
+	// Checks whether the server is ready, after it's started.
+	// If nil, ForGraphQL is used.
+	WaitingFor WaitStrategy
+	// Receive every line logged by the server.
+	LogConsumers []LogConsumer
+}
+
+// Container is a mock GraphQL server, started by Run.
+type Container struct {
+	// The address of the server.
+	url *url.URL
+	// The server running in process, if any.
+	server mock.Server
+	// The command running the server, if any.
+	cmd *exec.Cmd
+	// Forwards the logs to the consumers.
+	logs *logForwarder
+	// Guards terminated.
+	mu sync.Mutex
+	// Whether the server was terminated.
+	terminated bool
+}
+
+// Run starts the server described by the request, waiting until it's ready.
+// If the server fails to start (or to get ready before ctx is done), it's terminated and an error is returned.
+//
+// Be sure to call Terminate() when done with the server!
+func Run(ctx context.Context, req Request) (*Container, error) {
+	c := &Container{
+		logs: &logForwarder{consumers: req.LogConsumers},
+	}
+
+	var err error
+	if req.Binary == "" {
+		err = c.startServer(req)
+	} else {
+		err = c.startCommand(ctx, req)
+	}
+	if err != nil {
+		_ = c.Terminate(context.Background())
+		return nil, err
+	}
+
+	wait := req.WaitingFor
+	if wait == nil {
+		wait = ForGraphQL()
+	}
+
+	if err := wait.WaitUntilReady(ctx, c); err != nil {
+		_ = c.Terminate(context.Background())
+		return nil, fmt.Errorf("mockcontainer: wait for the server: %w", err)
+	}
+
+	return c, nil
+}
+
+// startServer starts the server in process, logging to the consumers.
+func (c *Container) startServer(req Request) error {
+	logger := slog.New(slog.NewTextHandler(c.logs.writer(StderrLog), &slog.HandlerOptions{Level: slog.LevelDebug}))
+	opts := append(req.Options[:len(req.Options):len(req.Options)], mock.WithLogger(logger))
+
+	s, err := mock.NewE(opts...)
+	if err != nil {
+		return fmt.Errorf("mockcontainer: start the server: %w", err)
+	}
+	c.server = s
+
+	u, err := url.Parse(c.server.URL())
+	if err != nil {
+		return fmt.Errorf("mockcontainer: invalid server address: %w", err)
+	}
+
+	c.url = u
+	return nil
+}
+
+// startCommand runs the standalone server, waiting for it to print its address.
+func (c *Container) startCommand(ctx context.Context, req Request) error {
+	c.cmd = exec.Command(req.Binary, req.Args...)
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("mockcontainer: start %s: %w", req.Binary, err)
+	}
+	c.cmd.Stderr = c.logs.writer(StderrLog)
+
+	if err := c.cmd.Start(); err != nil {
+		return fmt.Errorf("mockcontainer: start %s: %w", req.Binary, err)
+	}
+
+	// The command prints its address in the first line of its output.
+	addr := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		first := true
+		for scanner.Scan() {
+			line := scanner.Text()
+			if first {
+				addr <- line
+				first = false
+			}
+			c.logs.accept(StdoutLog, line)
+		}
+		if first {
+			close(addr)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("mockcontainer: wait for the address of %s: %w", req.Binary, ctx.Err())
+	case line, ok := <-addr:
+		if !ok {
+			return fmt.Errorf("mockcontainer: %s exited without printing its address", req.Binary)
+		}
+
+		u, err := url.Parse(strings.TrimSpace(line))
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("mockcontainer: %s printed an invalid address '%s'", req.Binary, line)
+		}
+
+		c.url = u
+		return nil
+	}
+}
+
+// Server returns the server running in process,
+// so mocks may be registered and requests inspected.
+// It returns nil for servers running the standalone command,
+// which must be managed through their admin API.
+func (c *Container) Server() mock.Server {
+	return c.server
+}
+
+// Host returns the host where the server may be reached.
+func (c *Container) Host(ctx context.Context) (string, error) {
+	return c.url.Hostname(), nil
+}
+
+// MappedPort returns the port where the server may be reached.
+// Since the server listens on a single port, there's nothing to select.
+func (c *Container) MappedPort(ctx context.Context) (int, error) {
+	port, err := strconv.Atoi(c.url.Port())
+	if err != nil {
+		return 0, fmt.Errorf("mockcontainer: invalid port in '%s': %w", c.url, err)
+	}
+
+	return port, nil
+}
+
+// Endpoint returns the address of the server, as "host:port".
+// If proto is set (e.g., "http"), it's prepended as the address' scheme.
+func (c *Container) Endpoint(ctx context.Context, proto string) (string, error) {
+	if proto == "" {
+		return c.url.Host, nil
+	}
+
+	return proto + "://" + c.url.Host, nil
+}
+
+// URL returns the address that a client may use to communicate with the server.
+func (c *Container) URL() string {
+	return c.url.String()
+}
+
+// IsRunning checks whether the server is still running.
+func (c *Container) IsRunning() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.terminated
+}
+
+// Terminate stops the server.
+// It's safe to call it multiple times.
+func (c *Container) Terminate(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.terminated {
+		return nil
+	}
+	c.terminated = true
+
+	if c.server != nil {
+		c.server.Close()
+	}
+	if c.cmd == nil || c.cmd.Process == nil {
+		return nil
+	}
+
+	if err := c.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("mockcontainer: terminate: %w", err)
+	}
+	_ = c.cmd.Wait()
+
+	return nil
+}
+
+// logForwarder sends the lines logged by the server to every consumer.
+type logForwarder struct {
+	// Receive every logged line.
+	consumers []LogConsumer
+	// Serializes the calls to the consumers.
+	mu sync.Mutex
+}
+
+// accept sends the line to every consumer.
+func (f *logForwarder) accept(logType, line string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, consumer := range f.consumers {
+		consumer.Accept(Log{LogType: logType, Content: []byte(line)})
+	}
+}
+
+// writer creates a writer that sends every line written to it to the consumers.
+func (f *logForwarder) writer(logType string) io.Writer {
+	return &lineWriter{forwarder: f, logType: logType}
+}
+
+// lineWriter implements io.Writer, splitting the written data into lines.
+type lineWriter struct {
+	// Receives every complete line.
+	forwarder *logForwarder
+	// The type of the written logs.
+	logType string
+	// Guards partial.
+	mu sync.Mutex
+	// The last line written, if it wasn't terminated yet.
+	partial []byte
+}
+
+// Write implements io.Writer for lineWriter.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.forwarder.accept(w.logType, string(bytes.TrimSuffix(w.partial[:i], []byte("\r"))))
+		w.partial = w.partial[i+1:]
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,119 @@
+package mockcontainer
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mock "github.com/SirGFM/goraphql_mock_server"
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorderConsumer implements LogConsumer, keeping every received log.
+type recorderConsumer struct {
+	mu   sync.Mutex
+	logs []Log
+}
+
+// Accept implements LogConsumer for recorderConsumer.
+func (r *recorderConsumer) Accept(l Log) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logs = append(r.logs, l)
+}
+
+// contains checks whether any log of the type contains the text.
+func (r *recorderConsumer) contains(logType, text string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.logs {
+		if l.LogType == logType && strings.Contains(string(l.Content), text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestRunInProcess checks the lifecycle of a server running in process.
+func TestRunInProcess(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	logs := &recorderConsumer{}
+	c, err := Run(ctx, Request{
+		WaitingFor:   ForAll(ForListeningPort(), ForGraphQL()),
+		LogConsumers: []LogConsumer{logs},
+	})
+	if !assert.NoError(t, err, "failed to run the server") {
+		return
+	}
+	defer c.Terminate(context.Background())
+
+	host, err := c.Host(ctx)
+	assert.NoError(t, err)
+	port, err := c.MappedPort(ctx)
+	assert.NoError(t, err)
+	endpoint, err := c.Endpoint(ctx, "http")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://"+host+":"+strconv.Itoa(port), endpoint, "unexpected endpoint")
+	assert.Equal(t, c.Server().URL(), c.URL(), "unexpected URL")
+
+	c.Server().RegisterQuery("ListFoos", mock.SimpleMockedRequest{
+		StringResponse: mock.StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	var resp map[string]any
+	err = graphql.NewClient(endpoint).Run(ctx, graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+	if assert.NoError(t, err, "failed to send the query") {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp, "unexpected response")
+	}
+	assert.True(t, logs.contains(StderrLog, "request matched"), "the server's logs weren't consumed")
+
+	assert.True(t, c.IsRunning())
+	assert.NoError(t, c.Terminate(ctx))
+	assert.NoError(t, c.Terminate(ctx), "terminating twice failed")
+	assert.False(t, c.IsRunning())
+}
+
+// TestRunCommand checks the lifecycle of a server running as a command.
+// A shell stands in for the standalone server, printing the address of a server running in process.
+func TestRunCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s := mock.New()
+	defer s.Close()
+
+	logs := &recorderConsumer{}
+	c, err := Run(ctx, Request{
+		Binary:       "sh",
+		Args:         []string{"-c", "echo " + s.URL() + "; echo started >&2; exec sleep 30"},
+		LogConsumers: []LogConsumer{logs},
+	})
+	if !assert.NoError(t, err, "failed to run the command") {
+		return
+	}
+
+	assert.Equal(t, s.URL(), c.URL(), "unexpected URL")
+	assert.Nil(t, c.Server(), "a command has an in-process server")
+	assert.Eventually(t, func() bool {
+		return logs.contains(StderrLog, "started")
+	}, time.Second, 10*time.Millisecond, "the command's logs weren't consumed")
+	assert.True(t, logs.contains(StdoutLog, s.URL()), "the command's output wasn't consumed")
+	assert.NoError(t, c.Terminate(ctx))
+
+	_, err = Run(ctx, Request{Binary: "sh", Args: []string{"-c", "exit 1"}})
+	assert.Error(t, err, "a command that didn't print its address was run")
+}
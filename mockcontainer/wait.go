@@ -0,0 +1,104 @@
+package mockcontainer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pollInterval is how long wait strategies wait between their checks.
+const pollInterval = 50 * time.Millisecond
+
+// WaitStrategy checks whether a server is ready to receive requests.
+type WaitStrategy interface {
+	// WaitUntilReady blocks until the server is ready,
+	// returning an error if it doesn't get ready before ctx is done.
+	WaitUntilReady(ctx context.Context, c *Container) error
+}
+
+// WaitStrategyFunc implements WaitStrategy for functions.
+type WaitStrategyFunc func(ctx context.Context, c *Container) error
+
+// WaitUntilReady implements WaitStrategy for WaitStrategyFunc.
+func (fn WaitStrategyFunc) WaitUntilReady(ctx context.Context, c *Container) error {
+	return fn(ctx, c)
+}
+
+// ForListeningPort waits until the server accepts connections.
+func ForListeningPort() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, c *Container) error {
+		return poll(ctx, func() error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", c.url.Host)
+			if err != nil {
+				return err
+			}
+
+			return conn.Close()
+		})
+	})
+}
+
+// ForGraphQL waits until the server answers GraphQL requests.
+// Any response is accepted, since the request doesn't need to match any mock.
+func ForGraphQL() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, c *Container) error {
+		// The server's certificate is self-signed, and only its readiness matters here.
+		client := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		}
+		defer client.CloseIdleConnections()
+
+		return poll(ctx, func() error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL(), strings.NewReader(`{"query": "query { __typename }"}`))
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+
+			return resp.Body.Close()
+		})
+	})
+}
+
+// ForAll waits until every strategy is satisfied, in order.
+func ForAll(strategies ...WaitStrategy) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, c *Container) error {
+		for _, strategy := range strategies {
+			if err := strategy.WaitUntilReady(ctx, c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// poll calls check until it succeeds or until ctx is done,
+// returning the last error in the latter case.
+func poll(ctx context.Context, check func() error) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package goraphql_mock_server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 )
 
 // MockedRequest manages validating whether a mocked request should be returned
@@ -17,6 +19,71 @@ type MockedRequest interface {
 	Response() any
 }
 
+// VariablesMatcher validates whether the variables provided by the GraphQL client
+// match the expected ones.
+// Every partial implementation of MockedRequest that compares variables
+// (e.g., KeyOnlyVariables and ExactVariables) implements this interface,
+// so they may also be used to search the recorded requests.
+type VariablesMatcher interface {
+	// CompareVariables validates if the variables provided by the GraphQL client
+	// matches the expected ones.
+	CompareVariables(v map[string]any) bool
+}
+
+// ContextVariablesMatcher may be implemented by mocks (or matchers) that need the request's context
+// (e.g., to read values injected by a middleware) to compare its variables.
+// If implemented, CompareVariablesContext is called instead of CompareVariables when matching requests.
+type ContextVariablesMatcher interface {
+	// CompareVariablesContext validates if the variables provided by the GraphQL client
+	// match the expected ones, given the context of the HTTP request.
+	CompareVariablesContext(ctx context.Context, v map[string]any) bool
+}
+
+// RawVariablesMatcher may be implemented by mocks (or matchers) that decode the request's variables themselves
+// (e.g., directly into a struct, or streaming huge variables), avoiding decoding them into a map first.
+// If implemented, CompareRawVariables is called instead of CompareVariables when matching requests.
+type RawVariablesMatcher interface {
+	// CompareRawVariables validates if the variables provided by the GraphQL client,
+	// exactly as encoded by the client (or nil, if there aren't any), match the expected ones.
+	CompareRawVariables(raw json.RawMessage) bool
+}
+
+// RawBodyMatcher may be implemented by mocks (or matchers) that need the request's raw body
+// (e.g., to check non-standard fields sent by the client, which aren't decoded into Request).
+// If implemented, CompareRawBody is called after the variables match,
+// and the request only matches the mock if it also returns true.
+type RawBodyMatcher interface {
+	// CompareRawBody validates if the body, exactly as sent by the GraphQL client, matches the expected one.
+	CompareRawBody(body []byte) bool
+}
+
+// ContextResponder may be implemented by mocks (or responders) that need the request's context
+// (e.g., to stop generating a slow response once the client gives up) to generate their response.
+// If implemented, ResponseContext is called instead of Response when answering requests.
+type ContextResponder interface {
+	// ResponseContext returns the object that should be sent as the response to the request,
+	// given the context of the HTTP request.
+	ResponseContext(ctx context.Context) any
+}
+
+// ErrorResponder may be implemented by mocks (or responders) whose response may fail to be generated
+// (e.g., when it's read from a file).
+// If implemented, ResponseE is called instead of Response when answering requests,
+// and the returned error is sent as a GraphQL error, along with any data.
+// Return a ResponseError to set the error's path and extensions.
+type ErrorResponder interface {
+	// ResponseE returns the object that should be sent as the response to the request,
+	// or the error describing why it couldn't be generated.
+	ResponseE() (any, error)
+}
+
+// ContextMockedRequest is a MockedRequest whose methods also receive the request's context.
+type ContextMockedRequest interface {
+	MockedRequest
+	ContextVariablesMatcher
+	ContextResponder
+}
+
 // VariableDecoder converts a generic map of variables
 // to the decoder's type,
 // so the expected variables for a mocked request may be more easily compared.
@@ -46,8 +113,32 @@ func (r RawResponse) Response() any {
 }
 
 // StringResponse implements a Response() that returns this string encoded as an object.
+//
+// Registering a mock whose StringResponse (embedded or not) is set but isn't a valid JSON object panics,
+// reporting the mock's identifier.
+// Use ParseStringResponse (or MustStringResponse) to check it beforehand.
 type StringResponse string
 
+// ParseStringResponse creates a StringResponse, failing if s isn't a valid JSON object.
+func ParseStringResponse(s string) (StringResponse, error) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return "", fmt.Errorf("goraphql_mock_server: invalid StringResponse: %w", err)
+	}
+
+	return StringResponse(s), nil
+}
+
+// MustStringResponse creates a StringResponse like ParseStringResponse, but panics if s isn't a valid JSON object.
+func MustStringResponse(s string) StringResponse {
+	res, err := ParseStringResponse(s)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return res
+}
+
 // Response partially implements MockedRequest for StringResponse.
 func (s StringResponse) Response() any {
 	var data map[string]any
@@ -69,6 +160,15 @@ func (nv NoVariable) CompareVariables(got map[string]any) bool {
 	return len(got) == 0
 }
 
+// AnyVariables implements a CompareVariables()
+// that matches the request regardless of its variables (e.g., for generic fallbacks).
+type AnyVariables struct{}
+
+// CompareVariables partially implements MockedRequest for AnyVariables.
+func (av AnyVariables) CompareVariables(got map[string]any) bool {
+	return true
+}
+
 // KeyOnlyVariables implements a CompareVariables()
 // that checks if the variable's keys exactly matches this object.
 type KeyOnlyVariables []string
@@ -93,30 +193,169 @@ func (kv KeyOnlyVariables) CompareVariables(got map[string]any) bool {
 	return true
 }
 
+// AllowExtra creates a KeyVariables requiring these keys,
+// but also matching requests with other variables.
+func (kv KeyOnlyVariables) AllowExtra() KeyVariables {
+	return KeyVariables{Required: kv}.AllowExtra()
+}
+
+// WithOptional creates a KeyVariables requiring these keys,
+// but also matching requests with any of the optional keys
+// (e.g., for clients that only send some variables when they're set).
+func (kv KeyOnlyVariables) WithOptional(keys ...string) KeyVariables {
+	return KeyVariables{Required: kv}.WithOptional(keys...)
+}
+
+// KeyVariables implements a CompareVariables()
+// that checks the variable's keys, like KeyOnlyVariables,
+// but allowing some (or any) other keys in the request.
+type KeyVariables struct {
+	// The keys that the request's variables must have.
+	Required []string
+	// The keys that the request's variables may have.
+	Optional []string
+	// Whether the request's variables may have keys other than Required and Optional.
+	Extra bool
+}
+
+// AllowExtra makes the matcher also match requests with keys other than Required and Optional.
+func (kv KeyVariables) AllowExtra() KeyVariables {
+	kv.Extra = true
+	return kv
+}
+
+// WithOptional adds the keys to the ones that the request's variables may have.
+func (kv KeyVariables) WithOptional(keys ...string) KeyVariables {
+	kv.Optional = append(slices.Clip(kv.Optional), keys...)
+	return kv
+}
+
+// CompareVariables partially implements MockedRequest for KeyVariables.
+func (kv KeyVariables) CompareVariables(got map[string]any) bool {
+	for _, k := range kv.Required {
+		if _, ok := got[k]; !ok {
+			return false
+		}
+	}
+
+	if kv.Extra {
+		return true
+	}
+
+	for k := range got {
+		if !slices.Contains(kv.Required, k) && !slices.Contains(kv.Optional, k) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // ExactVariables implements a CompareVariables()
 // that checks if the variable's matches exactly whatever was provided,
 // including the type of each variable.
+//
+// Use Exact to compile how the variables are compared once, instead of for every request.
 type ExactVariables struct {
 	// An object that should match the request's variables.
 	// If this object implements VariableDecoder,
 	// then Variable() is called on the request's variables
 	// to convert it to the same type as this object before comparing them.
 	Variables any
+
+	// How the variables are compared, if compiled by Exact.
+	plan *exactPlan
+}
+
+// Exact creates an ExactVariables matching the variables exactly,
+// compiling how they're compared to the request's variables only once.
+// It matches the same requests as ExactVariables{Variables: v},
+// but avoids reflection and allocations for every request (e.g., in load tests),
+// as long as v isn't modified afterwards.
+func Exact(v any) ExactVariables {
+	ev := ExactVariables{Variables: v}
+	plan := ev.compile()
+	ev.plan = &plan
+
+	return ev
+}
+
+// exactPlan describes how an ExactVariables compares the request's variables.
+type exactPlan struct {
+	// Converts the request's variables, if Variables implements VariableDecoder.
+	decoder VariableDecoder
+	// The expected variables, if typed like a request's variables,
+	// so they may be compared without reflection.
+	want map[string]any
+}
+
+// compile decides how the variables are compared to the request's variables.
+func (ev ExactVariables) compile() exactPlan {
+	if decoder, ok := ev.Variables.(VariableDecoder); ok {
+		return exactPlan{decoder: decoder}
+	}
+
+	want, _ := ev.Variables.(map[string]any)
+	return exactPlan{want: want}
 }
 
 // CompareVariables partially implements MockedRequest for ExactVariables.
 func (ev ExactVariables) CompareVariables(reqVar map[string]any) bool {
-	var got any
+	plan := ev.plan
+	if plan == nil {
+		compiled := ev.compile()
+		plan = &compiled
+	}
 
-	decoder, ok := ev.Variables.(VariableDecoder)
-	if ok {
-		got, ok = decoder.Variable(reqVar)
-		if !ok {
+	switch {
+	case plan.decoder != nil:
+		got, ok := plan.decoder.Variable(reqVar)
+		return ok && reflect.DeepEqual(ev.Variables, got)
+	case plan.want != nil:
+		return equalValues(plan.want, reqVar)
+	default:
+		return reflect.DeepEqual(ev.Variables, reqVar)
+	}
+}
+
+// equalValues checks whether want and got are deeply equal, like reflect.DeepEqual,
+// but without reflection for the types of a request's decoded variables.
+func equalValues(want, got any) bool {
+	switch want := want.(type) {
+	case nil:
+		return got == nil
+	case string:
+		got, ok := got.(string)
+		return ok && want == got
+	case float64:
+		got, ok := got.(float64)
+		return ok && want == got
+	case bool:
+		got, ok := got.(bool)
+		return ok && want == got
+	case map[string]any:
+		got, ok := got.(map[string]any)
+		if !ok || (want == nil) != (got == nil) || len(want) != len(got) {
 			return false
 		}
-	} else {
-		got = reqVar
+		for k, v := range want {
+			if gotValue, ok := got[k]; !ok || !equalValues(v, gotValue) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		got, ok := got.([]any)
+		if !ok || (want == nil) != (got == nil) || len(want) != len(got) {
+			return false
+		}
+		for i := range want {
+			if !equalValues(want[i], got[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(want, got)
 	}
-
-	return reflect.DeepEqual(ev.Variables, got)
 }
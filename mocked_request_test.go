@@ -80,6 +80,53 @@ func (EncodedVariable) Variable(req map[string]any) (any, bool) {
 	return ret, err == nil
 }
 
+// TestKeyVariables checks that KeyVariables allows optional and extra keys.
+func TestKeyVariables(t *testing.T) {
+	type testCase struct {
+		// The desired result.
+		want bool
+		// The structure that could have be registered to an operation.
+		registeredVar KeyVariables
+		// The variables that could have been received in a request.
+		requestVar map[string]any
+	}
+
+	testCases := []testCase{{
+		want:          true,
+		registeredVar: KeyOnlyVariables{"foo"}.WithOptional("page"),
+		requestVar:    map[string]any{"foo": 1},
+	}, {
+		want:          true,
+		registeredVar: KeyOnlyVariables{"foo"}.WithOptional("page"),
+		requestVar:    map[string]any{"foo": 1, "page": 2},
+	}, {
+		want:          false,
+		registeredVar: KeyOnlyVariables{"foo"}.WithOptional("page"),
+		requestVar:    map[string]any{"foo": 1, "page": 2, "size": 3},
+	}, {
+		want:          false,
+		registeredVar: KeyOnlyVariables{"foo"}.WithOptional("page"),
+		requestVar:    map[string]any{"page": 2},
+	}, {
+		want:          true,
+		registeredVar: KeyOnlyVariables{"foo"}.AllowExtra(),
+		requestVar:    map[string]any{"foo": 1, "page": 2, "size": 3},
+	}, {
+		want:          false,
+		registeredVar: KeyOnlyVariables{"foo"}.AllowExtra(),
+		requestVar:    map[string]any{"page": 2},
+	}, {
+		want:          true,
+		registeredVar: KeyVariables{Optional: []string{"page"}},
+		requestVar:    nil,
+	}}
+
+	for _, tc := range testCases {
+		got := tc.registeredVar.CompareVariables(tc.requestVar)
+		assert.Equal(t, tc.want, got, "var: %v, request: %v", tc.registeredVar, tc.requestVar)
+	}
+}
+
 // TestExactVariable checks that ExactVariables properly matches
 // both the key and the value in the dictionary,
 // converting it as necessary.
@@ -190,5 +237,46 @@ func TestExactVariable(t *testing.T) {
 	for _, tc := range testCases {
 		got := tc.registeredVar.CompareVariables(tc.requestVar)
 		assert.Equal(t, tc.want, got, "var: %v", tc.registeredVar)
+
+		got = Exact(tc.registeredVar.Variables).CompareVariables(tc.requestVar)
+		assert.Equal(t, tc.want, got, "compiled var: %v", tc.registeredVar)
 	}
 }
+
+// TestExactCompiled checks that compiled ExactVariables are compared without allocations,
+// and that they still tell apart values with different types.
+func TestExactCompiled(t *testing.T) {
+	ev := Exact(map[string]any{
+		"num":  2.0,
+		"tags": []any{"a", "b"},
+		"page": map[string]any{"first": 10.0, "after": nil},
+	})
+
+	var req map[string]any
+	err := json.Unmarshal([]byte(`{"num": 2, "tags": ["a", "b"], "page": {"first": 10, "after": null}}`), &req)
+	assert.NoError(t, err)
+
+	assert.True(t, ev.CompareVariables(req), "the decoded variables didn't match")
+	allocs := testing.AllocsPerRun(100, func() {
+		ev.CompareVariables(req)
+	})
+	assert.Zero(t, allocs, "comparing the variables allocated memory")
+
+	req["num"] = 2
+	assert.False(t, ev.CompareVariables(req), "an int matched a float64")
+	req["num"] = 2.0
+	req["tags"] = []any{"a"}
+	assert.False(t, ev.CompareVariables(req), "a shorter list matched")
+}
+
+// TestParseStringResponse checks that invalid StringResponses are detected beforehand.
+func TestParseStringResponse(t *testing.T) {
+	res, err := ParseStringResponse(`{"ListFoos": {"foo": 123}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, StringResponse(`{"ListFoos": {"foo": 123}}`), res)
+
+	_, err = ParseStringResponse(`{"ListFoos": `)
+	assert.ErrorContains(t, err, "goraphql_mock_server: invalid StringResponse")
+
+	assert.Panics(t, func() { MustStringResponse(`[1, 2]`) })
+}
@@ -0,0 +1,107 @@
+package goraphql_mock_server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultNamespaceHeader is the header used by WithNamespaceHeader if none is specified.
+const DefaultNamespaceHeader = "X-Mock-Namespace"
+
+// namespaceScopePrefix prefixes the scope of every namespace created by Server.Namespace,
+// so it never conflicts with the scopes created by Scope and At.
+const namespaceScopePrefix = "ns:"
+
+// WithNamespaceHeader isolates the requests carrying the header in namespaces, selected by the header's value,
+// so parallel tests sharing a single long-lived server may send their requests to the server's URL
+// without seeing each other's mocks and requests.
+//
+// Each namespace is managed through the view returned by Server.Namespace.
+// Requests without the header aren't in any namespace.
+//
+// If header is empty, DefaultNamespaceHeader is used.
+func WithNamespaceHeader(header string) ServerOptions {
+	return func(s *server) {
+		if header == "" {
+			header = DefaultNamespaceHeader
+		}
+
+		s.namespaceHeader = http.CanonicalHeaderKey(header)
+	}
+}
+
+// Namespace implements Server for server.
+func (s *server) Namespace(name string) Server {
+	if s.namespaceHeader == "" {
+		panic("goraphql_mock_server: Namespace requires a server started WithNamespaceHeader")
+	} else if name == "" {
+		panic("goraphql_mock_server: a namespace must have a name")
+	}
+
+	return &scopedServer{
+		server: s,
+		id:     namespaceScopePrefix + name,
+	}
+}
+
+// requestNamespace retrieves the scope of the namespace selected by the request, if any.
+func (s *server) requestNamespace(r *http.Request) (string, bool) {
+	if s.namespaceHeader == "" {
+		return "", false
+	}
+
+	name := r.Header.Get(s.namespaceHeader)
+	if name == "" {
+		return "", false
+	}
+
+	return namespaceScopePrefix + name, true
+}
+
+// namespace retrieves the name of the namespace viewed by the scope, if it's a namespace.
+func (sc *scopedServer) namespace() (string, bool) {
+	return strings.CutPrefix(sc.id, namespaceScopePrefix)
+}
+
+// Client implements Server for scopedServer.
+// The client of a namespace sends the namespace's header in every request.
+func (sc *scopedServer) Client() *http.Client {
+	client := sc.server.Client()
+
+	name, ok := sc.namespace()
+	if !ok {
+		return client
+	}
+
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	nsClient := *client
+	nsClient.Transport = namespaceTransport{
+		header: sc.namespaceHeader,
+		name:   name,
+		next:   transport,
+	}
+
+	return &nsClient
+}
+
+// namespaceTransport implements http.RoundTripper, selecting a namespace in every request.
+type namespaceTransport struct {
+	// The header selecting the namespace.
+	header string
+	// The namespace's name.
+	name string
+	// Sends the requests.
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper for namespaceTransport.
+func (nt namespaceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(nt.header, nt.name)
+
+	return nt.next.RoundTrip(req)
+}
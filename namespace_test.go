@@ -0,0 +1,84 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNamespace checks that requests selecting a namespace through the header
+// only see the namespace's mocks, and are only recorded in its history.
+func TestNamespace(t *testing.T) {
+	s := New(WithNamespaceHeader(""), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 0}}`),
+	})
+
+	t.Run("group", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+
+				ns := s.Namespace(fmt.Sprintf("test-%d", i))
+				defer ns.Close()
+
+				assert.Equal(t, s.URL(), ns.URL(), "the namespace didn't share the server's URL")
+				ns.RegisterQuery("ListFoos", SimpleMockedRequest{
+					StringResponse: StringResponse(fmt.Sprintf(`{"ListFoos": {"foo": %d}}`, i)),
+				})
+
+				for j := 0; j < i; j++ {
+					var resp map[string]any
+					err := ns.GraphQLClient().Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+					assert.NoError(t, err)
+					assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": float64(i)}}, resp)
+				}
+
+				assert.Len(t, ns.Requests(), i, "the namespace saw other requests")
+				assert.Equal(t, i, ns.Counts()["ListFoos"])
+			})
+		}
+	})
+
+	// Requests may also select the namespace by sending the header themselves.
+	ns := s.Namespace("manual")
+	ns.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 42}}`),
+	})
+
+	req, err := http.NewRequest(http.MethodPost, s.URL(), strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DefaultNamespaceHeader, "manual")
+
+	res, err := s.Client().Do(req)
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.JSONEq(t, `{"data": {"ListFoos": {"foo": 42}}}`, string(body))
+	}
+	assert.Len(t, ns.Requests(), 1)
+
+	// Requests without the header only see the server's mocks.
+	var resp map[string]any
+	err = s.GraphQLClient().Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 0.0}}, resp)
+
+	ns.Close()
+	assert.Empty(t, ns.Requests(), "closing the namespace didn't remove its history")
+	assert.Len(t, s.Requests(), 1, "the namespaces' requests weren't removed")
+
+	plain := New()
+	defer plain.Close()
+	assert.Panics(t, func() { plain.Namespace("test") }, "a namespace was created without the header")
+}
@@ -3,12 +3,15 @@ package goraphql_mock_server
 import (
 	"fmt"
 	"net"
+	"net/http"
+	"time"
 )
 
 // ServerOptions defines a function used to configure the server.
 type ServerOptions func(s *server)
 
 // WithAddress defines a specific address which the mock server should listen on.
+// The server fails to start if it can't listen on the address.
 func WithAddress(ip string, port uint16, ipv6 bool) ServerOptions {
 	return func(s *server) {
 		var err error
@@ -16,7 +19,8 @@ func WithAddress(ip string, port uint16, ipv6 bool) ServerOptions {
 		if s.server.Listener != nil {
 			err = s.server.Listener.Close()
 			if err != nil {
-				panic(fmt.Sprintf("goraphql_mock_server: failed to close the original listener: %v", err))
+				s.fail(fmt.Errorf("goraphql_mock_server: failed to close the original listener: %w", err))
+				return
 			}
 		}
 
@@ -28,7 +32,7 @@ func WithAddress(ip string, port uint16, ipv6 bool) ServerOptions {
 
 		s.server.Listener, err = net.Listen(protocol, addr)
 		if err != nil {
-			panic(fmt.Sprintf("goraphql_mock_server: failed to listen on %s: %v", addr, err))
+			s.fail(fmt.Errorf("goraphql_mock_server: failed to listen on %s: %w", addr, err))
 		}
 	}
 }
@@ -39,3 +43,109 @@ func WithTLS() ServerOptions {
 		s.useTLS = true
 	}
 }
+
+// WithMaxBodySize limits the size, in bytes, of the requests accepted by the mock server.
+//
+// Requests whose body is larger than this are rejected
+// with a 413 (Request Entity Too Large) and a GraphQL error.
+func WithMaxBodySize(size int64) ServerOptions {
+	return func(s *server) {
+		s.maxBodySize = size
+	}
+}
+
+// WithMaxVariablesSize avoids decoding huge variables, larger than size bytes, for every request,
+// so clients sending multi-megabyte variables may be load tested.
+//
+// These variables are only decoded (once per request) if some matcher needs them decoded:
+// matchers implementing RawVariablesMatcher (e.g., typed mocks) receive them encoded instead.
+// Unless decoded, they aren't set in the request's Variables nor recorded in the history,
+// but they're still available from the request's RawVariables and RawBody.
+func WithMaxVariablesSize(size int) ServerOptions {
+	return func(s *server) {
+		s.maxVariablesSize = size
+	}
+}
+
+// WithMiddleware wraps the handler for GraphQL requests with the middlewares,
+// so requests may be inspected or modified (e.g., to check authentication headers)
+// before being processed by the mock server.
+//
+// Middlewares are applied in the order they are provided,
+// so the first one is the first to receive the request.
+// This option may be used multiple times, appending more middlewares to the chain.
+func WithMiddleware(mw ...func(next http.Handler) http.Handler) ServerOptions {
+	return func(s *server) {
+		s.middlewares = append(s.middlewares, mw...)
+	}
+}
+
+// WithRequestCallback calls fn for every request received by the server,
+// after matching it against the registered mocks but before sending the response.
+//
+// fn is called from the goroutine handling the request,
+// so it may be used to synchronize the test with the client.
+// However, the request is only answered after fn returns.
+func WithRequestCallback(fn func(req RecordedRequest)) ServerOptions {
+	return func(s *server) {
+		s.callbacks = append(s.callbacks, fn)
+	}
+}
+
+// WithRequestChannel sends every request received by the server to ch,
+// after matching it against the registered mocks but before sending the response.
+//
+// The request is only answered after it's sent to the channel,
+// so be sure to either buffer the channel or to keep reading from it.
+// Requests are dropped if the server is closed while blocked on the channel.
+func WithRequestChannel(ch chan<- RecordedRequest) ServerOptions {
+	return func(s *server) {
+		s.callbacks = append(s.callbacks, func(req RecordedRequest) {
+			select {
+			case ch <- req:
+			case <-s.done:
+			}
+		})
+	}
+}
+
+// WithReadTimeout sets the maximum duration for reading an entire request,
+// including its body.
+func WithReadTimeout(timeout time.Duration) ServerOptions {
+	return func(s *server) {
+		s.server.Config.ReadTimeout = timeout
+	}
+}
+
+// WithReadHeaderTimeout sets the maximum duration for reading a request's headers.
+func WithReadHeaderTimeout(timeout time.Duration) ServerOptions {
+	return func(s *server) {
+		s.server.Config.ReadHeaderTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the maximum duration before timing out the writes of a response.
+func WithWriteTimeout(timeout time.Duration) ServerOptions {
+	return func(s *server) {
+		s.server.Config.WriteTimeout = timeout
+	}
+}
+
+// WithIdleTimeout sets the maximum duration to wait for the next request
+// on a connection with keep-alives enabled.
+func WithIdleTimeout(timeout time.Duration) ServerOptions {
+	return func(s *server) {
+		s.server.Config.IdleTimeout = timeout
+	}
+}
+
+// WithKeepAlives controls whether the mock server keeps connections alive between requests.
+//
+// By default, keep-alives are enabled.
+// Disabling them causes the server to close the connection after every response,
+// forcing the client to open a new one for each request.
+func WithKeepAlives(enabled bool) ServerOptions {
+	return func(s *server) {
+		s.server.Config.SetKeepAlivesEnabled(enabled)
+	}
+}
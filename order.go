@@ -0,0 +1,60 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InOrder implements Server for server.
+func (s *server) InOrder(identifiers ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.orders = append(s.orders, append([]string(nil), identifiers...))
+}
+
+// checkOrder verifies that calling the identifier doesn't violate any declared order,
+// recording a violation otherwise.
+// It must be called before the call is recorded in the history.
+// s.mu must be held by the caller.
+func (s *server) checkOrder(identifier string) {
+	for _, order := range s.orders {
+		for i, id := range order {
+			if id != identifier || i == 0 {
+				continue
+			}
+
+			prev := order[i-1]
+			if s.calls(prev) > 0 {
+				continue
+			}
+
+			var trace []string
+			for _, entry := range s.history.list() {
+				if entry.Matched {
+					trace = append(trace, entry.Identifier)
+				}
+			}
+			trace = append(trace, identifier)
+
+			s.orderViolations = append(s.orderViolations, fmt.Sprintf(
+				"'%s' was called before '%s' (expected order: %s; calls: %s)",
+				identifier,
+				prev,
+				strings.Join(order, " -> "),
+				strings.Join(trace, " -> "),
+			))
+		}
+	}
+}
+
+// calls counts how many times the mocks registered with the identifier were called.
+// s.mu must be held by the caller.
+func (s *server) calls(identifier string) int {
+	calls := 0
+	for _, reg := range s.registrations[identifier] {
+		calls += reg.calls
+	}
+
+	return calls
+}
@@ -1,15 +1,218 @@
 package goraphql_mock_server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
+	"sync"
 )
 
 // Request maps the received GraphQL request into a go structure.
 type Request struct {
 	Query     string         `json:"query"`
 	Variables map[string]any `json:"variables"`
+	// The name of the operation to be executed, if sent by the client.
+	OperationName string `json:"operationName,omitempty"`
+	// The request's extensions (e.g., for persisted queries), if any.
+	Extensions map[string]any `json:"extensions,omitempty"`
+
+	// The request's HTTP headers, matched by MatchHeader.
+	header http.Header
+	// The ID of the request's persisted query, if any.
+	persistedID string
+	// The context of the HTTP request.
+	ctx context.Context
+	// The request's raw body, as sent by the client.
+	body []byte
+	// The fields of the request that aren't decoded into the struct, by name.
+	unknown map[string]json.RawMessage
+	// The request's variables, as sent by the client.
+	rawVariables json.RawMessage
+	// Decodes the variables when they're first needed,
+	// if they were too large to be decoded into Variables (see WithMaxVariablesSize).
+	lazy *lazyVariables
+}
+
+// lazyVariables decodes a request's variables only when they're first needed,
+// so huge variables aren't decoded unless some matcher needs them decoded.
+type lazyVariables struct {
+	// Guards every field below.
+	mu sync.Mutex
+	// The encoded variables.
+	raw json.RawMessage
+	// Whether the variables were already decoded.
+	done bool
+	// The decoded variables.
+	decoded map[string]any
+}
+
+// get decodes the variables, if they weren't decoded yet.
+// Variables that can't be decoded are treated as if they weren't sent.
+func (l *lazyVariables) get() map[string]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.done {
+		_ = json.Unmarshal(l.raw, &l.decoded)
+		l.done = true
+	}
+
+	return l.decoded
+}
+
+// peek retrieves the variables only if they were already decoded.
+func (l *lazyVariables) peek() map[string]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.decoded
+}
+
+// Context returns the context of the HTTP request,
+// carrying any value injected by middlewares and canceled if the client disconnects.
+// It's never nil.
+func (r Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+
+	return r.ctx
+}
+
+// RawBody returns the request's body exactly as sent by the client,
+// so fields dropped while decoding the request (e.g., non-standard ones) may be inspected.
+// It must not be modified.
+func (r Request) RawBody() []byte {
+	return r.body
+}
+
+// RawVariables returns the request's variables exactly as sent by the client (i.e., encoded as JSON),
+// so they may be decoded directly into the desired type (e.g., by a RawVariablesMatcher).
+// It's nil if the request didn't have any variables. It must not be modified.
+func (r Request) RawVariables() json.RawMessage {
+	if r.rawVariables == nil && r.Variables != nil {
+		// The request wasn't decoded by the server (e.g., it was built by a test).
+		raw, _ := json.Marshal(r.Variables)
+		return raw
+	}
+
+	return r.rawVariables
+}
+
+// variables retrieves the request's decoded variables,
+// decoding them now if they were too large to be decoded into Variables.
+func (r Request) variables() map[string]any {
+	if r.lazy != nil {
+		return r.lazy.get()
+	}
+
+	return r.Variables
+}
+
+// Field returns the encoded value of a field sent in the request that isn't decoded into the struct
+// (e.g., a non-standard field added by the client), and whether it was sent.
+func (r Request) Field(name string) (json.RawMessage, bool) {
+	value, ok := r.unknown[name]
+	return value, ok
+}
+
+// requestFields are the fields decoded into a Request.
+var requestFields = []string{"query", "variables", "operationName", "extensions"}
+
+// formContentType is the Content-Type of requests sent as form fields, by some legacy clients.
+const formContentType = "application/x-www-form-urlencoded"
+
+// decodeRequest decodes the body of a GraphQL request, according to its Content-Type.
+// Requests are usually JSON-encoded, but may also be sent as form fields
+// (with the variables JSON-encoded in the "variables" field).
+//
+// If maxVariables is positive, variables larger than it (in bytes) are only decoded when first needed.
+func decodeRequest(contentType string, body []byte, maxVariables int) (Request, error) {
+	var req Request
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != formContentType {
+		// Split the fields without decoding them, so the variables may be kept encoded
+		// and every unknown field may be inspected by Field.
+		if err := json.Unmarshal(body, &req.unknown); err != nil {
+			return req, err
+		}
+
+		decode := func(name string, v any) error {
+			raw, ok := req.unknown[name]
+			if !ok {
+				return nil
+			}
+
+			delete(req.unknown, name)
+			if err := json.Unmarshal(raw, v); err != nil {
+				return fmt.Errorf("decode %s: %w", name, err)
+			}
+			return nil
+		}
+		if err := decode("query", &req.Query); err != nil {
+			return req, err
+		} else if err := decode("operationName", &req.OperationName); err != nil {
+			return req, err
+		} else if err := decode("extensions", &req.Extensions); err != nil {
+			return req, err
+		}
+
+		variables := req.unknown["variables"]
+		delete(req.unknown, "variables")
+		return req, req.setVariables(variables, maxVariables)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return req, err
+	}
+
+	req.Query = form.Get("query")
+	req.OperationName = form.Get("operationName")
+	if variables := form.Get("variables"); variables != "" {
+		if err := req.setVariables([]byte(variables), maxVariables); err != nil {
+			return req, err
+		}
+	}
+	if extensions := form.Get("extensions"); extensions != "" {
+		if err := json.Unmarshal([]byte(extensions), &req.Extensions); err != nil {
+			return req, fmt.Errorf("decode extensions: %w", err)
+		}
+	}
+
+	for name, values := range form {
+		if !slices.Contains(requestFields, name) && len(values) > 0 {
+			if req.unknown == nil {
+				req.unknown = make(map[string]json.RawMessage)
+			}
+			req.unknown[name], _ = json.Marshal(values[0])
+		}
+	}
+
+	return req, nil
+}
+
+// setVariables decodes the request's encoded variables,
+// unless they're larger than maxVariables (if positive), in which case they're only decoded when first needed.
+func (r *Request) setVariables(raw json.RawMessage, maxVariables int) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	r.rawVariables = raw
+	if maxVariables > 0 && len(raw) > maxVariables {
+		r.lazy = &lazyVariables{raw: raw}
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, &r.Variables); err != nil {
+		return fmt.Errorf("decode variables: %w", err)
+	}
+	return nil
 }
 
 // ResponseError maps an error response into a go structure.
@@ -19,14 +222,54 @@ type ResponseError struct {
 	Extensions any      `json:"extensions"`
 }
 
+// Error implements error for ResponseError,
+// so it may be returned by a QueryFunc or an ErrorResponder.
+func (e ResponseError) Error() string {
+	return e.Message
+}
+
 // ResponseError maps a successful response into a go structure.
 type Response struct {
-	Data   any             `json:"data"`
-	Errors []ResponseError `json:"errors,omitempty"`
+	Data       any             `json:"data"`
+	Errors     []ResponseError `json:"errors,omitempty"`
+	Extensions any             `json:"extensions,omitempty"`
+
+	// Extra top-level members (e.g., vendor-specific ones), sent along with the standard ones.
+	// Members named like a standard one are ignored.
+	Fields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler for Response, adding its extra Fields to the encoded object.
+func (r Response) MarshalJSON() ([]byte, error) {
+	// response has the same fields as Response, but isn't a json.Marshaler.
+	type response Response
+
+	data, err := json.Marshal(response(r))
+	if err != nil || len(r.Fields) == 0 {
+		return data, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for name, value := range r.Fields {
+		if _, ok := members[name]; ok {
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("encode field '%s': %w", name, err)
+		}
+		members[name] = encoded
+	}
+
+	return json.Marshal(members)
 }
 
 // respondError sends a ResponseError with the specified data and no errors.
-func respondError(w http.ResponseWriter, status int, err error, extensions any) {
+func respondError(w http.ResponseWriter, r *http.Request, status int, err error, extensions any) {
 	res := Response{
 		Errors: []ResponseError{{
 			Message:    err.Error(),
@@ -34,23 +277,53 @@ func respondError(w http.ResponseWriter, status int, err error, extensions any)
 		}},
 	}
 
-	respond(w, status, res)
+	respondGraphQL(w, r, status, res)
 }
 
 // respondResponse sends a Response with the specified data and no errors.
-func respondResponse(w http.ResponseWriter, status int, data any) {
+func respondResponse(w http.ResponseWriter, r *http.Request, status int, data any) {
 	res := Response{
 		Data:   data,
 		Errors: nil,
 	}
 
-	respond(w, status, res)
+	respondGraphQL(w, r, status, res)
+}
+
+// respondGraphQL sends the GraphQL response to the request,
+// wrapped into the envelope of the server that received it, if set WithEnvelope.
+func respondGraphQL(w http.ResponseWriter, r *http.Request, status int, res Response) {
+	var payload any = res
+	if envelope := envelopeOf(r); envelope != nil {
+		payload = envelope(res)
+	}
+
+	respond(w, status, payload)
 }
 
-// respond sends a response with the specified status code and payload.
+// respond sends a response with the specified status code and payload, encoded as JSON.
+// If the payload can't be encoded, a 500 with a GraphQL error describing the failure is sent instead.
 func respond(w http.ResponseWriter, status int, payload any) {
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		panic(fmt.Sprintf("goraphql_mock_server: failed to encode response: %v", err))
+	// The encoder also appends the trailing newline.
+	eb := getEncoder()
+	defer putEncoder(eb)
+
+	if err := eb.enc.Encode(payload); err != nil {
+		status = http.StatusInternalServerError
+		eb.Reset()
+		_ = eb.enc.Encode(Response{
+			Errors: []ResponseError{{Message: fmt.Sprintf("goraphql_mock_server: failed to encode response: %v", err)}},
+		})
 	}
+
+	writeJSON(w, status, eb.Bytes())
+}
+
+// writeJSON sends a response with the specified status code and the already encoded body.
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	if _, ok := w.Header()["Content-Type"]; !ok {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
 }
@@ -0,0 +1,162 @@
+package goraphql_mock_server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// apolloManifestFormat identifies Apollo's persisted query manifests.
+const apolloManifestFormat = "apollo-persisted-query-manifest"
+
+// Errors sent for requests with a persisted query, in the same format as Apollo Server's.
+var (
+	errPersistedQueryNotFound = errors.New("PersistedQueryNotFound")
+	errPersistedQueryMismatch = errors.New("provided sha does not match query")
+)
+
+// persistedQueryNotFoundExtensions is sent in the extensions of the error for unknown persisted queries.
+type persistedQueryNotFoundExtensions struct {
+	Code string `json:"code"`
+}
+
+// PersistedQueries maps the ID (e.g., the SHA-256 hash) of each persisted query to its text.
+type PersistedQueries map[string]string
+
+// apolloManifest is a persisted query manifest generated by Apollo's tooling.
+type apolloManifest struct {
+	Format     string `json:"format"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// ParsePersistedQueries decodes a persisted query manifest,
+// either in Apollo's format (with a list of operations, as generated by generate-persisted-query-manifest)
+// or in Relay's format (a JSON object mapping each ID to its query).
+func ParsePersistedQueries(r io.Reader) (PersistedQueries, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: read persisted queries: %w", err)
+	}
+
+	var apollo apolloManifest
+	if err := json.Unmarshal(data, &apollo); err == nil && apollo.Format == apolloManifestFormat {
+		queries := make(PersistedQueries, len(apollo.Operations))
+		for _, op := range apollo.Operations {
+			queries[op.ID] = op.Body
+		}
+
+		return queries, nil
+	}
+
+	var queries PersistedQueries
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: decode persisted queries: %w", err)
+	}
+
+	return queries, nil
+}
+
+// WithPersistedQueries resolves the persisted queries sent by clients (i.e., their IDs) to the queries,
+// so they are matched like any other query.
+//
+// Regardless of this option, queries sent with Apollo's automatic persisted queries
+// (i.e., with both the query and its hash) are persisted,
+// and unknown persisted queries are answered with a PersistedQueryNotFound error,
+// so the client may retry with the complete query.
+func WithPersistedQueries(queries PersistedQueries) ServerOptions {
+	return func(s *server) {
+		for id, query := range queries {
+			s.persistedQueries[id] = query
+		}
+	}
+}
+
+// RegisterPersistedQuery implements Server for server.
+func (s *server) RegisterPersistedQuery(id string, mock MockedRequest, opts ...RegisterOptions) {
+	s.mustRegisterIn("", id, OperationQuery, mock, append([]RegisterOptions{persistedQuery(id)}, opts...))
+}
+
+// persistedQuery makes the mock match requests by their persisted query's ID, instead of by their query.
+func persistedQuery(id string) RegisterOptions {
+	return func(r *registration) {
+		r.persistedID = id
+	}
+}
+
+// persistedRequest is the part of a request that identifies its persisted query.
+type persistedRequest struct {
+	Extensions struct {
+		PersistedQuery *struct {
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+	// Relay's conventions for persisted queries.
+	DocID      string `json:"doc_id"`
+	DocumentID string `json:"documentId"`
+	ID         string `json:"id"`
+}
+
+// resolvePersistedQuery resolves the request's persisted query, if any,
+// storing its ID in the request and replacing its query.
+// Queries sent along with their hash are persisted.
+// It answers the request, returning false, if the persisted query can't be resolved.
+func (s *server) resolvePersistedQuery(w http.ResponseWriter, r *http.Request, body []byte, req *Request) bool {
+	var persisted persistedRequest
+	if err := json.Unmarshal(body, &persisted); err != nil {
+		return true
+	}
+
+	var hash string
+	if pq := persisted.Extensions.PersistedQuery; pq != nil {
+		hash = pq.SHA256Hash
+	}
+
+	id := hash
+	for _, alt := range []string{persisted.DocID, persisted.DocumentID, persisted.ID} {
+		if id == "" {
+			id = alt
+		}
+	}
+	if id == "" {
+		return true
+	}
+	req.persistedID = id
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Query != "" {
+		if sum := sha256.Sum256([]byte(req.Query)); hash != "" && hex.EncodeToString(sum[:]) != hash {
+			s.logger.Warn("goraphql_mock_server: persisted query doesn't match its hash", "hash", hash)
+			respondError(w, r, http.StatusBadRequest, errPersistedQueryMismatch, nil)
+			return false
+		}
+
+		s.persistedQueries[id] = req.Query
+		return true
+	}
+
+	if query, ok := s.persistedQueries[id]; ok {
+		req.Query = query
+		return true
+	}
+
+	for _, registrations := range s.registrations {
+		for _, reg := range registrations {
+			if reg.persistedID == id {
+				return true
+			}
+		}
+	}
+
+	s.logger.Warn("goraphql_mock_server: unknown persisted query", "id", id)
+	respondError(w, r, http.StatusOK, errPersistedQueryNotFound, persistedQueryNotFoundExtensions{Code: "PERSISTED_QUERY_NOT_FOUND"})
+	return false
+}
@@ -0,0 +1,122 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParsePersistedQueries checks that both Apollo's and Relay's manifests are decoded.
+func TestParsePersistedQueries(t *testing.T) {
+	type testCase struct {
+		// The manifest's content.
+		manifest string
+		// The expected persisted queries.
+		expected PersistedQueries
+		// Whether decoding the manifest should fail.
+		fail bool
+	}
+
+	testCases := []testCase{{
+		manifest: `{
+			"format": "apollo-persisted-query-manifest",
+			"version": 1,
+			"operations": [{"id": "abc", "name": "GetUser", "type": "query", "body": "query GetUser { user { id } }"}]
+		}`,
+		expected: PersistedQueries{"abc": "query GetUser { user { id } }"},
+	}, {
+		manifest: `{"def": "mutation AddUser { addUser { id } }"}`,
+		expected: PersistedQueries{"def": "mutation AddUser { addUser { id } }"},
+	}, {
+		manifest: `["abc"]`,
+		fail:     true,
+	}}
+
+	for i, tc := range testCases {
+		queries, err := ParsePersistedQueries(strings.NewReader(tc.manifest))
+		if tc.fail {
+			assert.Error(t, err, "test %d", i)
+			continue
+		}
+
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, tc.expected, queries, "test %d", i)
+	}
+}
+
+// TestPersistedQueries checks that requests sending only the ID of their persisted query are matched.
+func TestPersistedQueries(t *testing.T) {
+	s := New(
+		WithPersistedQueries(PersistedQueries{"1": "query GetUser { user { id } }"}),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	defer s.Close()
+
+	s.RegisterQuery("GetUser", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"user": {"id": 1}}`),
+	})
+	s.RegisterPersistedQuery("2", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"viewer": {"id": 2}}`),
+	})
+
+	type testCase struct {
+		// The request's body.
+		body string
+		// The expected status.
+		status int
+		// The expected response.
+		expected string
+	}
+
+	testCases := []testCase{{
+		body:     `{"doc_id": "1"}`,
+		status:   http.StatusOK,
+		expected: `{"data": {"user": {"id": 1}}}`,
+	}, {
+		body:     `{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "2"}}}`,
+		status:   http.StatusOK,
+		expected: `{"data": {"viewer": {"id": 2}}}`,
+	}, {
+		body:     `{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "e446789367a50555f3c7d4118f03ebd0f71f57f8df2cde5cd6af919cf7f1f3ce"}}}`,
+		status:   http.StatusOK,
+		expected: `{"data": null, "errors": [{"message": "PersistedQueryNotFound", "path": null, "extensions": {"code": "PERSISTED_QUERY_NOT_FOUND"}}]}`,
+	}, {
+		body:     `{"query": "query GetUser { user { id } }", "extensions": {"persistedQuery": {"version": 1, "sha256Hash": "7cf1d0b8ef1df0c6cc86a6c1d0f52c6c35e4b9e1bab5e57a9d8f52a6a0ab2a0a"}}}`,
+		status:   http.StatusBadRequest,
+		expected: `{"data": null, "errors": [{"message": "provided sha does not match query", "path": null, "extensions": null}]}`,
+	}, {
+		// Automatic persisted queries send the query along with its hash, so it's persisted...
+		body:     `{"query": "query GetUser { user { id } }", "extensions": {"persistedQuery": {"version": 1, "sha256Hash": "e446789367a50555f3c7d4118f03ebd0f71f57f8df2cde5cd6af919cf7f1f3ce"}}}`,
+		status:   http.StatusOK,
+		expected: `{"data": {"user": {"id": 1}}}`,
+	}, {
+		// ... and later requests may send only the hash.
+		body:     `{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "e446789367a50555f3c7d4118f03ebd0f71f57f8df2cde5cd6af919cf7f1f3ce"}}}`,
+		status:   http.StatusOK,
+		expected: `{"data": {"user": {"id": 1}}}`,
+	}}
+
+	for i, tc := range testCases {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(tc.body))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, tc.status, resp.StatusCode, "test %d", i)
+		assert.JSONEq(t, tc.expected, string(body), "test %d", i)
+	}
+
+	requests := s.RequestsFor("2")
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "2", requests[0].PersistedQueryID)
+		assert.Empty(t, requests[0].Query)
+	}
+	assert.True(t, s.AssertNumberOfCalls(t, "GetUser", 3))
+}
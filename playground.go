@@ -0,0 +1,96 @@
+package goraphql_mock_server
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// playgroundPage is the HTML page that loads GraphiQL from a CDN,
+// configured to send its requests to the mock server.
+var playgroundPage = template.Must(template.New("playground").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>goraphql_mock_server playground</title>
+	<style>
+		body { height: 100%; margin: 0; width: 100%; overflow: hidden; }
+		#graphiql { height: 100vh; }
+	</style>
+	<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css" />
+	<script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+</head>
+<body>
+	<div id="graphiql">Loading...</div>
+	<script>
+		const fetcher = GraphiQL.createFetcher({
+			url: window.location.origin + {{.Endpoint}},
+		});
+		const root = ReactDOM.createRoot(document.getElementById('graphiql'));
+		root.render(React.createElement(GraphiQL, {
+			fetcher: fetcher,
+			defaultQuery: {{.DefaultQuery}},
+		}));
+	</script>
+</body>
+</html>
+`))
+
+// playgroundData holds the values used to render playgroundPage.
+type playgroundData struct {
+	// The path where GraphQL requests should be sent to.
+	Endpoint string
+	// The query initially displayed in the editor.
+	DefaultQuery string
+}
+
+// WithPlayground serves an interactive GraphiQL IDE at the specified path,
+// so the mock server may be manually explored while debugging a test.
+//
+// The IDE itself is loaded from a CDN,
+// so the browser must have access to the internet.
+// Its initial query lists every identifier registered in the server.
+// Note that the documentation explorer depends on the introspection query,
+// which must also be mocked (e.g., by registering a query for "__schema").
+func WithPlayground(path string) ServerOptions {
+	return func(s *server) {
+		s.mux.HandleFunc("GET "+path, s.playgroundHandler)
+	}
+}
+
+// playgroundHandler renders the GraphiQL IDE.
+func (s *server) playgroundHandler(w http.ResponseWriter, r *http.Request) {
+	data := playgroundData{
+		Endpoint:     "/",
+		DefaultQuery: s.playgroundQuery(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := playgroundPage.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// playgroundQuery generates a comment listing every registered identifier.
+func (s *server) playgroundQuery() string {
+	s.mu.RLock()
+	identifiers := s.sortedIdentifiers()
+	s.mu.RUnlock()
+
+	var b strings.Builder
+	b.WriteString("# Welcome to goraphql_mock_server!\n#\n")
+	if len(identifiers) == 0 {
+		b.WriteString("# There are no registered queries.\n")
+	} else {
+		b.WriteString("# Registered queries:\n")
+		for _, id := range identifiers {
+			b.WriteString("#   ")
+			b.WriteString(id)
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPlayground checks that the playground is served and lists the registered queries.
+func TestPlayground(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	s := New(WithPlayground("/playground"))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	resp, err := s.Client().Get(s.URL() + "/playground")
+	if !assert.NoError(t, err, "failed to get the playground") {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err, "failed to read the playground")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status")
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html", "unexpected content type")
+	assert.Contains(t, string(body), "GraphiQL", "the page doesn't load GraphiQL")
+	assert.Contains(t, string(body), "ListFoos", "the registered query isn't listed")
+}
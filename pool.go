@@ -0,0 +1,88 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity, in bytes, above which buffers aren't reused,
+// so a single huge response doesn't keep its memory for the server's lifetime.
+const maxPooledBuffer = 1 << 20
+
+// bufferPool reuses the buffers that keep a copy of each response, reducing the GC pressure under load.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer retrieves an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns the buffer to the pool, unless it grew too large.
+// The buffer must not be used afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// encodeBuffer is a buffer with an encoder writing to it, reused to encode each response.
+type encodeBuffer struct {
+	bytes.Buffer
+	// Encodes values as JSON into the buffer.
+	enc *json.Encoder
+}
+
+// encoderPool reuses the buffers and encoders used to encode each response.
+var encoderPool = sync.Pool{
+	New: func() any {
+		eb := new(encodeBuffer)
+		eb.enc = json.NewEncoder(&eb.Buffer)
+		return eb
+	},
+}
+
+// getEncoder retrieves an empty encodeBuffer from the pool.
+func getEncoder() *encodeBuffer {
+	eb := encoderPool.Get().(*encodeBuffer)
+	eb.Reset()
+	return eb
+}
+
+// putEncoder returns the encodeBuffer to the pool, unless it grew too large.
+// The encodeBuffer must not be used afterwards.
+func putEncoder(eb *encodeBuffer) {
+	if eb.Cap() <= maxPooledBuffer {
+		encoderPool.Put(eb)
+	}
+}
+
+// readBody reads the whole body, whose size is either known (e.g., from the request's Content-Length) or -1.
+// Either way, it's kept in a single allocation of its exact size, instead of growing it while reading:
+// bodies of known size are read directly, and the others through a pooled buffer.
+func readBody(r io.Reader, size int64) ([]byte, error) {
+	if size >= 0 && size <= maxPooledBuffer {
+		body := make([]byte, size)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, err
+		}
+
+		return body, nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(buf.Bytes()), nil
+}
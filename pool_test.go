@@ -0,0 +1,24 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReadBody checks that bodies are read completely, whether their size is known or not.
+func TestReadBody(t *testing.T) {
+	body := strings.Repeat("a", 1000)
+	for _, size := range []int64{int64(len(body)), -1} {
+		got, err := readBody(strings.NewReader(body), size)
+		if assert.NoError(t, err, "size %d", size) {
+			assert.Equal(t, body, string(got), "size %d", size)
+		}
+	}
+
+	_, err := readBody(bytes.NewReader([]byte(body)), int64(len(body)+1))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "the truncated body was read")
+}
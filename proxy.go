@@ -0,0 +1,192 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// RecordingMode defines which requests are forwarded to the upstream by WithRecordingProxy.
+type RecordingMode int
+
+const (
+	// RecordUnmatched forwards only the requests that don't match any registered mock. This is the default.
+	RecordUnmatched RecordingMode = iota
+	// RecordAll forwards every request, ignoring the registered mocks.
+	RecordAll
+)
+
+// upstream forwards requests to a real GraphQL server.
+type upstream struct {
+	// The address of the real GraphQL server.
+	url *url.URL
+	// The client used to forward requests.
+	client *http.Client
+}
+
+// newUpstream parses the address of a real GraphQL server, failing if it's invalid.
+func newUpstream(upstreamURL string) (*upstream, error) {
+	u, err := url.Parse(upstreamURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("goraphql_mock_server: invalid upstream URL '%s'", upstreamURL)
+	}
+
+	return &upstream{
+		url:    u,
+		client: &http.Client{},
+	}, nil
+}
+
+// forward sends the request to the upstream, copying its response to w.
+// It returns the response's status and body,
+// or a zero status if the request couldn't be forwarded (in which case a 502 is sent).
+func (u *upstream) forward(w http.ResponseWriter, r *http.Request) (int, []byte) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, r, http.StatusBadGateway, fmt.Errorf("goraphql_mock_server: forward request: %w", err), nil)
+		return 0, nil
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, u.url.String(), bytes.NewReader(body))
+	if err != nil {
+		respondError(w, r, http.StatusBadGateway, fmt.Errorf("goraphql_mock_server: forward request: %w", err), nil)
+		return 0, nil
+	}
+
+	// Let the client negotiate the encoding, so the response's body is decompressed.
+	req.Header = r.Header.Clone()
+	req.Header.Del("Accept-Encoding")
+	req.Header.Del("Connection")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		respondError(w, r, http.StatusBadGateway, fmt.Errorf("goraphql_mock_server: forward request: %w", err), nil)
+		return 0, nil
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		respondError(w, r, http.StatusBadGateway, fmt.Errorf("goraphql_mock_server: read upstream response: %w", err), nil)
+		return 0, nil
+	}
+
+	for name, values := range resp.Header {
+		if name != "Content-Length" {
+			w.Header()[name] = values
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(data)
+
+	return resp.StatusCode, data
+}
+
+// ServeHTTP implements http.Handler for upstream, forwarding the request.
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.forward(w, r)
+}
+
+// WithPassthrough transparently forwards every request that doesn't match any registered mock
+// to the real GraphQL server at upstreamURL (e.g., a staging server),
+// so tests may mock only the operations they care about.
+// Unlike WithRecordingProxy, the upstream's responses aren't recorded.
+//
+// This is a shorthand for WithFallbackHandler, so it replaces any other fallback handler.
+// The server fails to start if upstreamURL is invalid.
+func WithPassthrough(upstreamURL string) ServerOptions {
+	return func(s *server) {
+		u, err := newUpstream(upstreamURL)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		WithFallbackHandler(u)(s)
+	}
+}
+
+// recordingProxy forwards requests to the upstream, recording its responses as mock definitions.
+type recordingProxy struct {
+	// Where requests are forwarded to.
+	upstream *upstream
+	// Which requests are forwarded.
+	mode RecordingMode
+	// Guards recordings.
+	mu sync.Mutex
+	// Every response recorded, in the order they were received.
+	recordings []MockDefinition
+}
+
+// WithRecordingProxy forwards requests to the real GraphQL server at upstreamURL,
+// sending its responses to the clients
+// and recording every successful response as a mock definition,
+// which may be written with Server.WriteRecordings and later loaded to replay the session.
+//
+// Depending on mode, either only the requests that don't match any registered mock
+// or every request is forwarded.
+// Forwarded requests are still recorded as unmatched requests.
+//
+// This takes precedence over WithSchemaMock, WithFallbackHandler and WithUnmatchedBehavior.
+// The server fails to start if upstreamURL is invalid.
+func WithRecordingProxy(upstreamURL string, mode RecordingMode) ServerOptions {
+	return func(s *server) {
+		u, err := newUpstream(upstreamURL)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		s.recorder = &recordingProxy{
+			upstream: u,
+			mode:     mode,
+		}
+	}
+}
+
+// forwardsAll checks whether every request is forwarded by the recording proxy, ignoring the registered mocks.
+func (s *server) forwardsAll() bool {
+	return s.recorder != nil && s.recorder.mode == RecordAll
+}
+
+// record forwards the request to the upstream, recording its response if it was successful.
+func (p *recordingProxy) record(w http.ResponseWriter, r *http.Request, req Request) {
+	status, body := p.upstream.forward(w, r)
+	if status != http.StatusOK {
+		return
+	}
+
+	data, err := responseData(body)
+	if err != nil || req.Query == "" {
+		return
+	}
+
+	def := collectedDefinition("", req)
+	def.Response = data
+
+	p.mu.Lock()
+	p.recordings = append(p.recordings, def)
+	p.mu.Unlock()
+}
+
+// WriteRecordings implements Server for server.
+func (s *server) WriteRecordings(w io.Writer) error {
+	defs := []MockDefinition{}
+	if s.recorder != nil {
+		s.recorder.mu.Lock()
+		defs = append(defs, s.recorder.recordings...)
+		s.recorder.mu.Unlock()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(defs); err != nil {
+		return fmt.Errorf("goraphql_mock_server: write recordings: %w", err)
+	}
+
+	return nil
+}
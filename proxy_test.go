@@ -0,0 +1,124 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecordingProxy checks that requests are forwarded to the upstream,
+// and that its responses are recorded as mock definitions that may be replayed.
+func TestRecordingProxy(t *testing.T) {
+	upstream := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer upstream.Close()
+
+	upstream.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": "upstream"}}`),
+	})
+	upstream.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": "upstream"}}`),
+	})
+
+	type testCase struct {
+		// Which requests are forwarded.
+		mode RecordingMode
+		// The expected responses for ListFoos and ListBars, in order.
+		expected []string
+		// How many responses are expected to be recorded.
+		recorded int
+	}
+
+	testCases := []testCase{{
+		mode:     RecordUnmatched,
+		expected: []string{"local", "upstream"},
+		recorded: 1,
+	}, {
+		mode:     RecordAll,
+		expected: []string{"upstream", "upstream"},
+		recorded: 2,
+	}}
+
+	for i, tc := range testCases {
+		s := New(WithRecordingProxy(upstream.URL(), tc.mode), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": "local"}}`),
+		})
+
+		client := graphql.NewClient(s.URL())
+		for j, query := range []string{`query { ListFoos { foo } }`, `query { ListBars { bar } }`} {
+			var resp map[string]map[string]string
+			err := client.Run(context.Background(), graphql.NewRequest(query), &resp)
+			if assert.NoError(t, err, "test %d: query %d", i, j) {
+				for _, fields := range resp {
+					for _, value := range fields {
+						assert.Equal(t, tc.expected[j], value, "test %d: query %d", i, j)
+					}
+				}
+			}
+		}
+
+		var buf bytes.Buffer
+		err := s.WriteRecordings(&buf)
+		s.Close()
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		defs, err := decodeDefinitions(buf.Bytes())
+		if !assert.NoError(t, err, "test %d", i) || !assert.Len(t, defs, tc.recorded, "test %d", i) {
+			continue
+		}
+
+		replay := New()
+		err = replay.RegisterDefinitions(defs...)
+		if assert.NoError(t, err, "test %d", i) {
+			var resp map[string]any
+			err = graphql.NewClient(replay.URL()).Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), &resp)
+			if assert.NoError(t, err, "test %d", i) {
+				assert.Equal(t, map[string]any{"ListBars": map[string]any{"bar": "upstream"}}, resp, "test %d", i)
+			}
+		}
+		replay.Close()
+	}
+}
+
+// TestPassthrough checks that only the requests that don't match any mock are forwarded to the upstream.
+func TestPassthrough(t *testing.T) {
+	upstream := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer upstream.Close()
+
+	upstream.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": "upstream"}}`),
+	})
+
+	s := New(WithPassthrough(upstream.URL()), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": "local"}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+
+	var resp map[string]any
+	err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": "upstream"}}, resp)
+	}
+
+	resp = nil
+	err = client.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), &resp)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]any{"ListBars": map[string]any{"bar": "local"}}, resp)
+	}
+
+	err = client.Run(context.Background(), graphql.NewRequest(`query { ListBazs { baz } }`), &resp)
+	assert.Error(t, err, "the upstream's error wasn't forwarded")
+	assert.Equal(t, 1, upstream.Counts()["ListFoos"])
+	assert.Len(t, upstream.Requests(), 2)
+}
@@ -0,0 +1,825 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// This file implements a parser for GraphQL executable documents (i.e., queries),
+// so the server may analyze the requests it receives.
+// It only checks the document's syntax: validating it against a schema is done by WithQueryValidation.
+
+// tokenKind is the kind of a lexical token in a GraphQL document.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a lexical token in a GraphQL document.
+type token struct {
+	kind tokenKind
+	// The token's text. For strings, it's the decoded value.
+	text string
+	// The byte offset of the token in the document.
+	pos int
+}
+
+// lexer splits a GraphQL document into tokens.
+type lexer struct {
+	src string
+	pos int
+}
+
+// next reads the next token in the document.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunctuator, text: "...", pos: start}, nil
+	case strings.IndexByte("!$&():=@[]{|}", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, text: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, text: l.src[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	default:
+		return token{}, fmt.Errorf("unexpected character %q at offset %d", c, start)
+	}
+}
+
+// skipIgnored skips whitespace, commas and comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return
+		}
+	}
+}
+
+// number reads an int or a float.
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := l.digits()
+	if digits == 0 {
+		return token{}, fmt.Errorf("invalid number at offset %d", start)
+	}
+
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		if l.digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if l.digits() == 0 {
+			return token{}, fmt.Errorf("invalid number at offset %d", start)
+		}
+	}
+
+	return token{kind: kind, text: l.src[start:l.pos], pos: start}, nil
+}
+
+// digits skips a sequence of digits, returning how many were skipped.
+func (l *lexer) digits() int {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+
+	return l.pos - start
+}
+
+// string reads either a string or a block string, decoding its value.
+func (l *lexer) string() (token, error) {
+	start := l.pos
+
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		l.pos += 3
+		end := strings.Index(l.src[l.pos:], `"""`)
+		for end >= 0 && end > 0 && l.src[l.pos+end-1] == '\\' {
+			next := strings.Index(l.src[l.pos+end+3:], `"""`)
+			if next < 0 {
+				end = -1
+				break
+			}
+			end += 3 + next
+		}
+		if end < 0 {
+			return token{}, fmt.Errorf("unterminated block string at offset %d", start)
+		}
+
+		raw := l.src[l.pos : l.pos+end]
+		l.pos += end + 3
+		return token{kind: tokenString, text: strings.ReplaceAll(raw, `\"""`, `"""`), pos: start}, nil
+	}
+
+	l.pos++
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenString, text: sb.String(), pos: start}, nil
+		case c == '\n' || c == '\r':
+			return token{}, fmt.Errorf("unterminated string at offset %d", start)
+		case c == '\\' && l.pos+1 < len(l.src):
+			l.pos++
+			switch esc := l.src[l.pos]; esc {
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				var r rune
+				if _, err := fmt.Sscanf(l.src[l.pos+1:min(l.pos+5, len(l.src))], "%04x", &r); err != nil {
+					return token{}, fmt.Errorf("invalid unicode escape at offset %d", l.pos)
+				}
+				sb.WriteRune(r)
+				l.pos += 4
+			default:
+				sb.WriteByte(esc)
+			}
+			l.pos++
+		default:
+			sb.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return token{}, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+// isLetter checks whether the character is an ASCII letter.
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit checks whether the character is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// document is a parsed GraphQL executable document.
+type document struct {
+	// Every operation in the document, in order.
+	operations []*operation
+	// Every fragment in the document, indexed by their name.
+	fragments map[string]*fragment
+}
+
+// operation is an operation definition in a GraphQL document.
+type operation struct {
+	// The type of the operation (e.g., "query").
+	// Operations written in the shorthand form (i.e., just a selection set) are queries.
+	kind string
+	// The operation's name. Empty for anonymous operations.
+	name string
+	// The variables declared by the operation.
+	variables []variableDefinition
+	// The directives applied to the operation.
+	directives []directive
+	// The fields selected by the operation.
+	selections []selection
+	// The byte offset of the operation in the document.
+	pos int
+}
+
+// fragment is a fragment definition in a GraphQL document.
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []directive
+	selections    []selection
+}
+
+// variableDefinition is a variable declared by an operation.
+type variableDefinition struct {
+	name         string
+	typ          *typeRef
+	defaultValue *value
+	// The byte offset of the definition in the document.
+	pos int
+}
+
+// typeRef references a type, as in a variable definition.
+type typeRef struct {
+	// The named type. Empty for lists.
+	name string
+	// The type of the list's elements. Nil if it's not a list.
+	elem *typeRef
+	// Whether the type is non-null.
+	nonNull bool
+}
+
+// String formats the type as in a GraphQL document.
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+
+	return s
+}
+
+// selectionKind is the kind of a selection in a selection set.
+type selectionKind int
+
+const (
+	selectionField selectionKind = iota
+	selectionFragmentSpread
+	selectionInlineFragment
+)
+
+// selection is a field, fragment spread or inline fragment in a selection set.
+type selection struct {
+	kind selectionKind
+	// The field's alias, if any.
+	alias string
+	// The field's name, or the name of the spread fragment.
+	name string
+	// The field's arguments.
+	arguments []argument
+	// The directives applied to the selection.
+	directives []directive
+	// The type condition of an inline fragment, if any.
+	typeCondition string
+	// The fields selected by the field or the inline fragment.
+	selections []selection
+	// The byte offset of the selection in the document.
+	pos int
+}
+
+// responseKey is the key of the field in the response (i.e., its alias or its name).
+func (sel selection) responseKey() string {
+	if sel.alias != "" {
+		return sel.alias
+	}
+
+	return sel.name
+}
+
+// argument is an argument of a field or of a directive.
+type argument struct {
+	name  string
+	value value
+}
+
+// directive is a directive applied to an operation, a selection or a fragment.
+type directive struct {
+	name      string
+	arguments []argument
+}
+
+// valueKind is the kind of a value in a GraphQL document.
+type valueKind int
+
+const (
+	valueVariable valueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueBoolean
+	valueNull
+	valueEnum
+	valueList
+	valueObject
+)
+
+// value is a literal (or a variable) in a GraphQL document.
+type value struct {
+	kind valueKind
+	// The value's text (e.g., the variable's name, or the number as written).
+	raw string
+	// The elements of a list.
+	list []value
+	// The fields of an object, in order.
+	fields []argument
+}
+
+// parser parses a GraphQL document.
+type parser struct {
+	lex lexer
+	tok token
+}
+
+// parseQuery parses a GraphQL executable document.
+func parseQuery(src string) (*document, error) {
+	p := &parser{lex: lexer{src: src}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			pos := p.tok.pos
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: string(OperationQuery), selections: sels, pos: pos})
+		case p.peek(tokenName, "fragment"):
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments[frag.name] = frag
+		case p.tok.kind == tokenName:
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("the document doesn't define any operation")
+	}
+
+	return doc, nil
+}
+
+// advance reads the next token.
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+
+	p.tok = tok
+	return nil
+}
+
+// peek checks whether the current token is of the kind and has the text.
+func (p *parser) peek(kind tokenKind, text string) bool {
+	return p.tok.kind == kind && p.tok.text == text
+}
+
+// skip consumes the current token if it's the punctuator, reporting whether it did.
+func (p *parser) skip(punctuator string) (bool, error) {
+	if !p.peek(tokenPunctuator, punctuator) {
+		return false, nil
+	}
+
+	return true, p.advance()
+}
+
+// expect consumes the current token, failing if it isn't the punctuator.
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(tokenPunctuator, punctuator) {
+		return p.unexpected()
+	}
+
+	return p.advance()
+}
+
+// name consumes the current token, failing if it isn't a name.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+
+	name := p.tok.text
+	return name, p.advance()
+}
+
+// unexpected describes the current token as unexpected.
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of document")
+	}
+
+	return fmt.Errorf("unexpected %q at offset %d", p.tok.text, p.tok.pos)
+}
+
+// operation parses an operation definition.
+func (p *parser) operation() (*operation, error) {
+	pos := p.tok.pos
+	kind, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "query", "mutation", "subscription":
+	default:
+		return nil, fmt.Errorf("unknown operation type %q", kind)
+	}
+
+	op := &operation{kind: kind, pos: pos}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for {
+			if ok, err := p.skip(")"); err != nil {
+				return nil, err
+			} else if ok {
+				break
+			}
+
+			def, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, def)
+		}
+	}
+
+	if op.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// variableDefinition parses a variable declared by an operation.
+func (p *parser) variableDefinition() (variableDefinition, error) {
+	def := variableDefinition{pos: p.tok.pos}
+
+	if err := p.expect("$"); err != nil {
+		return def, err
+	}
+
+	var err error
+	if def.name, err = p.name(); err != nil {
+		return def, err
+	}
+	if err := p.expect(":"); err != nil {
+		return def, err
+	}
+	if def.typ, err = p.typeRef(); err != nil {
+		return def, err
+	}
+
+	if ok, err := p.skip("="); err != nil {
+		return def, err
+	} else if ok {
+		v, err := p.value()
+		if err != nil {
+			return def, err
+		}
+		def.defaultValue = &v
+	}
+
+	// Directives on variable definitions are parsed, but ignored.
+	_, err = p.directives()
+	return def, err
+}
+
+// typeRef parses a reference to a type.
+func (p *parser) typeRef() (*typeRef, error) {
+	t := &typeRef{}
+
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		if t.elem, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else if t.name, err = p.name(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	t.nonNull, err = p.skip("!")
+	return t, err
+}
+
+// fragment parses a fragment definition.
+func (p *parser) fragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	frag := &fragment{}
+
+	var err error
+	if frag.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if !p.peek(tokenName, "on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if frag.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+
+	return frag, nil
+}
+
+// selectionSet parses a selection set, including its braces.
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var sels []selection
+	for {
+		if ok, err := p.skip("}"); err != nil {
+			return nil, err
+		} else if ok {
+			break
+		}
+
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+
+	return sels, nil
+}
+
+// selection parses a field, a fragment spread or an inline fragment.
+func (p *parser) selection() (selection, error) {
+	sel := selection{pos: p.tok.pos}
+	var err error
+
+	if ok, err := p.skip("..."); err != nil {
+		return sel, err
+	} else if ok {
+		if p.tok.kind == tokenName && p.tok.text != "on" {
+			sel.kind = selectionFragmentSpread
+			if sel.name, err = p.name(); err != nil {
+				return sel, err
+			}
+			sel.directives, err = p.directives()
+			return sel, err
+		}
+
+		sel.kind = selectionInlineFragment
+		if p.peek(tokenName, "on") {
+			if err := p.advance(); err != nil {
+				return sel, err
+			}
+			if sel.typeCondition, err = p.name(); err != nil {
+				return sel, err
+			}
+		}
+		if sel.directives, err = p.directives(); err != nil {
+			return sel, err
+		}
+		sel.selections, err = p.selectionSet()
+		return sel, err
+	}
+
+	sel.kind = selectionField
+	if sel.name, err = p.name(); err != nil {
+		return sel, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return sel, err
+	} else if ok {
+		sel.alias = sel.name
+		if sel.name, err = p.name(); err != nil {
+			return sel, err
+		}
+	}
+
+	if sel.arguments, err = p.arguments(); err != nil {
+		return sel, err
+	}
+	if sel.directives, err = p.directives(); err != nil {
+		return sel, err
+	}
+	if p.peek(tokenPunctuator, "{") {
+		sel.selections, err = p.selectionSet()
+	}
+
+	return sel, err
+}
+
+// arguments parses a list of arguments, if any.
+func (p *parser) arguments() ([]argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+
+	var args []argument
+	for {
+		if ok, err := p.skip(")"); err != nil {
+			return nil, err
+		} else if ok {
+			return args, nil
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, argument{name: name, value: v})
+	}
+}
+
+// directives parses a list of directives, if any.
+func (p *parser) directives() ([]directive, error) {
+	var dirs []directive
+
+	for p.peek(tokenPunctuator, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+
+		dirs = append(dirs, directive{name: name, arguments: args})
+	}
+
+	return dirs, nil
+}
+
+// value parses a value.
+func (p *parser) value() (value, error) {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokenInt:
+		return value{kind: valueInt, raw: tok.text}, p.advance()
+	case tokenFloat:
+		return value{kind: valueFloat, raw: tok.text}, p.advance()
+	case tokenString:
+		return value{kind: valueString, raw: tok.text}, p.advance()
+	case tokenName:
+		kind := valueEnum
+		switch tok.text {
+		case "true", "false":
+			kind = valueBoolean
+		case "null":
+			kind = valueNull
+		}
+		return value{kind: kind, raw: tok.text}, p.advance()
+	}
+
+	switch tok.text {
+	case "$":
+		if err := p.advance(); err != nil {
+			return value{}, err
+		}
+		name, err := p.name()
+		return value{kind: valueVariable, raw: name}, err
+	case "[":
+		if err := p.advance(); err != nil {
+			return value{}, err
+		}
+
+		v := value{kind: valueList}
+		for {
+			if ok, err := p.skip("]"); err != nil {
+				return value{}, err
+			} else if ok {
+				return v, nil
+			}
+
+			elem, err := p.value()
+			if err != nil {
+				return value{}, err
+			}
+			v.list = append(v.list, elem)
+		}
+	case "{":
+		if err := p.advance(); err != nil {
+			return value{}, err
+		}
+
+		v := value{kind: valueObject}
+		for {
+			if ok, err := p.skip("}"); err != nil {
+				return value{}, err
+			} else if ok {
+				return v, nil
+			}
+
+			name, err := p.name()
+			if err != nil {
+				return value{}, err
+			}
+			if err := p.expect(":"); err != nil {
+				return value{}, err
+			}
+			field, err := p.value()
+			if err != nil {
+				return value{}, err
+			}
+			v.fields = append(v.fields, argument{name: name, value: field})
+		}
+	default:
+		return value{}, p.unexpected()
+	}
+}
+
+// operation selects the operation to be executed: the one with the name,
+// or the only operation in the document if name is empty.
+func (doc *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) != 1 {
+			return nil, fmt.Errorf("the document defines multiple operations, but no operation name was given")
+		}
+
+		return doc.operations[0], nil
+	}
+
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
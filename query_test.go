@@ -0,0 +1,132 @@
+package goraphql_mock_server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseQuery checks that GraphQL documents are parsed, and that invalid ones are rejected.
+func TestParseQuery(t *testing.T) {
+	src := `
+		# A comment.
+		query ListFoos($num: Int! = 10, $ids: [ID!]) @cached {
+			foos: ListFoos(first: $num, filter: {ids: $ids, tags: ["a", "b"]}, name: "\"x\"") {
+				...FooFields
+				... on Foo @include(if: true) { bar }
+			}
+		}
+
+		fragment FooFields on Foo {
+			id
+		}
+	`
+
+	doc, err := parseQuery(src)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	op, err := doc.operation("")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "query", op.kind)
+	assert.Equal(t, "ListFoos", op.name)
+	if assert.Len(t, op.variables, 2) {
+		assert.Equal(t, "num", op.variables[0].name)
+		assert.Equal(t, "Int!", op.variables[0].typ.String())
+		assert.Equal(t, &value{kind: valueInt, raw: "10"}, op.variables[0].defaultValue)
+		assert.Equal(t, "[ID!]", op.variables[1].typ.String())
+	}
+	assert.Equal(t, []directive{{name: "cached"}}, op.directives)
+
+	if assert.Len(t, op.selections, 1) {
+		foos := op.selections[0]
+		assert.Equal(t, "foos", foos.responseKey())
+		assert.Equal(t, "ListFoos", foos.name)
+		assert.Equal(t, []argument{
+			{name: "first", value: value{kind: valueVariable, raw: "num"}},
+			{name: "filter", value: value{kind: valueObject, fields: []argument{
+				{name: "ids", value: value{kind: valueVariable, raw: "ids"}},
+				{name: "tags", value: value{kind: valueList, list: []value{
+					{kind: valueString, raw: "a"},
+					{kind: valueString, raw: "b"},
+				}}},
+			}}},
+			{name: "name", value: value{kind: valueString, raw: `"x"`}},
+		}, foos.arguments)
+
+		if assert.Len(t, foos.selections, 2) {
+			assert.Equal(t, selectionFragmentSpread, foos.selections[0].kind)
+			assert.Equal(t, "FooFields", foos.selections[0].name)
+			assert.Equal(t, selectionInlineFragment, foos.selections[1].kind)
+			assert.Equal(t, "Foo", foos.selections[1].typeCondition)
+			assert.Equal(t, "include", foos.selections[1].directives[0].name)
+		}
+	}
+
+	if assert.Contains(t, doc.fragments, "FooFields") {
+		assert.Equal(t, "Foo", doc.fragments["FooFields"].typeCondition)
+		assert.Equal(t, []selection{{name: "id", pos: strings.LastIndex(src, "id")}}, doc.fragments["FooFields"].selections)
+	}
+}
+
+// TestParseQueryErrors checks that invalid GraphQL documents are rejected.
+func TestParseQueryErrors(t *testing.T) {
+	type testCase struct {
+		// The document being parsed.
+		src string
+		// The expected error.
+		expected string
+	}
+
+	testCases := []testCase{{
+		src:      ``,
+		expected: "the document doesn't define any operation",
+	}, {
+		src:      `query { ListFoos { foo }`,
+		expected: "unexpected end of document",
+	}, {
+		src:      `query { ListFoos { } }`,
+		expected: "empty selection set",
+	}, {
+		src:      `subscribe { ListFoos }`,
+		expected: `unknown operation type "subscribe"`,
+	}, {
+		src:      `query { ListFoos(num: ) }`,
+		expected: `unexpected ")" at offset 22`,
+	}, {
+		src:      `query { ListFoos(name: "foo) }`,
+		expected: "unterminated string at offset 23",
+	}, {
+		src:      `query { ListFoos % }`,
+		expected: "unexpected character '%' at offset 17",
+	}}
+
+	for i, tc := range testCases {
+		_, err := parseQuery(tc.src)
+		assert.EqualError(t, err, tc.expected, "test %d", i)
+	}
+}
+
+// TestDocumentOperation checks that the operation to be executed is selected by its name.
+func TestDocumentOperation(t *testing.T) {
+	doc, err := parseQuery(`query GetFoo { foo } mutation SetFoo { setFoo }`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	op, err := doc.operation("SetFoo")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "mutation", op.kind)
+	}
+
+	_, err = doc.operation("")
+	assert.EqualError(t, err, "the document defines multiple operations, but no operation name was given")
+
+	_, err = doc.operation("GetBar")
+	assert.EqualError(t, err, `unknown operation "GetBar"`)
+}
@@ -0,0 +1,111 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// errRateLimited is the error sent for requests rejected by the rate limiter.
+var errRateLimited = errors.New("goraphql_mock_server: rate limit exceeded")
+
+// rateLimiter counts the requests in fixed time windows, rejecting those over the limit.
+type rateLimiter struct {
+	// The number of requests accepted in each window.
+	limit int
+	// The duration of each window.
+	window time.Duration
+	// Whether requests are counted per client (i.e., per remote IP).
+	perClient bool
+	// Guards windows.
+	mu sync.Mutex
+	// The current window of each client, or of every request if not counting per client.
+	windows map[string]*rateWindow
+}
+
+// rateWindow counts the requests received in a window.
+type rateWindow struct {
+	// When the window started.
+	start time.Time
+	// How many requests were received in the window.
+	count int
+}
+
+// WithRateLimit accepts at most limit requests per window, across every client,
+// answering the others with a 429, a Retry-After header and a GraphQL error,
+// so clients' backoff may be tested.
+//
+// If limit or window isn't positive, the server fails to start
+// (i.e., NewE returns an error, and New panics).
+func WithRateLimit(limit int, window time.Duration) ServerOptions {
+	return withRateLimiter(limit, window, false)
+}
+
+// WithClientRateLimit works like WithRateLimit,
+// but counts the requests of each client (i.e., of each remote IP) separately.
+func WithClientRateLimit(limit int, window time.Duration) ServerOptions {
+	return withRateLimiter(limit, window, true)
+}
+
+// withRateLimiter rejects the requests exceeding the limit in each window.
+func withRateLimiter(limit int, window time.Duration, perClient bool) ServerOptions {
+	return func(s *server) {
+		if limit <= 0 || window <= 0 {
+			s.fail(fmt.Errorf("goraphql_mock_server: invalid rate limit of %d requests per %v", limit, window))
+			return
+		}
+
+		rl := &rateLimiter{
+			limit:     limit,
+			window:    window,
+			perClient: perClient,
+			windows:   make(map[string]*rateWindow),
+		}
+
+		s.faults = append(s.faults, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if retryAfter, ok := rl.allow(r, time.Now()); !ok {
+					s.logger.Warn("goraphql_mock_server: request rate limited", "remote", r.RemoteAddr, "retryAfter", retryAfter)
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+					respondError(w, r, http.StatusTooManyRequests, errRateLimited, nil)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+}
+
+// allow counts the request, checking whether it's accepted.
+// If not, it also returns how long until the current window ends.
+func (rl *rateLimiter) allow(r *http.Request, now time.Time) (time.Duration, bool) {
+	key := ""
+	if rl.perClient {
+		key = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			key = host
+		}
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	win := rl.windows[key]
+	if win == nil || now.Sub(win.start) >= rl.window {
+		win = &rateWindow{start: now}
+		rl.windows[key] = win
+	}
+
+	if win.count >= rl.limit {
+		return win.start.Add(rl.window).Sub(now), false
+	}
+
+	win.count++
+	return 0, true
+}
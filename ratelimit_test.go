@@ -0,0 +1,86 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithRateLimit checks that requests over the limit are rejected.
+func TestWithRateLimit(t *testing.T) {
+	s := New(WithRateLimit(2, time.Hour), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	expected := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i, status := range expected {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+		if !assert.NoError(t, err, "request %d", i) {
+			continue
+		}
+		resp.Body.Close()
+
+		assert.Equal(t, status, resp.StatusCode, "request %d", i)
+		if status == http.StatusTooManyRequests {
+			assert.Equal(t, "3600", resp.Header.Get("Retry-After"), "request %d", i)
+		}
+	}
+
+	for i, opt := range []ServerOptions{WithRateLimit(0, time.Hour), WithClientRateLimit(1, 0)} {
+		_, err := NewE(opt)
+		assert.ErrorContains(t, err, "invalid rate limit", "test %d", i)
+	}
+	assert.Panics(t, func() { New(WithRateLimit(-1, time.Hour)) })
+}
+
+// TestRateLimiter checks that requests are counted in fixed windows, per client if requested.
+func TestRateLimiter(t *testing.T) {
+	type testCase struct {
+		// Whether requests are counted per client.
+		perClient bool
+		// The client sending each request.
+		clients []string
+		// The time, since the start of the test, when each request is sent.
+		times []time.Duration
+		// Whether each request is expected to be accepted.
+		expected []bool
+	}
+
+	testCases := []testCase{{
+		clients:  []string{"1.1.1.1:1", "1.1.1.1:2", "2.2.2.2:1", "2.2.2.2:1"},
+		times:    []time.Duration{0, 0, 0, time.Second},
+		expected: []bool{true, false, false, true},
+	}, {
+		perClient: true,
+		clients:   []string{"1.1.1.1:1", "1.1.1.1:2", "2.2.2.2:1", "2.2.2.2:1"},
+		times:     []time.Duration{0, 0, 0, time.Second},
+		expected:  []bool{true, false, true, true},
+	}}
+
+	for i, tc := range testCases {
+		rl := &rateLimiter{
+			limit:     1,
+			window:    time.Second,
+			perClient: tc.perClient,
+			windows:   make(map[string]*rateWindow),
+		}
+
+		start := time.Now()
+		for j, client := range tc.clients {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.RemoteAddr = client
+
+			_, ok := rl.allow(r, start.Add(tc.times[j]))
+			assert.Equal(t, tc.expected[j], ok, "test %d: request %d", i, j)
+		}
+	}
+}
@@ -0,0 +1,668 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OperationType is the type of a GraphQL operation.
+type OperationType string
+
+const (
+	// OperationQuery identifies a query operation.
+	OperationQuery OperationType = "query"
+	// OperationMutation identifies a mutation operation.
+	OperationMutation OperationType = "mutation"
+)
+
+// operationType detects the type of the operation in the query,
+// returning false if it isn't supported.
+func operationType(query string) (OperationType, bool) {
+	op, _, ok := parseOperation(query)
+	return op, ok
+}
+
+// parseOperation detects the type and the name of the operation in the query,
+// returning false if it isn't supported.
+// The name is empty for anonymous operations.
+//
+// Queries in the shorthand form (i.e., just a selection set, as sent by shurcooL/graphql)
+// are detected as anonymous queries.
+func parseOperation(query string) (OperationType, string, bool) {
+	query = strings.TrimSpace(query)
+
+	var op OperationType
+	switch {
+	case strings.HasPrefix(query, "{"):
+		return OperationQuery, "", true
+	case strings.HasPrefix(query, string(OperationQuery)):
+		op = OperationQuery
+	case strings.HasPrefix(query, string(OperationMutation)):
+		op = OperationMutation
+	default:
+		return "", "", false
+	}
+
+	rest := strings.TrimLeft(query[len(op):], " \t\r\n,")
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+
+	return op, rest[:end], true
+}
+
+// RegisterOptions defines a function used to configure a registered mock.
+type RegisterOptions func(r *registration)
+
+// Named gives the mock a human-readable name,
+// used instead of its identifier and type in logs, diagnostics and assertion failures.
+func Named(name string) RegisterOptions {
+	return func(r *registration) {
+		r.name = name
+	}
+}
+
+// Required marks the mock as required,
+// so Server.ExpectationsWereMet fails if the mock is never called.
+func Required() RegisterOptions {
+	return MinTimes(1)
+}
+
+// Times expects the mock to be called exactly n times,
+// so Server.ExpectationsWereMet fails if it's called more or less times.
+func Times(n int) RegisterOptions {
+	return func(r *registration) {
+		MinTimes(n)(r)
+		MaxTimes(n)(r)
+	}
+}
+
+// MinTimes expects the mock to be called at least n times,
+// so Server.ExpectationsWereMet fails if it's called less times.
+func MinTimes(n int) RegisterOptions {
+	return func(r *registration) {
+		r.minCalls = n
+	}
+}
+
+// MaxTimes expects the mock to be called at most n times,
+// so Server.ExpectationsWereMet fails if it's called more times.
+//
+// Note that the mock keeps matching requests after being called n times,
+// so the client receives the expected response and the test may fail with a clear message.
+func MaxTimes(n int) RegisterOptions {
+	return func(r *registration) {
+		r.maxCalls = n
+		r.limitCalls = true
+	}
+}
+
+// ExpiresAt makes the mock stop matching requests at the time,
+// so, e.g., short-lived tokens or cache windows may be simulated.
+// Requests received afterwards are matched against the following mocks, as if it weren't registered.
+func ExpiresAt(t time.Time) RegisterOptions {
+	return func(r *registration) {
+		r.expiresAt = t
+	}
+}
+
+// ExpiresAfter makes the mock stop matching requests once the duration elapses since it was registered, like ExpiresAt.
+func ExpiresAfter(d time.Duration) RegisterOptions {
+	return func(r *registration) {
+		r.expiresAt = r.registered.Add(d)
+	}
+}
+
+// ExpiresAfterCalls makes the mock stop matching requests after answering n of them,
+// so the following mocks answer the next requests (e.g., to simulate a token that's refreshed after a scenario).
+// Unlike MaxTimes, the expectations are met regardless of how many requests the mock would have matched.
+func ExpiresAfterCalls(n int) RegisterOptions {
+	return func(r *registration) {
+		r.callsLimit = n
+	}
+}
+
+// ResponseField adds a top-level member, besides data, errors and extensions, to every response sent by the mock,
+// so clients that must tolerate (or parse) vendor-specific members may be tested.
+//
+// This may be used multiple times, adding every member.
+func ResponseField(name string, value any) RegisterOptions {
+	return func(r *registration) {
+		if r.fields == nil {
+			r.fields = make(map[string]any)
+		}
+		r.fields[name] = value
+	}
+}
+
+// registration holds a mock registered in the server,
+// along with the information needed to manage it.
+type registration struct {
+	// The identifier used to register the mock.
+	identifier string
+	// The type of operation matched by the mock.
+	operation OperationType
+	// The registered mock.
+	mock MockedRequest
+	// Where the mock was registered from (e.g., the directory it was loaded from).
+	// Empty for mocks registered directly by the caller.
+	source string
+	// The mock's human-readable name, if any.
+	name string
+	// The scope, created by Server.Scope, that registered the mock.
+	// Empty for mocks visible to every request.
+	scope string
+	// The minimum number of times the mock must be called for the expectations to be met.
+	minCalls int
+	// The maximum number of times the mock may be called for the expectations to be met.
+	// Only checked if limitCalls is set.
+	maxCalls int
+	// Whether the number of calls is limited by maxCalls.
+	limitCalls bool
+	// How many times the mock was called.
+	calls int
+	// Whether the mock is part of a session replayed in order (see ReplayInOrder),
+	// so it may only be called once, right after previous.
+	ordered bool
+	// The mock preceding this one in a session replayed in order.
+	// Nil for the first mock in the session.
+	previous *registration
+	// The ID of the persisted query matched by the mock, if registered by RegisterPersistedQuery.
+	// If set, the mock matches requests by this ID instead of by its identifier.
+	persistedID string
+	// The headers that requests must have to match the mock, set by MatchHeader.
+	headers http.Header
+	// How long the mock's response is delayed by Delay.
+	delay time.Duration
+	// When the mock was registered.
+	registered time.Time
+	// When the mock stops matching requests, set by ExpiresAt or ExpiresAfter.
+	// Zero if the mock never expires.
+	expiresAt time.Time
+	// How many calls the mock answers before it stops matching requests, set by ExpiresAfterCalls.
+	// Zero if the calls aren't limited.
+	callsLimit int
+	// The mock's response, encoded once if it never changes.
+	// Nil for mocks whose response must be generated for every request.
+	static *staticResponse
+	// Extra top-level members of the mock's responses, set by ResponseField.
+	fields map[string]any
+	// Wrap the handler that sends the mock's response, in order.
+	// The first decorator is the outermost one.
+	decorators []responseDecorator
+	// Every error found while applying the options, reported when the mock is registered.
+	err error
+}
+
+// fail records an error found while applying an option, so the mock fails to be registered.
+func (r *registration) fail(err error) {
+	r.err = errors.Join(r.err, err)
+}
+
+// apply applies the options to the registration, returning every error found while applying them.
+func (r *registration) apply(opts []RegisterOptions) error {
+	for _, fn := range opts {
+		fn(r)
+	}
+
+	err := r.err
+	r.err = nil
+	return err
+}
+
+// mockRequestKey is the context key of the GraphQL request answered by a mock.
+type mockRequestKey struct{}
+
+// withMockRequest stores the GraphQL request in the request's context,
+// so it may be inspected by the handler sending the mock's response.
+func withMockRequest(r *http.Request, req Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), mockRequestKey{}, req))
+}
+
+// mockRequest retrieves the GraphQL request answered by a mock from the request's context.
+func mockRequest(r *http.Request) Request {
+	req, _ := r.Context().Value(mockRequestKey{}).(Request)
+	return req
+}
+
+// responseDecorator wraps the handler that sends a mock's response (e.g., to delay it).
+// matched is the snapshot of the registration matched by the request,
+// which must be used instead of the registration the decorator was added to,
+// since the latter may be modified (e.g., replaced) while the response is sent.
+type responseDecorator func(s *server, matched *registration, next http.Handler) http.Handler
+
+// responseHandler creates the handler that sends the mock's response, wrapped by its decorators.
+func (r *registration) responseHandler(s *server) http.Handler {
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if isLiveRequest(req, mockRequest(req).Query) {
+			r.streamLive(s, w, req)
+			return
+		}
+
+		if r.respondStatic(s, w) {
+			return
+		}
+
+		res, err := r.response(req)
+		if err != nil {
+			r.respondFailure(s, w, req, err)
+			return
+		}
+		if tracing := r.apolloTracing(req); tracing != nil {
+			res.Extensions = apolloTracingExtensions{Tracing: tracing}
+		}
+		if r.fields != nil {
+			res.Fields = r.fields
+		}
+
+		respondGraphQL(w, req, http.StatusOK, res)
+	})
+
+	for i := len(r.decorators) - 1; i >= 0; i-- {
+		h = r.decorators[i](s, r, h)
+	}
+
+	return h
+}
+
+// unmetCalls describes why the number of calls to the mock doesn't meet its expectations.
+// It returns an empty string if the expectations were met.
+// s.mu must be held by the caller.
+func (r *registration) unmetCalls(s *server) string {
+	switch {
+	case r.calls < r.minCalls && r.calls == 0:
+		return r.describe(s) + " was never called"
+	case r.calls < r.minCalls:
+		return fmt.Sprintf("%s was called %d times, expected at least %d", r.describe(s), r.calls, r.minCalls)
+	case r.limitCalls && r.calls > r.maxCalls:
+		return fmt.Sprintf("%s was called %d times, expected at most %d", r.describe(s), r.calls, r.maxCalls)
+	default:
+		return ""
+	}
+}
+
+// describe returns a string identifying the registered mock in messages.
+// s.mu must be held by the caller.
+func (r *registration) describe(s *server) string {
+	if r.name != "" {
+		return fmt.Sprintf("%s mock '%s'", r.operation, r.name)
+	}
+
+	index := 0
+	for i, reg := range s.registrations[r.identifier] {
+		if reg == r {
+			index = i
+			break
+		}
+	}
+
+	return fmt.Sprintf("%s mock #%d for '%s' (%T)", r.operation, index, r.identifier, r.mock)
+}
+
+// compareVariables checks whether the request's variables match the mock,
+// passing the encoded variables to mocks implementing RawVariablesMatcher
+// and the request's context to mocks implementing ContextVariablesMatcher.
+// Mocks implementing RawBodyMatcher must also match the request's raw body.
+func (r *registration) compareVariables(req Request) bool {
+	var match bool
+	if m, ok := r.mock.(RawVariablesMatcher); ok {
+		match = m.CompareRawVariables(req.RawVariables())
+	} else if m, ok := r.mock.(ContextVariablesMatcher); ok {
+		match = m.CompareVariablesContext(req.Context(), req.variables())
+	} else {
+		match = r.mock.CompareVariables(req.variables())
+	}
+
+	if m, ok := r.mock.(RawBodyMatcher); ok && match {
+		return m.CompareRawBody(req.body)
+	}
+
+	return match
+}
+
+// snapshot copies the registration, so it may be read without holding s.mu
+// (e.g., while answering a request) even if the registration is later changed (e.g., by ReplaceQuery).
+// s.mu must be held by the caller.
+func (r *registration) snapshot() *registration {
+	cpy := *r
+	return &cpy
+}
+
+// visibleFrom checks whether the mock may match requests sent to the scope.
+// Unscoped mocks are visible from every scope.
+func (r *registration) visibleFrom(scope string) bool {
+	return r.scope == "" || r.scope == scope
+}
+
+// matchesQuery checks whether the request's query matches the mock's identifier and operation,
+// or, for persisted queries, whether the request is for the mock's persisted query.
+func (r *registration) matchesQuery(req Request, op OperationType) bool {
+	if r.persistedID != "" {
+		return req.persistedID == r.persistedID
+	}
+
+	return r.operation == op && strings.Contains(req.Query, r.identifier)
+}
+
+// expired checks whether the mock stopped matching requests, set by ExpiresAt, ExpiresAfter or ExpiresAfterCalls.
+// s.mu must be held by the caller.
+func (r *registration) expired() bool {
+	return (!r.expiresAt.IsZero() && !time.Now().Before(r.expiresAt)) || (r.callsLimit > 0 && r.calls >= r.callsLimit)
+}
+
+// inTurn checks whether the mock may be called now.
+// Expired mocks may not be called,
+// and mocks replayed in order may only be called once, after the previous mock in the session.
+// s.mu must be held by the caller.
+func (r *registration) inTurn() bool {
+	if r.expired() {
+		return false
+	} else if !r.ordered {
+		return true
+	}
+
+	return r.calls == 0 && (r.previous == nil || r.previous.calls > 0)
+}
+
+// logName identifies the mock in logs, by either its name or its type.
+func (r *registration) logName() string {
+	if r.name != "" {
+		return r.name
+	}
+
+	return fmt.Sprintf("%T", r.mock)
+}
+
+// register adds the mock for the identifier, panicking if it's invalid.
+func (s *server) register(identifier string, op OperationType, mock MockedRequest, opts []RegisterOptions) {
+	s.mustRegisterIn("", identifier, op, mock, opts)
+}
+
+// mustRegisterIn works like registerIn, but panics if the mock is invalid.
+func (s *server) mustRegisterIn(scope, identifier string, op OperationType, mock MockedRequest, opts []RegisterOptions) *registration {
+	reg, err := s.registerIn(scope, identifier, op, mock, opts)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return reg
+}
+
+// registerIn adds the mock for the identifier, visible only from the scope, returning its registration.
+// Invalid mocks (e.g., whose StringResponse isn't a valid JSON object) aren't registered.
+func (s *server) registerIn(scope, identifier string, op OperationType, mock MockedRequest, opts []RegisterOptions) (*registration, error) {
+	if err := s.validateRegistration(identifier, op, mock); err != nil {
+		return nil, err
+	}
+
+	reg := &registration{
+		identifier: identifier,
+		operation:  op,
+		mock:       mock,
+		scope:      scope,
+		registered: time.Now(),
+	}
+	if err := reg.apply(opts); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addRegistration(reg)
+	return reg, nil
+}
+
+// addRegistration adds the registered mock after every other mock with the same identifier.
+// Scoped mocks are added before the unscoped ones, so they take precedence within their scope.
+// s.mu must be held by the caller.
+func (s *server) addRegistration(reg *registration) {
+	reg.premarshal()
+
+	registrations := s.registrations[reg.identifier]
+
+	i := len(registrations)
+	if reg.scope != "" {
+		for i > 0 && registrations[i-1].scope == "" {
+			i--
+		}
+	}
+
+	s.registrations[reg.identifier] = append(registrations[:i], append([]*registration{reg}, registrations[i:]...)...)
+	s.index.invalidate()
+}
+
+// unregister removes every unscoped mock registered with the identifier.
+// If identifier is empty, every unscoped mock is removed.
+func (s *server) unregister(identifier string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeRegistrations(func(reg *registration) bool {
+		return reg.scope == "" && (identifier == "" || reg.identifier == identifier)
+	})
+}
+
+// Unregister implements Server for server.
+func (s *server) Unregister(identifier string) {
+	if identifier != "" {
+		s.unregister(identifier)
+	}
+}
+
+// ReplaceQuery implements Server for server.
+func (s *server) ReplaceQuery(identifier string, index int, mock MockedRequest) {
+	s.replaceIn("", identifier, fmt.Sprintf("#%d", index), mock, func(i int, reg *registration) bool {
+		return i == index
+	})
+}
+
+// ReplaceNamed implements Server for server.
+func (s *server) ReplaceNamed(identifier, name string, mock MockedRequest) {
+	s.replaceIn("", identifier, fmt.Sprintf("'%s'", name), mock, func(i int, reg *registration) bool {
+		return reg.name == name
+	})
+}
+
+// replaceIn replaces the mock of every registration for the identifier, in the scope, selected by match,
+// keeping its options and its calls.
+// match receives the position of the registration among the ones for the identifier in the scope.
+// It panics, describing the registration as desc, if no registration is selected.
+func (s *server) replaceIn(scope, identifier, desc string, mock MockedRequest, match func(i int, reg *registration) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var selected []*registration
+	i := 0
+	for _, reg := range s.registrations[identifier] {
+		if reg.scope != scope {
+			continue
+		}
+		if match(i, reg) {
+			selected = append(selected, reg)
+		}
+		i++
+	}
+
+	if len(selected) == 0 {
+		panic(fmt.Sprintf("goraphql_mock_server: there's no mock %s registered for '%s' to be replaced", desc, identifier))
+	}
+
+	for _, reg := range selected {
+		if err := s.validateRegistration(identifier, reg.operation, mock); err != nil {
+			panic(err.Error())
+		}
+		reg.mock = mock
+		reg.premarshal()
+	}
+}
+
+// ClearQueries implements Server for server.
+func (s *server) ClearQueries() {
+	s.unregister("")
+}
+
+// Reset implements Server for server.
+func (s *server) Reset() {
+	s.reset(false)
+}
+
+// ResetAll implements Server for server.
+func (s *server) ResetAll() {
+	s.reset(true)
+}
+
+// reset removes the server's mocks, history and counters.
+// Unless all is set, the mocks and requests of every scope are kept.
+func (s *server) reset(all bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if all {
+		s.registrations = make(map[string][]*registration)
+		s.index.invalidate()
+		s.defaults = nil
+		s.history.clear()
+		s.unmatched.clear()
+		s.attempts.clear()
+		s.scopeCounters = nil
+	} else {
+		s.removeRegistrations(func(reg *registration) bool {
+			return reg.scope == ""
+		})
+		delete(s.defaults, "")
+
+		keep := func(entry RecordedRequest) bool {
+			return entry.scope != ""
+		}
+		s.history.retain(keep)
+		s.unmatched.retain(keep)
+		s.attempts.retain(func(key requestKey) bool {
+			return key.scope != ""
+		})
+	}
+	s.counts = make(map[string]int)
+	s.total = 0
+	s.stats = statsAccumulator{}
+	s.orders = nil
+	s.orderViolations = nil
+	s.ambiguities = nil
+}
+
+// registerMocks atomically registers every mock.
+// If any of them is invalid, none is registered.
+func (s *server) registerMocks(mocks []sourcedMock) error {
+	return s.registerMocksIn("", mocks)
+}
+
+// registerMocksIn atomically registers every mock, visible only from the scope.
+// If any of them is invalid, none is registered.
+func (s *server) registerMocksIn(scope string, mocks []sourcedMock) error {
+	if err := s.validateMocks(mocks); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range mocks {
+		s.addRegistration(&registration{
+			identifier: m.identifier,
+			operation:  m.operation,
+			mock:       m.mock,
+			name:       m.name,
+			headers:    m.headers,
+			scope:      scope,
+		})
+	}
+
+	return nil
+}
+
+// replaceSource atomically replaces every mock registered from the source
+// by the provided mocks.
+// If any of them is invalid, the mocks registered from the source are kept.
+func (s *server) replaceSource(source string, mocks []sourcedMock) error {
+	if err := s.validateMocks(mocks); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeSource(source)
+	s.addMocks(source, mocks)
+	return nil
+}
+
+// validateMocks checks every mock, like they're checked when registered by RegisterQuery.
+func (s *server) validateMocks(mocks []sourcedMock) error {
+	var errs []error
+	for _, m := range mocks {
+		errs = append(errs, s.validateRegistration(m.identifier, m.operation, m.mock))
+	}
+
+	return errors.Join(errs...)
+}
+
+// addMocks registers every mock from the source.
+// s.mu must be held by the caller.
+func (s *server) addMocks(source string, mocks []sourcedMock) {
+	for _, m := range mocks {
+		reg := &registration{
+			identifier: m.identifier,
+			operation:  m.operation,
+			mock:       m.mock,
+			name:       m.name,
+			headers:    m.headers,
+			source:     source,
+		}
+		reg.premarshal()
+		s.registrations[m.identifier] = append(s.registrations[m.identifier], reg)
+	}
+	s.index.invalidate()
+}
+
+// sourcedMock associates a mock loaded from a source with its identifier.
+type sourcedMock struct {
+	identifier string
+	operation  OperationType
+	mock       MockedRequest
+	name       string
+	headers    http.Header
+}
+
+// removeSource removes every mock registered from the source.
+// s.mu must be held by the caller.
+func (s *server) removeSource(source string) {
+	s.removeRegistrations(func(reg *registration) bool {
+		return reg.source == source
+	})
+}
+
+// removeRegistrations removes every mock for which remove returns true.
+// s.mu must be held by the caller.
+func (s *server) removeRegistrations(remove func(reg *registration) bool) {
+	for id, registrations := range s.registrations {
+		var kept []*registration
+		for _, reg := range registrations {
+			if !remove(reg) {
+				kept = append(kept, reg)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(s.registrations, id)
+		} else {
+			s.registrations[id] = kept
+		}
+	}
+	s.index.invalidate()
+}
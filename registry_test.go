@@ -0,0 +1,300 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUnregister checks that mocks may be removed from a running server.
+func TestUnregister(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+	s.RegisterMutation("CreateFoo", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"CreateFoo": {"id": "abc"}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+	run := func(query string) error {
+		return client.Run(context.Background(), graphql.NewRequest(query), nil)
+	}
+
+	s.Unregister("ListFoos")
+	assert.Error(t, run(`query { ListFoos { foo } }`))
+	assert.NoError(t, run(`mutation { CreateFoo { id } }`))
+
+	s.ClearQueries()
+	assert.Error(t, run(`mutation { CreateFoo { id } }`))
+
+	// The history is kept.
+	assert.Equal(t, 3, s.TotalCount())
+}
+
+// TestReset checks that a server may be reused after being reset.
+func TestReset(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, Required())
+	s.InOrder("ListBars", "ListFoos")
+
+	client := graphql.NewClient(s.URL())
+	_ = client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), nil)
+	_ = client.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), nil)
+	assert.Error(t, s.ExpectationsWereMet())
+
+	s.Reset()
+
+	assert.NoError(t, s.ExpectationsWereMet())
+	assert.Empty(t, s.Requests())
+	assert.Empty(t, s.UnmatchedRequests())
+	assert.Empty(t, s.Counts())
+	assert.Zero(t, s.TotalCount())
+
+	err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), nil)
+	assert.Error(t, err)
+}
+
+// TestReplaceQuery checks that a single mock may be replaced, keeping the others.
+func TestReplaceQuery(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	foo := func(n int) MockedRequest {
+		return SimpleMockedRequest{StringResponse: StringResponse(fmt.Sprintf(`{"GetFoo": %d}`, n))}
+	}
+
+	s.RegisterQuery("GetFoo", foo(1), Named("first"))
+	s.RegisterQuery("GetFoo", foo(2))
+
+	client := graphql.NewClient(s.URL())
+	get := func() any {
+		var resp map[string]any
+		err := client.Run(context.Background(), graphql.NewRequest(`query { GetFoo }`), &resp)
+		assert.NoError(t, err)
+		return resp["GetFoo"]
+	}
+
+	assert.Equal(t, float64(1), get())
+
+	s.ReplaceQuery("GetFoo", 0, foo(3))
+	assert.Equal(t, float64(3), get())
+
+	s.ReplaceNamed("GetFoo", "first", foo(4))
+	assert.Equal(t, float64(4), get())
+
+	// The name is kept.
+	s.ReplaceNamed("GetFoo", "first", foo(5))
+	assert.Equal(t, float64(5), get())
+
+	assert.Panics(t, func() {
+		s.ReplaceQuery("GetFoo", 2, foo(0))
+	})
+	assert.Panics(t, func() {
+		s.ReplaceNamed("GetBar", "first", foo(0))
+	})
+
+	// Scopes only replace their own mocks.
+	sc := s.Scope(t)
+	assert.Panics(t, func() {
+		sc.ReplaceQuery("GetFoo", 0, foo(0))
+	})
+}
+
+// TestExpiration checks that expired mocks stop matching requests, falling back to the following mocks.
+func TestExpiration(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	foo := func(n int) MockedRequest {
+		return SimpleMockedRequest{StringResponse: StringResponse(fmt.Sprintf(`{"GetFoo": %d}`, n))}
+	}
+
+	s.RegisterQuery("GetFoo", foo(1), ExpiresAt(time.Now().Add(-time.Second)))
+	s.RegisterQuery("GetFoo", foo(2), ExpiresAfterCalls(2))
+	s.RegisterQuery("GetFoo", foo(3), ExpiresAfter(200*time.Millisecond))
+	s.RegisterQuery("GetBar", foo(4), ExpiresAfterCalls(1))
+
+	client := graphql.NewClient(s.URL())
+	get := func(query string) (any, error) {
+		var resp map[string]any
+		err := client.Run(context.Background(), graphql.NewRequest(query), &resp)
+		return resp["GetFoo"], err
+	}
+
+	for i, expected := range []float64{2, 2, 3} {
+		got, err := get(`query { GetFoo }`)
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, expected, got, "test %d", i)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	_, err := get(`query { GetFoo }`)
+	assert.Error(t, err)
+
+	_, err = get(`query { GetBar }`)
+	assert.NoError(t, err)
+	_, err = get(`query { GetBar }`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "mocked request not found")
+	}
+
+	assert.Contains(t, s.Dump(), "called 1 times, expired")
+	assert.Len(t, s.UnmatchedRequests(), 2)
+}
+
+// TestResponseField checks that mocks may send extra top-level members in their responses.
+func TestResponseField(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}, ResponseField("cost", map[string]any{"requested": 3}), ResponseField("data", "ignored"))
+
+	resp, err := http.Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data": {"ListFoos": {"foo": 123}}, "cost": {"requested": 3}}`, string(body))
+
+	data, err := json.Marshal(Response{Data: 1})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data": 1}`, string(data), "a response without fields changed")
+}
+
+// TestConcurrentRegistration checks that mocks may be registered, replaced and unregistered
+// while requests are in flight. It's only meaningful when run with -race.
+func TestConcurrentRegistration(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	mock := SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	}
+	s.RegisterQuery("ListFoos", mock)
+
+	const workers = 4
+	const iterations = 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			client := s.GraphQLClient()
+			for j := 0; j < iterations; j++ {
+				var resp map[string]any
+				_ = client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+			}
+		}()
+
+		// Each worker (un)registers its own identifier, so they don't remove each other's mocks.
+		identifier := fmt.Sprintf("ListBars%d", i)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < iterations; j++ {
+				s.RegisterQuery(identifier, mock, Named("bars"))
+				s.ReplaceNamed(identifier, "bars", mock)
+				s.ReplaceQuery("ListFoos", 0, mock)
+				_ = s.Requests()
+				_ = s.Dump()
+				_ = s.ExpectationsWereMet()
+				s.Unregister(identifier)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, workers*iterations, len(s.RequestsFor("ListFoos")), "unexpected number of matched requests")
+}
+
+// blockingMock is a mock whose matcher blocks until release is closed,
+// signaling entered when it starts matching a request.
+type blockingMock struct {
+	SimpleMockedRequest
+	// Receives a value whenever the mock starts matching a request.
+	entered chan struct{}
+	// Closed to let the mock finish matching requests.
+	release chan struct{}
+}
+
+// CompareVariables implements MockedRequest for blockingMock.
+func (m blockingMock) CompareVariables(map[string]any) bool {
+	m.entered <- struct{}{}
+	<-m.release
+	return true
+}
+
+// TestConcurrentMatching checks that concurrent requests are matched concurrently,
+// and that mocks limited to some calls aren't called more often by concurrent requests.
+func TestConcurrentMatching(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	slow := blockingMock{
+		SimpleMockedRequest: SimpleMockedRequest{StringResponse: StringResponse(`{"GetFoo": 1}`)},
+		entered:             make(chan struct{}),
+		release:             make(chan struct{}),
+	}
+	s.RegisterQuery("GetFoo", slow)
+	s.RegisterQuery("GetBar", SimpleMockedRequest{StringResponse: StringResponse(`{"GetBar": 2}`)}, ExpiresAfterCalls(1))
+
+	const workers = 8
+	client := s.GraphQLClient()
+	send := func(query string, errs chan<- error) {
+		for i := 0; i < workers; i++ {
+			go func() {
+				var resp map[string]any
+				errs <- client.Run(context.Background(), graphql.NewRequest(query), &resp)
+			}()
+		}
+	}
+
+	// Every request must be matching the slow mock at the same time.
+	errs := make(chan error, workers)
+	send(`query { GetFoo }`, errs)
+	for i := 0; i < workers; i++ {
+		select {
+		case <-slow.entered:
+		case <-time.After(time.Second):
+			t.Fatalf("test %d: the request waited for another request to be matched", i)
+		}
+	}
+	close(slow.release)
+	for i := 0; i < workers; i++ {
+		assert.NoError(t, <-errs, "test %d", i)
+	}
+
+	send(`query { GetBar }`, errs)
+	answered := 0
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err == nil {
+			answered++
+		}
+	}
+	assert.Equal(t, 1, answered, "the mock was called more often than allowed")
+}
@@ -0,0 +1,60 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+)
+
+// ReplayMode defines how WithReplay matches requests against the recorded session.
+type ReplayMode int
+
+const (
+	// ReplayByContent answers each request with the first recorded response whose request matches it,
+	// regardless of the order the requests were recorded in. This is the default.
+	ReplayByContent ReplayMode = iota
+	// ReplayInOrder requires the requests to be received in exactly the order they were recorded in,
+	// answering each with its recorded response, once.
+	// Requests received out of order don't match any mock,
+	// and ExpectationsWereMet fails if any recorded response isn't replayed.
+	ReplayInOrder
+)
+
+// WithReplay registers the session recorded at path (e.g., written by Server.WriteRecordings),
+// so tests that previously ran against a real GraphQL server may run hermetically.
+// path may be either a file or a directory, in any format accepted by Server.LoadMocks.
+//
+// Depending on mode, the recorded requests are matched either by their content or in order.
+func WithReplay(path string, mode ReplayMode) ServerOptions {
+	return func(s *server) {
+		mocks, err := loadMocks(path)
+		if err != nil {
+			s.fail(fmt.Errorf("goraphql_mock_server: failed to load the session from %s: %w", path, err))
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var previous *registration
+		for _, m := range mocks {
+			reg := &registration{
+				identifier: m.identifier,
+				operation:  m.operation,
+				mock:       m.mock,
+				name:       m.name,
+				headers:    m.headers,
+				source:     "replay:" + path,
+			}
+
+			if mode == ReplayInOrder {
+				reg.ordered = true
+				reg.previous = previous
+				reg.minCalls = 1
+				reg.maxCalls = 1
+				reg.limitCalls = true
+				previous = reg
+			}
+
+			s.addRegistration(reg)
+		}
+	}
+}
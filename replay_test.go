@@ -0,0 +1,103 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReplay checks that recorded sessions are replayed either by content or in order.
+func TestReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	err := os.WriteFile(path, []byte(`[
+		{"identifier": "query { Status { value } }", "response": {"Status": {"value": "pending"}}},
+		{"identifier": "query { Foo { value } }", "response": {"Foo": {"value": "foo"}}},
+		{"identifier": "query { Status { value } }", "response": {"Status": {"value": "done"}}}
+	]`), 0o644)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	type testCase struct {
+		// How the session is replayed.
+		mode ReplayMode
+		// The queries sent, in order.
+		queries []string
+		// The value expected in the response of each query, or an empty string if it should fail.
+		expected []string
+		// Whether every recorded response should be replayed.
+		complete bool
+	}
+
+	testCases := []testCase{{
+		mode:     ReplayInOrder,
+		queries:  []string{"Status", "Foo", "Status"},
+		expected: []string{"pending", "foo", "done"},
+		complete: true,
+	}, {
+		mode:     ReplayInOrder,
+		queries:  []string{"Foo", "Status", "Status"},
+		expected: []string{"", "pending", ""},
+	}, {
+		mode:     ReplayByContent,
+		queries:  []string{"Foo", "Status", "Status"},
+		expected: []string{"foo", "pending", "pending"},
+		complete: true,
+	}}
+
+	for i, tc := range testCases {
+		s := New(WithReplay(path, tc.mode), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		client := graphql.NewClient(s.URL())
+
+		for j, name := range tc.queries {
+			var resp map[string]map[string]string
+			err := client.Run(context.Background(), graphql.NewRequest("query { "+name+" { value } }"), &resp)
+			if tc.expected[j] == "" {
+				assert.Error(t, err, "test %d: query %d", i, j)
+			} else if assert.NoError(t, err, "test %d: query %d", i, j) {
+				assert.Equal(t, tc.expected[j], resp[name]["value"], "test %d: query %d", i, j)
+			}
+		}
+
+		if tc.complete {
+			assert.NoError(t, s.ExpectationsWereMet(), "test %d", i)
+		} else {
+			assert.Error(t, s.ExpectationsWereMet(), "test %d", i)
+		}
+		s.Close()
+	}
+}
+
+// TestReplayClone checks that sessions replayed in order still advance in servers cloned from the original.
+func TestReplayClone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	err := os.WriteFile(path, []byte(`[
+		{"identifier": "query { Status { value } }", "response": {"Status": {"value": "pending"}}},
+		{"identifier": "query { Status { value } }", "response": {"Status": {"value": "done"}}}
+	]`), 0o644)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := New(WithReplay(path, ReplayInOrder), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	clone := s.Clone(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer clone.Close()
+
+	client := graphql.NewClient(clone.URL())
+	for i, expected := range []string{"pending", "done"} {
+		var resp map[string]map[string]string
+		err := client.Run(context.Background(), graphql.NewRequest("query { Status { value } }"), &resp)
+		if assert.NoError(t, err, "query %d", i) {
+			assert.Equal(t, expected, resp["Status"]["value"], "query %d", i)
+		}
+	}
+	assert.NoError(t, clone.ExpectationsWereMet())
+}
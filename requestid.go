@@ -0,0 +1,36 @@
+package goraphql_mock_server
+
+import (
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the header used by WithRequestID if none is specified.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// WithRequestID echoes the request ID, sent by the client in the header, back in the response,
+// so clients may correlate their logs with the server's responses.
+// If the request doesn't have an ID, a random one is generated.
+// Either way, the ID is recorded in the request's RequestID.
+//
+// If header is empty, DefaultRequestIDHeader is used.
+func WithRequestID(header string) ServerOptions {
+	return func(s *server) {
+		if header == "" {
+			header = DefaultRequestIDHeader
+		}
+
+		s.requestIDHeader = http.CanonicalHeaderKey(header)
+	}
+}
+
+// echoRequestID sends the request's ID back to the client, generating one if needed,
+// and returns it.
+func (s *server) echoRequestID(w http.ResponseWriter, r *http.Request) string {
+	id := r.Header.Get(s.requestIDHeader)
+	if id == "" {
+		id = randomHex(16)
+	}
+
+	w.Header().Set(s.requestIDHeader, id)
+	return id
+}
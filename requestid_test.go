@@ -0,0 +1,60 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWithRequestID checks that the request ID is echoed back to the client.
+func TestWithRequestID(t *testing.T) {
+	type testCase struct {
+		// The header configured in the server.
+		header string
+		// The header actually sent by the client.
+		sentHeader string
+		// The ID sent by the client, if any.
+		id string
+	}
+
+	testCases := []testCase{{
+		sentHeader: "X-Request-ID",
+		id:         "abc",
+	}, {
+		header:     "x-correlation-id",
+		sentHeader: "X-Correlation-ID",
+		id:         "def",
+	}, {
+		sentHeader: "X-Request-ID",
+	}}
+
+	for _, tc := range testCases {
+		s := New(WithRequestID(tc.header))
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		})
+
+		req := graphql.NewRequest(`query { ListFoos { foo } }`)
+		if tc.id != "" {
+			req.Header.Set(tc.sentHeader, tc.id)
+		}
+
+		err := graphql.NewClient(s.URL()).Run(context.Background(), req, nil)
+		assert.NoError(t, err)
+
+		requests := s.Requests()
+		if assert.Len(t, requests, 1) {
+			id := requests[0].RequestID
+			if tc.id != "" {
+				assert.Equal(t, tc.id, id)
+			} else {
+				assert.NotEmpty(t, id)
+			}
+			assert.Equal(t, id, requests[0].Response.Header.Get(tc.sentHeader))
+		}
+
+		s.Close()
+	}
+}
@@ -0,0 +1,158 @@
+package goraphql_mock_server
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// pausableListener wraps the server's listener so it may stop accepting connections for a while.
+// While paused, the underlying socket is closed, so new connections are refused,
+// and it's opened again on the same address when resumed.
+type pausableListener struct {
+	// The address the listener is bound to.
+	addr net.Addr
+	// Guards every field below.
+	mu sync.Mutex
+	// The listener currently accepting connections.
+	inner net.Listener
+	// Closed when the listener is resumed. It's nil unless the listener is paused.
+	resumed chan struct{}
+	// Whether the listener was closed.
+	closed bool
+}
+
+// newPausableListener wraps the listener.
+func newPausableListener(inner net.Listener) *pausableListener {
+	return &pausableListener{
+		addr:  inner.Addr(),
+		inner: inner,
+	}
+}
+
+// Accept implements net.Listener for pausableListener,
+// waiting until the listener is resumed if it's paused.
+func (l *pausableListener) Accept() (net.Conn, error) {
+	for {
+		l.mu.Lock()
+		inner := l.inner
+		l.mu.Unlock()
+
+		conn, err := inner.Accept()
+		if err == nil {
+			return conn, nil
+		}
+
+		// The listener is only paused after resumed is set,
+		// so either it's still set or the listener was already replaced
+		// if the error was caused by pausing it.
+		l.mu.Lock()
+		resumed := l.resumed
+		replaced := l.inner != inner
+		closed := l.closed
+		l.mu.Unlock()
+
+		switch {
+		case closed:
+			return nil, err
+		case resumed != nil:
+			<-resumed
+		case !replaced:
+			return nil, err
+		}
+	}
+}
+
+// Close implements net.Listener for pausableListener.
+func (l *pausableListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	if l.resumed != nil {
+		// The underlying listener is already closed,
+		// so simply release Accept.
+		close(l.resumed)
+		l.resumed = nil
+		return nil
+	}
+
+	return l.inner.Close()
+}
+
+// Addr implements net.Listener for pausableListener.
+func (l *pausableListener) Addr() net.Addr {
+	return l.addr
+}
+
+// pause stops accepting connections, refusing new ones.
+func (l *pausableListener) pause() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed || l.resumed != nil {
+		return nil
+	}
+
+	l.resumed = make(chan struct{})
+	return l.inner.Close()
+}
+
+// resume starts accepting connections again, on the same address.
+func (l *pausableListener) resume() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed || l.resumed == nil {
+		return nil
+	}
+
+	inner, err := net.Listen(l.addr.Network(), l.addr.String())
+	if err != nil {
+		return err
+	}
+
+	l.inner = inner
+	close(l.resumed)
+	l.resumed = nil
+	return nil
+}
+
+// Restart implements Server for server.
+func (s *server) Restart() {
+	s.server.CloseClientConnections()
+
+	if err := s.listener.pause(); err != nil {
+		s.logger.Error("goraphql_mock_server: failed to stop listening", "error", err)
+	}
+	if err := s.listener.resume(); err != nil {
+		s.logger.Error("goraphql_mock_server: failed to listen again", "error", err)
+	}
+}
+
+// Pause implements Server for server.
+func (s *server) Pause(d time.Duration) {
+	s.server.CloseClientConnections()
+
+	if err := s.listener.pause(); err != nil {
+		s.logger.Error("goraphql_mock_server: failed to stop listening", "error", err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-s.done:
+			return
+		}
+
+		if err := s.listener.resume(); err != nil {
+			s.logger.Error("goraphql_mock_server: failed to listen again", "error", err)
+		}
+	}()
+}
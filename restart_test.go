@@ -0,0 +1,57 @@
+package goraphql_mock_server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPause checks that paused servers refuse connections until resumed.
+func TestPause(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	send := func() error {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return nil
+	}
+
+	assert.NoError(t, send())
+
+	// The restart drops the connection kept alive by the client,
+	// so it must not be reused.
+	s.Restart()
+	s.Client().CloseIdleConnections()
+	assert.NoError(t, send(), "the server didn't restart")
+
+	const outage = 100 * time.Millisecond
+	s.Pause(outage)
+	assert.Error(t, send(), "the paused server accepted a connection")
+
+	time.Sleep(2 * outage)
+	assert.NoError(t, send(), "the server didn't resume")
+	assert.Len(t, s.RequestsFor("ListFoos"), 3, "the history wasn't kept")
+}
+
+// TestPauseClose checks that paused servers may be closed.
+func TestPauseClose(t *testing.T) {
+	s := New()
+	s.Pause(time.Hour)
+
+	start := time.Now()
+	s.Close()
+	assert.Less(t, time.Since(start), time.Second)
+}
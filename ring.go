@@ -0,0 +1,269 @@
+package goraphql_mock_server
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// WithMaxHistory limits the history to the last size requests received by the server
+// (and to the last size unmatched requests), evicting the oldest requests as new ones are received,
+// so long running (e.g., soak) tests don't run out of memory.
+//
+// The counts (e.g., Counts and TotalCount) still include the evicted requests.
+// The detected retries (i.e., the Attempt of each request) also include them,
+// as long as they're identical to one of the last size distinct requests.
+// The evicted requests may be kept in a file WithHistorySpill.
+func WithMaxHistory(size int) ServerOptions {
+	return func(s *server) {
+		if size < 0 {
+			s.fail(fmt.Errorf("goraphql_mock_server: invalid history size %d", size))
+			return
+		}
+
+		s.history.limit = size
+		s.unmatched.limit = size
+	}
+}
+
+// WithHistorySampling only stores a random sample, with the specified probability (in (0, 1]),
+// of the matched requests in the history, reducing its memory usage under load.
+//
+// Unmatched requests are always stored, so they're still reported by ExpectationsWereMet,
+// and the counts (e.g., Counts and TotalCount) still include every request.
+// The sample may be made reproducible WithSeed.
+func WithHistorySampling(rate float64) ServerOptions {
+	return func(s *server) {
+		if rate <= 0 || rate > 1 {
+			s.fail(fmt.Errorf("goraphql_mock_server: invalid history sampling rate %v", rate))
+			return
+		}
+
+		s.historySampling = rate
+	}
+}
+
+// WithHistorySpill writes the requests evicted from a history limited WithMaxHistory
+// to the file at path (created, or truncated, when the server starts), as JSON lines,
+// so they may still be inspected after the test.
+//
+// The file is only completely written once the server is closed.
+func WithHistorySpill(path string) ServerOptions {
+	return func(s *server) {
+		spill, err := newHistorySpill(path)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		s.spill = spill
+	}
+}
+
+// closeSpill closes the file receiving the requests evicted from the history, if any.
+func (s *server) closeSpill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.spill == nil {
+		return
+	}
+
+	if err := s.spill.close(); err != nil {
+		s.logger.Warn("goraphql_mock_server: failed to write the history spill file", "error", err)
+	}
+	s.spill = nil
+}
+
+// requestRing stores the requests received by the server, from the oldest to the newest.
+//
+// If it's limited, it works as a ring buffer:
+// once full, each new request replaces the oldest one,
+// so the memory used by the history is bounded in long running (e.g., soak) tests.
+type requestRing struct {
+	// The maximum number of stored requests. If zero, the number isn't limited.
+	limit int
+	// The stored requests, starting from start and wrapping around.
+	entries []RecordedRequest
+	// The index of the oldest request in entries.
+	start int
+}
+
+// len returns how many requests are stored.
+func (r *requestRing) len() int {
+	return len(r.entries)
+}
+
+// at returns the i-th oldest stored request.
+func (r *requestRing) at(i int) *RecordedRequest {
+	return &r.entries[(r.start+i)%len(r.entries)]
+}
+
+// push stores the request, returning the request evicted to make room for it, if any.
+func (r *requestRing) push(entry RecordedRequest) (RecordedRequest, bool) {
+	if r.limit <= 0 || len(r.entries) < r.limit {
+		r.entries = append(r.entries, entry)
+		return RecordedRequest{}, false
+	}
+
+	evicted := r.entries[r.start]
+	r.entries[r.start] = entry
+	r.start = (r.start + 1) % len(r.entries)
+
+	return evicted, true
+}
+
+// find returns the stored request with the sequence number, or nil if it isn't stored.
+// Requests are stored in the order they're received,
+// so the search starts from the newest one and stops at the first one received before it.
+func (r *requestRing) find(seq uint64) *RecordedRequest {
+	for i := r.len() - 1; i >= 0; i-- {
+		switch entry := r.at(i); {
+		case entry.seq == seq:
+			return entry
+		case entry.seq < seq:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// list returns a copy of the stored requests, from the oldest to the newest.
+func (r *requestRing) list() []RecordedRequest {
+	if len(r.entries) == 0 {
+		return nil
+	}
+
+	list := make([]RecordedRequest, 0, len(r.entries))
+	list = append(list, r.entries[r.start:]...)
+	return append(list, r.entries[:r.start]...)
+}
+
+// retain removes every request for which keep returns false.
+func (r *requestRing) retain(keep func(entry RecordedRequest) bool) {
+	var kept []RecordedRequest
+	for _, entry := range r.list() {
+		if keep(entry) {
+			kept = append(kept, entry)
+		}
+	}
+
+	r.entries = kept
+	r.start = 0
+}
+
+// clear removes every stored request.
+func (r *requestRing) clear() {
+	r.entries = nil
+	r.start = 0
+}
+
+// attemptTracker stores the last of each set of identical requests, so retries may be detected.
+//
+// If it's limited, it evicts the least recently received set once full,
+// so it doesn't grow unbounded in long running (e.g., soak) tests.
+type attemptTracker struct {
+	// The element of order holding each set's last attempt.
+	attempts map[requestKey]*list.Element
+	// The sets' keys, from the least to the most recently received.
+	order list.List
+}
+
+// get returns the last attempt of the set identified by key, if any.
+func (t *attemptTracker) get(key requestKey) (lastAttempt, bool) {
+	elem, ok := t.attempts[key]
+	if !ok {
+		return lastAttempt{}, false
+	}
+
+	return elem.Value.(*trackedAttempt).last, true
+}
+
+// put stores the last attempt of the set identified by key,
+// evicting the least recently received set if more than limit (if positive) sets are stored.
+func (t *attemptTracker) put(key requestKey, last lastAttempt, limit int) {
+	if elem, ok := t.attempts[key]; ok {
+		elem.Value.(*trackedAttempt).last = last
+		t.order.MoveToBack(elem)
+		return
+	}
+
+	if t.attempts == nil {
+		t.attempts = make(map[requestKey]*list.Element)
+	}
+	t.attempts[key] = t.order.PushBack(&trackedAttempt{key: key, last: last})
+
+	for limit > 0 && t.order.Len() > limit {
+		oldest := t.order.Front()
+		t.order.Remove(oldest)
+		delete(t.attempts, oldest.Value.(*trackedAttempt).key)
+	}
+}
+
+// retain removes every set for which keep returns false.
+func (t *attemptTracker) retain(keep func(key requestKey) bool) {
+	for key, elem := range t.attempts {
+		if !keep(key) {
+			t.order.Remove(elem)
+			delete(t.attempts, key)
+		}
+	}
+}
+
+// clear removes every set.
+func (t *attemptTracker) clear() {
+	t.attempts = nil
+	t.order.Init()
+}
+
+// trackedAttempt is a set of identical requests stored by an attemptTracker.
+type trackedAttempt struct {
+	// Identifies the set.
+	key requestKey
+	// The set's last attempt.
+	last lastAttempt
+}
+
+// historySpill writes the requests evicted from the history to a file, as JSON lines.
+type historySpill struct {
+	// The file receiving the requests.
+	file *os.File
+	// Buffers the writes to the file.
+	buf *bufio.Writer
+	// Encodes each request into buf.
+	enc *json.Encoder
+}
+
+// newHistorySpill creates (or truncates) the file at path, to receive the evicted requests.
+func newHistorySpill(path string) (*historySpill, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: failed to create the history spill file: %w", err)
+	}
+
+	buf := bufio.NewWriter(file)
+	return &historySpill{
+		file: file,
+		buf:  buf,
+		enc:  json.NewEncoder(buf),
+	}, nil
+}
+
+// write appends the request to the file.
+func (hs *historySpill) write(entry RecordedRequest) error {
+	return hs.enc.Encode(entry)
+}
+
+// close flushes every pending request and closes the file.
+func (hs *historySpill) close() error {
+	flushErr := hs.buf.Flush()
+	closeErr := hs.file.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+
+	return closeErr
+}
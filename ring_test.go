@@ -0,0 +1,188 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMaxHistory checks that a limited history only keeps the last requests,
+// spilling the evicted ones to a file.
+func TestMaxHistory(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "history.jsonl")
+
+	s := New(
+		WithMaxHistory(3),
+		WithHistorySpill(spillPath),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num"},
+	})
+
+	client := graphql.NewClient(s.URL())
+	for i := 0; i < 5; i++ {
+		req := graphql.NewRequest(`query ListFoos($num: Int!) { ListFoos(num: $num) { foo } }`)
+		req.Var("num", i)
+
+		var resp map[string]any
+		err := client.Run(context.Background(), req, &resp)
+		assert.NoError(t, err, "request %d", i)
+	}
+	for i := 0; i < 4; i++ {
+		var resp map[string]any
+		_ = client.Run(context.Background(), graphql.NewRequest(fmt.Sprintf(`query { ListBars%d { bar } }`, i)), &resp)
+	}
+
+	requests := s.Requests()
+	if assert.Len(t, requests, 3) {
+		for i, req := range requests {
+			assert.Equal(t, fmt.Sprintf(`query { ListBars%d { bar } }`, i+1), req.Query, "request %d", i)
+		}
+	}
+	assert.Len(t, s.UnmatchedRequests(), 3)
+	assert.Equal(t, 9, s.TotalCount())
+	assert.Equal(t, 5, s.Counts()["ListFoos"])
+
+	s.Close()
+
+	data, err := os.ReadFile(spillPath)
+	assert.NoError(t, err)
+
+	var spilled []RecordedRequest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var req RecordedRequest
+		assert.NoError(t, dec.Decode(&req))
+		spilled = append(spilled, req)
+	}
+	if assert.Len(t, spilled, 6) {
+		for i, req := range spilled[:5] {
+			assert.Equal(t, float64(i), req.Variables["num"], "spilled request %d", i)
+			assert.NotNil(t, req.Response, "spilled request %d", i)
+		}
+		assert.Equal(t, `query { ListBars0 { bar } }`, spilled[5].Query)
+	}
+}
+
+// TestHistorySampling checks that only a sample of the matched requests is stored,
+// while every unmatched request is.
+func TestHistorySampling(t *testing.T) {
+	s := New(
+		WithHistorySampling(0.5),
+		WithSeed(42),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+	for i := 0; i < 100; i++ {
+		var resp map[string]any
+		err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+		assert.NoError(t, err, "request %d", i)
+	}
+	for i := 0; i < 10; i++ {
+		var resp map[string]any
+		_ = client.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), &resp)
+	}
+
+	assert.Equal(t, 110, s.TotalCount())
+	assert.Equal(t, 100, s.Counts()["ListFoos"])
+	sampled := len(s.RequestsFor("ListFoos"))
+	assert.Greater(t, sampled, 25, "too few requests were sampled")
+	assert.Less(t, sampled, 75, "too many requests were sampled")
+	assert.Len(t, s.UnmatchedRequests(), 10)
+	assert.Len(t, s.Requests(), sampled+10)
+
+	_, err := NewE(WithHistorySampling(0))
+	assert.ErrorContains(t, err, "invalid history sampling rate")
+}
+
+// TestRingFind checks that requests are found by their sequence number, even after wrapping around.
+func TestRingFind(t *testing.T) {
+	type testCase struct {
+		// The sequence numbers of the stored requests, in the order they were pushed.
+		pushed []uint64
+		// The sequence number being searched.
+		seq uint64
+		// Whether the request should be found.
+		found bool
+	}
+
+	testCases := []testCase{{
+		pushed: []uint64{1, 2, 3},
+		seq:    2,
+		found:  true,
+	}, {
+		pushed: []uint64{1, 2, 3, 4, 5},
+		seq:    3,
+		found:  true,
+	}, {
+		pushed: []uint64{1, 2, 3, 4, 5},
+		seq:    2,
+	}, {
+		pushed: []uint64{1, 3, 5},
+		seq:    4,
+	}, {
+		pushed: []uint64{1, 2},
+		seq:    3,
+	}, {
+		seq: 1,
+	}}
+
+	for i, tc := range testCases {
+		ring := requestRing{limit: 3}
+		for _, seq := range tc.pushed {
+			ring.push(RecordedRequest{seq: seq})
+		}
+
+		entry := ring.find(tc.seq)
+		if !tc.found {
+			assert.Nil(t, entry, "test %d: unexpected request found", i)
+		} else if assert.NotNil(t, entry, "test %d: request not found", i) {
+			assert.Equal(t, tc.seq, entry.seq, "test %d: unexpected request", i)
+		}
+	}
+}
+
+// TestMaxHistoryScope checks that the counts of a scope include every request, even if the history is limited.
+func TestMaxHistoryScope(t *testing.T) {
+	s := New(WithMaxHistory(3), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	sc := s.Scope(t)
+	sc.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(sc.URL())
+	for i := 0; i < 10; i++ {
+		var resp map[string]any
+		err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+		assert.NoError(t, err, "request %d failed", i)
+	}
+
+	assert.Len(t, sc.Requests(), 3, "the history wasn't limited")
+	assert.Equal(t, map[string]int{"ListFoos": 10}, sc.Counts(), "unexpected counts")
+	assert.Equal(t, 10, sc.TotalCount(), "unexpected total count")
+	assert.Equal(t, 10, sc.Stats().Requests, "unexpected stats")
+	sc.AssertNumberOfCalls(t, "ListFoos", 10)
+
+	sc.Reset()
+	assert.Zero(t, sc.TotalCount(), "the counts weren't reset")
+}
@@ -0,0 +1,545 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"sort"
+)
+
+// This file implements a parser for GraphQL schemas written in SDL,
+// extending the parser for executable documents.
+
+// typeKind is the kind of a type defined in a schema.
+type typeKind string
+
+const (
+	kindScalar      typeKind = "SCALAR"
+	kindObject      typeKind = "OBJECT"
+	kindInterface   typeKind = "INTERFACE"
+	kindUnion       typeKind = "UNION"
+	kindEnum        typeKind = "ENUM"
+	kindInputObject typeKind = "INPUT_OBJECT"
+)
+
+// builtinScalars are the scalars defined by every schema.
+var builtinScalars = []string{"Int", "Float", "String", "Boolean", "ID"}
+
+// schema is a parsed GraphQL schema.
+type schema struct {
+	// Every type in the schema, indexed by their name.
+	types map[string]*schemaType
+	// The names of the root types, indexed by the operation type.
+	roots map[string]string
+}
+
+// schemaType is a type defined in a schema.
+type schemaType struct {
+	kind typeKind
+	name string
+	// The fields of objects and interfaces, or of input objects, in order.
+	fields []*schemaField
+	// The interfaces implemented by objects and interfaces.
+	interfaces []string
+	// The members of unions.
+	members []string
+	// The values of enums, in order.
+	enumValues []string
+}
+
+// schemaField is a field of an object, interface or input object,
+// or an argument of a field.
+type schemaField struct {
+	name string
+	typ  *typeRef
+	// The field's arguments, if it's a field of an object or interface.
+	arguments []*schemaField
+	// The default value of an input field or argument, if any.
+	defaultValue *value
+}
+
+// field retrieves the type's field with the name, if any.
+func (t *schemaType) field(name string) *schemaField {
+	for _, f := range t.fields {
+		if f.name == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// argument retrieves the field's argument with the name, if any.
+func (f *schemaField) argument(name string) *schemaField {
+	for _, arg := range f.arguments {
+		if arg.name == name {
+			return arg
+		}
+	}
+
+	return nil
+}
+
+// rootType retrieves the root type for the operation type (e.g., "query"), if any.
+func (sch *schema) rootType(op string) *schemaType {
+	return sch.types[sch.roots[op]]
+}
+
+// possibleTypes lists the object types that may be returned for the (abstract) type, in order.
+func (sch *schema) possibleTypes(t *schemaType) []*schemaType {
+	switch t.kind {
+	case kindObject:
+		return []*schemaType{t}
+	case kindUnion:
+		var types []*schemaType
+		for _, name := range t.members {
+			if member, ok := sch.types[name]; ok {
+				types = append(types, member)
+			}
+		}
+		return types
+	case kindInterface:
+		var types []*schemaType
+		for _, name := range sch.sortedTypes() {
+			if impl := sch.types[name]; impl.kind == kindObject && impl.implements(t.name) {
+				types = append(types, impl)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+// implements checks whether the type implements the interface.
+func (t *schemaType) implements(iface string) bool {
+	for _, name := range t.interfaces {
+		if name == iface {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedTypes lists the name of every type, sorted.
+func (sch *schema) sortedTypes() []string {
+	names := make([]string, 0, len(sch.types))
+	for name := range sch.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// parseSchema parses a GraphQL schema, split across the sources.
+// Types may be extended (with "extend type ...") in any of the sources.
+func parseSchema(sources ...string) (*schema, error) {
+	sch := &schema{
+		types: make(map[string]*schemaType),
+		roots: make(map[string]string),
+	}
+	for _, name := range builtinScalars {
+		sch.types[name] = &schemaType{kind: kindScalar, name: name}
+	}
+
+	// Extensions are only applied once every type is defined,
+	// so they may come before the types they extend.
+	var extensions []*schemaType
+	for _, src := range sources {
+		p := &parser{lex: lexer{src: src}}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		for p.tok.kind != tokenEOF {
+			t, extend, err := p.typeDefinition(sch)
+			if err != nil {
+				return nil, err
+			} else if t == nil {
+				continue
+			}
+
+			if extend {
+				extensions = append(extensions, t)
+			} else if _, ok := sch.types[t.name]; ok {
+				return nil, fmt.Errorf("type %q is defined multiple times", t.name)
+			} else {
+				sch.types[t.name] = t
+			}
+		}
+	}
+
+	for _, ext := range extensions {
+		t, ok := sch.types[ext.name]
+		if !ok {
+			return nil, fmt.Errorf("cannot extend undefined type %q", ext.name)
+		}
+
+		t.fields = append(t.fields, ext.fields...)
+		t.interfaces = append(t.interfaces, ext.interfaces...)
+		t.members = append(t.members, ext.members...)
+		t.enumValues = append(t.enumValues, ext.enumValues...)
+	}
+
+	for op, name := range map[string]string{"query": "Query", "mutation": "Mutation", "subscription": "Subscription"} {
+		if _, ok := sch.roots[op]; !ok {
+			if _, ok := sch.types[name]; ok {
+				sch.roots[op] = name
+			}
+		}
+	}
+	if sch.rootType("query") == nil {
+		return nil, fmt.Errorf("the schema doesn't define a query type")
+	}
+
+	return sch, nil
+}
+
+// typeDefinition parses a definition in a schema.
+// Schema and directive definitions are stored directly in the schema, returning a nil type.
+// Otherwise, it returns the defined type and whether it's an extension.
+func (p *parser) typeDefinition(sch *schema) (*schemaType, bool, error) {
+	if p.tok.kind == tokenString {
+		// Skip the description.
+		if err := p.advance(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	keyword, err := p.name()
+	if err != nil {
+		return nil, false, err
+	}
+
+	extend := keyword == "extend"
+	if extend {
+		if keyword, err = p.name(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	switch keyword {
+	case "schema":
+		return nil, false, p.schemaDefinition(sch)
+	case "directive":
+		return nil, false, p.directiveDefinition()
+	}
+
+	var kind typeKind
+	switch keyword {
+	case "scalar":
+		kind = kindScalar
+	case "type":
+		kind = kindObject
+	case "interface":
+		kind = kindInterface
+	case "union":
+		kind = kindUnion
+	case "enum":
+		kind = kindEnum
+	case "input":
+		kind = kindInputObject
+	default:
+		return nil, false, fmt.Errorf("unknown definition %q", keyword)
+	}
+
+	t := &schemaType{kind: kind}
+	if t.name, err = p.name(); err != nil {
+		return nil, false, err
+	}
+
+	if (kind == kindObject || kind == kindInterface) && p.peek(tokenName, "implements") {
+		if err := p.advance(); err != nil {
+			return nil, false, err
+		}
+		if _, err := p.skip("&"); err != nil {
+			return nil, false, err
+		}
+
+		for {
+			iface, err := p.name()
+			if err != nil {
+				return nil, false, err
+			}
+			t.interfaces = append(t.interfaces, iface)
+
+			if ok, err := p.skip("&"); err != nil {
+				return nil, false, err
+			} else if !ok {
+				break
+			}
+		}
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, false, err
+	}
+
+	switch kind {
+	case kindObject, kindInterface:
+		t.fields, err = p.fieldsDefinition(true)
+	case kindInputObject:
+		t.fields, err = p.fieldsDefinition(false)
+	case kindUnion:
+		t.members, err = p.unionMembers()
+	case kindEnum:
+		t.enumValues, err = p.enumValues()
+	}
+
+	return t, extend, err
+}
+
+// schemaDefinition parses the definition of the schema's root types.
+func (p *parser) schemaDefinition(sch *schema) error {
+	if _, err := p.directives(); err != nil {
+		return err
+	}
+	if !p.peek(tokenPunctuator, "{") {
+		return nil
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	for {
+		if ok, err := p.skip("}"); err != nil || ok {
+			return err
+		}
+
+		op, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if sch.roots[op], err = p.name(); err != nil {
+			return err
+		}
+	}
+}
+
+// directiveDefinition parses (and discards) the definition of a directive.
+func (p *parser) directiveDefinition() error {
+	if err := p.expect("@"); err != nil {
+		return err
+	}
+	if _, err := p.name(); err != nil {
+		return err
+	}
+
+	if p.peek(tokenPunctuator, "(") {
+		if _, err := p.argumentsDefinition(); err != nil {
+			return err
+		}
+	}
+
+	if p.peek(tokenName, "repeatable") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+
+	if !p.peek(tokenName, "on") {
+		return p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if _, err := p.skip("|"); err != nil {
+		return err
+	}
+
+	for {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+
+		if ok, err := p.skip("|"); err != nil || !ok {
+			return err
+		}
+	}
+}
+
+// fieldsDefinition parses the fields of a type, if any.
+// Arguments are only accepted if withArguments is set.
+func (p *parser) fieldsDefinition(withArguments bool) ([]*schemaField, error) {
+	if ok, err := p.skip("{"); err != nil || !ok {
+		return nil, err
+	}
+
+	var fields []*schemaField
+	for {
+		if ok, err := p.skip("}"); err != nil {
+			return nil, err
+		} else if ok {
+			return fields, nil
+		}
+
+		var field *schemaField
+		var err error
+		if withArguments {
+			field, err = p.fieldDefinition()
+		} else {
+			field, err = p.inputValueDefinition()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+}
+
+// fieldDefinition parses a field of an object or interface.
+func (p *parser) fieldDefinition() (*schemaField, error) {
+	if p.tok.kind == tokenString {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	field := &schemaField{}
+
+	var err error
+	if field.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunctuator, "(") {
+		if field.arguments, err = p.argumentsDefinition(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if field.typ, err = p.typeRef(); err != nil {
+		return nil, err
+	}
+
+	_, err = p.directives()
+	return field, err
+}
+
+// argumentsDefinition parses the arguments of a field or directive, including its parenthesis.
+func (p *parser) argumentsDefinition() ([]*schemaField, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var args []*schemaField
+	for {
+		if ok, err := p.skip(")"); err != nil {
+			return nil, err
+		} else if ok {
+			return args, nil
+		}
+
+		arg, err := p.inputValueDefinition()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+}
+
+// inputValueDefinition parses an argument, or a field of an input object.
+func (p *parser) inputValueDefinition() (*schemaField, error) {
+	if p.tok.kind == tokenString {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	field := &schemaField{}
+
+	var err error
+	if field.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if field.typ, err = p.typeRef(); err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		field.defaultValue = &v
+	}
+
+	_, err = p.directives()
+	return field, err
+}
+
+// unionMembers parses the members of a union, if any.
+func (p *parser) unionMembers() ([]string, error) {
+	if ok, err := p.skip("="); err != nil || !ok {
+		return nil, err
+	}
+	if _, err := p.skip("|"); err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for {
+		member, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+
+		if ok, err := p.skip("|"); err != nil {
+			return nil, err
+		} else if !ok {
+			return members, nil
+		}
+	}
+}
+
+// enumValues parses the values of an enum, if any.
+func (p *parser) enumValues() ([]string, error) {
+	if ok, err := p.skip("{"); err != nil || !ok {
+		return nil, err
+	}
+
+	var values []string
+	for {
+		if ok, err := p.skip("}"); err != nil {
+			return nil, err
+		} else if ok {
+			return values, nil
+		}
+
+		if p.tok.kind == tokenString {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+
+		values = append(values, name)
+	}
+}
+
+// namedType retrieves the innermost named type of the reference (e.g., "Foo" for "[Foo!]!").
+func (t *typeRef) namedType() string {
+	for t.elem != nil {
+		t = t.elem
+	}
+
+	return t.name
+}
@@ -0,0 +1,114 @@
+package goraphql_mock_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseSchema checks that every kind of definition is parsed from SDL.
+func TestParseSchema(t *testing.T) {
+	sch, err := parseSchema(`
+		"""The schema's root types."""
+		schema {
+			query: RootQuery
+		}
+
+		directive @goModel(model: String, models: [String!]) on OBJECT | INPUT_OBJECT
+
+		scalar Time
+
+		interface Node {
+			id: ID!
+		}
+
+		"A user."
+		type User implements Node @goModel(model: "example.User") {
+			id: ID!
+			"The user's name."
+			name(format: NameFormat = FULL): String
+			friends(first: Int = 10, filter: UserFilter): [User!]!
+		}
+
+		enum NameFormat {
+			FULL
+			SHORT @deprecated
+		}
+
+		input UserFilter {
+			name: String
+			since: Time = "2000-01-01"
+		}
+
+		union SearchResult = | User
+
+		type RootQuery {
+			node(id: ID!): Node
+		}
+	`, `
+		extend type RootQuery {
+			search(text: String!): [SearchResult!]!
+		}
+	`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "RootQuery", sch.rootType("query").name)
+	assert.Nil(t, sch.rootType("mutation"))
+
+	assert.Equal(t, kindScalar, sch.types["Time"].kind)
+	assert.Equal(t, kindScalar, sch.types["Int"].kind)
+	assert.Equal(t, []string{"FULL", "SHORT"}, sch.types["NameFormat"].enumValues)
+	assert.Equal(t, []string{"User"}, sch.types["SearchResult"].members)
+
+	user := sch.types["User"]
+	assert.Equal(t, kindObject, user.kind)
+	assert.Equal(t, []string{"Node"}, user.interfaces)
+	if friends := user.field("friends"); assert.NotNil(t, friends) {
+		assert.Equal(t, "[User!]!", friends.typ.String())
+		assert.Equal(t, "User", friends.typ.namedType())
+		assert.Equal(t, &value{kind: valueInt, raw: "10"}, friends.argument("first").defaultValue)
+		assert.Equal(t, "UserFilter", friends.argument("filter").typ.String())
+	}
+
+	filter := sch.types["UserFilter"]
+	assert.Equal(t, kindInputObject, filter.kind)
+	assert.Equal(t, &value{kind: valueString, raw: "2000-01-01"}, filter.field("since").defaultValue)
+
+	assert.Equal(t, []*schemaType{user}, sch.possibleTypes(sch.types["Node"]))
+	assert.Equal(t, []*schemaType{user}, sch.possibleTypes(sch.types["SearchResult"]))
+	assert.NotNil(t, sch.rootType("query").field("search"))
+}
+
+// TestParseSchemaErrors checks that invalid schemas are rejected.
+func TestParseSchemaErrors(t *testing.T) {
+	type testCase struct {
+		// The sources being parsed.
+		sources []string
+		// The expected error.
+		expected string
+	}
+
+	testCases := []testCase{{
+		sources:  []string{`type User { id: ID! }`},
+		expected: "the schema doesn't define a query type",
+	}, {
+		sources:  []string{`type Query { id: ID! }`, `type Query { name: String }`},
+		expected: `type "Query" is defined multiple times`,
+	}, {
+		sources:  []string{`type Query { id: ID! } extend type User { name: String }`},
+		expected: `cannot extend undefined type "User"`,
+	}, {
+		sources:  []string{`type Query { id: ID! } class User { name: String }`},
+		expected: `unknown definition "class"`,
+	}, {
+		sources:  []string{`type Query { id ID! }`},
+		expected: `unexpected "ID" at offset 16`,
+	}}
+
+	for i, tc := range testCases {
+		_, err := parseSchema(tc.sources...)
+		assert.EqualError(t, err, tc.expected, "test %d", i)
+	}
+}
@@ -0,0 +1,474 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// schemaMockName is the MockName of the requests answered by a SchemaMock, with WithSchemaMock.
+const schemaMockName = "schema"
+
+// FieldResolver resolves a field of a SchemaMock.
+//
+// parent is the value resolved for the object that owns the field
+// (decoded as JSON, so objects are map[string]any), or nil if it was generated.
+// args are the field's arguments, with their default values applied.
+//
+// The returned value is encoded as JSON, so it may be any value that encodes to the field's type.
+// Objects may be partial: their missing fields are resolved by their own resolvers or generated.
+// Returning an error resolves the field to null, and reports the error in the response.
+type FieldResolver func(parent any, args map[string]any) any
+
+// SchemaMock answers any operation valid in a GraphQL schema,
+// generating a value for every field that doesn't have a FieldResolver,
+// so tests may start from a complete fake server and only override the fields they care about.
+//
+// Generated scalars are always the same (e.g., 42 for Int and "Hello World" for String),
+// enums resolve to their first value, lists have two elements
+// and abstract types resolve to their first possible type.
+//
+// SchemaMock implements http.Handler, so it may be served on its own,
+// or answer the requests not matched by any mock with WithSchemaMock.
+type SchemaMock struct {
+	schema *schema
+	// Guards resolvers.
+	mu sync.RWMutex
+	// The resolvers overriding the generated fields, indexed by their coordinate (e.g., "Query.user").
+	resolvers map[string]FieldResolver
+}
+
+// NewSchemaMock creates a SchemaMock for the schema (in SDL), split across the sources.
+// gqlgen schemas, split across multiple files, may be loaded with LoadSchemaMock.
+func NewSchemaMock(sources ...string) (*SchemaMock, error) {
+	sch, err := parseSchema(sources...)
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: parse schema: %w", err)
+	}
+
+	return &SchemaMock{
+		schema:    sch,
+		resolvers: make(map[string]FieldResolver),
+	}, nil
+}
+
+// LoadSchemaMock creates a SchemaMock for the schema (in SDL) split across the files
+// (e.g., the schema files of a gqlgen project).
+func LoadSchemaMock(paths ...string) (*SchemaMock, error) {
+	sources := make([]string, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("goraphql_mock_server: read schema: %w", err)
+		}
+
+		sources = append(sources, string(data))
+	}
+
+	return NewSchemaMock(sources...)
+}
+
+// Resolve overrides the value of the field at the coordinate (e.g., "Query.user" or "User.name").
+// It panics if the schema doesn't define the field.
+func (m *SchemaMock) Resolve(coordinate string, fn FieldResolver) *SchemaMock {
+	typeName, fieldName, _ := strings.Cut(coordinate, ".")
+	if t, ok := m.schema.types[typeName]; !ok || t.field(fieldName) == nil {
+		panic(fmt.Sprintf("goraphql_mock_server: the schema doesn't define the field '%s'", coordinate))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resolvers[coordinate] = fn
+	return m
+}
+
+// WithSchemaMock answers every request that doesn't match any registered mock with the schema mock.
+// Those requests are recorded as matched, without an identifier and with the MockName "schema".
+//
+// This takes precedence over WithFallbackHandler and WithUnmatchedBehavior.
+func WithSchemaMock(m *SchemaMock) ServerOptions {
+	return func(s *server) {
+		s.schemaMock = m
+	}
+}
+
+// schemaRequest is a GraphQL request answered by a SchemaMock.
+type schemaRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeHTTP implements http.Handler for SchemaMock.
+func (m *SchemaMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req schemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, fmt.Errorf("goraphql_mock_server: decode request body: %w", err), nil)
+		return
+	}
+
+	res, err := m.execute(req)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	respondGraphQL(w, r, http.StatusOK, res)
+}
+
+// execute resolves the request's operation.
+// It returns an error if the operation can't be executed at all.
+func (m *SchemaMock) execute(req schemaRequest) (Response, error) {
+	doc, err := parseQuery(req.Query)
+	if err != nil {
+		return Response{}, fmt.Errorf("goraphql_mock_server: parse query: %w", err)
+	}
+
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return Response{}, fmt.Errorf("goraphql_mock_server: %w", err)
+	}
+
+	root := m.schema.rootType(op.kind)
+	if root == nil || op.kind == "subscription" {
+		return Response{}, fmt.Errorf("goraphql_mock_server: the schema doesn't support %s operations", op.kind)
+	}
+
+	variables := make(map[string]any)
+	for _, def := range op.variables {
+		if v, ok := req.Variables[def.name]; ok {
+			variables[def.name] = v
+		} else if def.defaultValue != nil {
+			variables[def.name] = def.defaultValue.resolve(nil)
+		}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e := &execution{mock: m, doc: doc, variables: variables}
+	data := e.object(root, nil, op.selections, nil)
+
+	return Response{Data: data, Errors: e.errors}, nil
+}
+
+// execution resolves an operation of a SchemaMock.
+type execution struct {
+	mock *SchemaMock
+	doc  *document
+	// The operation's variables, with their default values applied.
+	variables map[string]any
+	// The errors raised while resolving the operation.
+	errors []ResponseError
+}
+
+// fieldSelections are the selections of a field, merged by its response key.
+type fieldSelections struct {
+	key  string
+	name string
+	// Every selection of the field, in order.
+	selections []selection
+}
+
+// object resolves the selections of an object.
+func (e *execution) object(t *schemaType, parent any, sels []selection, path []string) map[string]any {
+	result := make(map[string]any)
+
+	for _, fs := range e.collectFields(t, sels, nil, make(map[string]bool)) {
+		fieldPath := append(append([]string(nil), path...), fs.key)
+
+		if fs.name == "__typename" {
+			result[fs.key] = t.name
+			continue
+		}
+
+		def := t.field(fs.name)
+		if def == nil {
+			e.fail(fieldPath, fmt.Sprintf("Cannot query field %q on type %q.", fs.name, t.name))
+			continue
+		}
+
+		var children []selection
+		for _, sel := range fs.selections {
+			children = append(children, sel.selections...)
+		}
+
+		v, resolved := e.resolve(t, def, parent, fs.selections[0].arguments)
+		result[fs.key] = e.complete(def.typ, v, resolved, children, fieldPath)
+	}
+
+	return result
+}
+
+// collectFields lists the fields selected in the object, merging the selections with the same response key.
+// Skipped fields and fragments that don't apply to the object are ignored.
+func (e *execution) collectFields(t *schemaType, sels []selection, fields []*fieldSelections, visited map[string]bool) []*fieldSelections {
+	for _, sel := range sels {
+		if !e.included(sel.directives) {
+			continue
+		}
+
+		switch sel.kind {
+		case selectionField:
+			var fs *fieldSelections
+			for _, f := range fields {
+				if f.key == sel.responseKey() {
+					fs = f
+					break
+				}
+			}
+			if fs == nil {
+				fs = &fieldSelections{key: sel.responseKey(), name: sel.name}
+				fields = append(fields, fs)
+			}
+			fs.selections = append(fs.selections, sel)
+		case selectionInlineFragment:
+			if e.applies(t, sel.typeCondition) {
+				fields = e.collectFields(t, sel.selections, fields, visited)
+			}
+		case selectionFragmentSpread:
+			frag, ok := e.doc.fragments[sel.name]
+			if !ok || visited[sel.name] || !e.applies(t, frag.typeCondition) {
+				continue
+			}
+
+			visited[sel.name] = true
+			fields = e.collectFields(t, frag.selections, fields, visited)
+		}
+	}
+
+	return fields
+}
+
+// included evaluates the @skip and @include directives of a selection.
+func (e *execution) included(dirs []directive) bool {
+	for _, dir := range dirs {
+		if dir.name != "skip" && dir.name != "include" {
+			continue
+		}
+
+		for _, arg := range dir.arguments {
+			if arg.name == "if" {
+				cond, _ := arg.value.resolve(e.variables).(bool)
+				if cond == (dir.name == "skip") {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// applies checks whether a fragment with the type condition applies to the object.
+func (e *execution) applies(t *schemaType, typeCondition string) bool {
+	if typeCondition == "" || typeCondition == t.name {
+		return true
+	}
+
+	cond, ok := e.mock.schema.types[typeCondition]
+	if !ok {
+		return false
+	}
+
+	for _, possible := range e.mock.schema.possibleTypes(cond) {
+		if possible == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolve resolves the field of the object, either by its resolver or from its parent.
+// It returns false if the field should be generated.
+func (e *execution) resolve(t *schemaType, def *schemaField, parent any, args []argument) (any, bool) {
+	if fn, ok := e.mock.resolvers[t.name+"."+def.name]; ok {
+		return normalizeResolved(fn(parent, e.arguments(def, args))), true
+	}
+
+	if obj, ok := parent.(map[string]any); ok {
+		if v, ok := obj[def.name]; ok {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+// arguments resolves the field's arguments, applying their default values.
+func (e *execution) arguments(def *schemaField, args []argument) map[string]any {
+	resolved := make(map[string]any)
+
+	for _, arg := range def.arguments {
+		if arg.defaultValue != nil {
+			resolved[arg.name] = arg.defaultValue.resolve(nil)
+		}
+	}
+
+	for _, arg := range args {
+		if arg.value.kind == valueVariable {
+			if v, ok := e.variables[arg.value.raw]; ok {
+				resolved[arg.name] = v
+			}
+			continue
+		}
+
+		resolved[arg.name] = arg.value.resolve(e.variables)
+	}
+
+	return resolved
+}
+
+// complete converts the value resolved for a field into the field's type,
+// generating it if it wasn't resolved.
+func (e *execution) complete(typ *typeRef, v any, resolved bool, sels []selection, path []string) any {
+	if err, ok := v.(error); ok {
+		e.fail(path, err.Error())
+		return nil
+	}
+
+	if resolved && v == nil {
+		return nil
+	}
+
+	if typ.elem != nil {
+		var items []any
+		if resolved {
+			items, _ = v.([]any)
+		} else {
+			items = []any{nil, nil}
+		}
+
+		list := make([]any, 0, len(items))
+		for i, item := range items {
+			list = append(list, e.complete(typ.elem, item, resolved, sels, append(append([]string(nil), path...), strconv.Itoa(i))))
+		}
+
+		return list
+	}
+
+	t, ok := e.mock.schema.types[typ.name]
+	if !ok {
+		e.fail(path, fmt.Sprintf("Unknown type %q.", typ.name))
+		return nil
+	}
+
+	switch t.kind {
+	case kindScalar:
+		if resolved {
+			return v
+		}
+		return generatedScalar(t.name)
+	case kindEnum:
+		if resolved || len(t.enumValues) == 0 {
+			return v
+		}
+		return t.enumValues[0]
+	case kindObject, kindInterface, kindUnion:
+		concrete := e.concreteType(t, v)
+		if concrete == nil {
+			e.fail(path, fmt.Sprintf("Abstract type %q has no possible type.", t.name))
+			return nil
+		}
+
+		return e.object(concrete, v, sels, path)
+	default:
+		return v
+	}
+}
+
+// concreteType retrieves the object type of a value resolved for the type:
+// the one in the value's __typename, if any, or the type's first possible type.
+func (e *execution) concreteType(t *schemaType, v any) *schemaType {
+	if obj, ok := v.(map[string]any); ok {
+		if name, ok := obj["__typename"].(string); ok {
+			if concrete, ok := e.mock.schema.types[name]; ok && e.applies(concrete, t.name) {
+				return concrete
+			}
+		}
+	}
+
+	if possible := e.mock.schema.possibleTypes(t); len(possible) > 0 {
+		return possible[0]
+	}
+
+	return nil
+}
+
+// fail reports an error raised while resolving the field at the path.
+func (e *execution) fail(path []string, msg string) {
+	e.errors = append(e.errors, ResponseError{Message: msg, Path: path})
+}
+
+// generatedScalar generates a value for the scalar.
+func generatedScalar(name string) any {
+	switch name {
+	case "Int":
+		return 42
+	case "Float":
+		return 4.2
+	case "Boolean":
+		return true
+	case "ID":
+		return "1"
+	default:
+		return "Hello World"
+	}
+}
+
+// normalizeResolved converts the value returned by a FieldResolver into its JSON representation
+// (e.g., structs into map[string]any), so it may be resolved like any other value.
+// Errors are kept as they are.
+func normalizeResolved(v any) any {
+	if _, ok := v.(error); ok || v == nil {
+		return v
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("goraphql_mock_server: encode resolved value: %w", err)
+	}
+
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return fmt.Errorf("goraphql_mock_server: decode resolved value: %w", err)
+	}
+
+	return normalized
+}
+
+// resolve converts the value into its JSON representation (e.g., numbers into float64),
+// replacing variables by their values.
+func (v value) resolve(variables map[string]any) any {
+	switch v.kind {
+	case valueVariable:
+		return variables[v.raw]
+	case valueInt, valueFloat:
+		n, _ := strconv.ParseFloat(v.raw, 64)
+		return n
+	case valueBoolean:
+		return v.raw == "true"
+	case valueNull:
+		return nil
+	case valueList:
+		list := make([]any, 0, len(v.list))
+		for _, elem := range v.list {
+			list = append(list, elem.resolve(variables))
+		}
+		return list
+	case valueObject:
+		obj := make(map[string]any, len(v.fields))
+		for _, field := range v.fields {
+			obj[field.name] = field.value.resolve(variables)
+		}
+		return obj
+	default:
+		return v.raw
+	}
+}
@@ -0,0 +1,194 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testSchema is the schema used to test SchemaMock.
+const testSchema = `
+	interface Node {
+		id: ID!
+	}
+
+	type User implements Node {
+		id: ID!
+		name: String!
+		age: Int
+		role: Role!
+		friends(first: Int = 2): [User!]!
+	}
+
+	enum Role {
+		ADMIN
+		GUEST
+	}
+
+	type Query {
+		user(id: ID!): User
+		node(id: ID!): Node
+		version: String!
+	}
+
+	type Mutation {
+		deleteUser(id: ID!): Boolean!
+	}
+`
+
+// TestSchemaMock checks that operations are resolved by the resolvers, generating every other field.
+func TestSchemaMock(t *testing.T) {
+	m, err := NewSchemaMock(testSchema)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	m.Resolve("Query.user", func(parent any, args map[string]any) any {
+		if args["id"] == "0" {
+			return errors.New("user not found")
+		}
+
+		return map[string]any{"id": args["id"], "name": "Foo"}
+	}).Resolve("User.friends", func(parent any, args map[string]any) any {
+		// The parent is nil if it was generated.
+		name := "nobody"
+		if user, ok := parent.(map[string]any); ok {
+			name, _ = user["name"].(string)
+		}
+
+		friends := []map[string]any{}
+		for range int(args["first"].(float64)) {
+			friends = append(friends, map[string]any{"name": "Friend of " + name})
+		}
+		return friends
+	})
+
+	type testCase struct {
+		// The request sent to the mock.
+		req schemaRequest
+		// The expected response.
+		expected Response
+	}
+
+	testCases := []testCase{{
+		req: schemaRequest{
+			Query:     `query ($id: ID!) { user(id: $id) { id name age role friends(first: 1) { name } } version }`,
+			Variables: map[string]any{"id": "7"},
+		},
+		expected: Response{Data: map[string]any{
+			"user": map[string]any{
+				"id":      "7",
+				"name":    "Foo",
+				"age":     42,
+				"role":    "ADMIN",
+				"friends": []any{map[string]any{"name": "Friend of Foo"}},
+			},
+			"version": "Hello World",
+		}},
+	}, {
+		req: schemaRequest{
+			Query: `query { node(id: "1") { __typename id ... on User { me: name } ...Friends } } fragment Friends on User { friends { id } }`,
+		},
+		expected: Response{Data: map[string]any{
+			"node": map[string]any{
+				"__typename": "User",
+				"id":         "1",
+				"me":         "Hello World",
+				"friends":    []any{map[string]any{"id": "1"}, map[string]any{"id": "1"}},
+			},
+		}},
+	}, {
+		req: schemaRequest{
+			Query: `query { user(id: "0") { name } version @skip(if: true) }`,
+		},
+		expected: Response{
+			Data:   map[string]any{"user": nil},
+			Errors: []ResponseError{{Message: "user not found", Path: []string{"user"}}},
+		},
+	}, {
+		req: schemaRequest{
+			Query:         `query GetVersion { version } mutation DeleteUser { deleteUser(id: "1") }`,
+			OperationName: "DeleteUser",
+		},
+		expected: Response{Data: map[string]any{"deleteUser": true}},
+	}, {
+		req: schemaRequest{
+			Query: `query { version email }`,
+		},
+		expected: Response{
+			Data:   map[string]any{"version": "Hello World"},
+			Errors: []ResponseError{{Message: `Cannot query field "email" on type "Query".`, Path: []string{"email"}}},
+		},
+	}}
+
+	for i, tc := range testCases {
+		res, err := m.execute(tc.req)
+		if assert.NoError(t, err, "test %d", i) {
+			assert.Equal(t, tc.expected, res, "test %d", i)
+		}
+	}
+
+	_, err = m.execute(schemaRequest{Query: `subscription { version }`})
+	assert.EqualError(t, err, "goraphql_mock_server: the schema doesn't support subscription operations")
+
+	assert.Panics(t, func() {
+		m.Resolve("User.email", func(parent any, args map[string]any) any { return nil })
+	})
+}
+
+// TestWithSchemaMock checks that requests not matched by any mock are answered by the schema mock.
+func TestWithSchemaMock(t *testing.T) {
+	m, err := NewSchemaMock(testSchema)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := New(WithSchemaMock(m), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("version", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"version": "1.0.0"}`),
+	})
+
+	type testCase struct {
+		// The query sent to the server.
+		query string
+		// The expected response.
+		expected string
+	}
+
+	testCases := []testCase{{
+		query:    `query { version }`,
+		expected: `{"data": {"version": "1.0.0"}}`,
+	}, {
+		query:    `query { user(id: \"1\") { name } }`,
+		expected: `{"data": {"user": {"name": "Hello World"}}}`,
+	}}
+
+	for i, tc := range testCases {
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(`{"query": "`+tc.query+`"}`))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "test %d", i)
+		assert.JSONEq(t, tc.expected, string(body), "test %d", i)
+	}
+
+	requests := s.Requests()
+	if assert.Len(t, requests, 2) {
+		assert.True(t, requests[1].Matched)
+		assert.Equal(t, "schema", requests[1].MockName)
+	}
+	assert.Empty(t, s.UnmatchedRequests())
+	assert.Equal(t, map[string]int{"version": 1}, s.Counts())
+	assert.NoError(t, s.ExpectationsWereMet())
+}
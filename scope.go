@@ -0,0 +1,401 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	shurcool "github.com/shurcooL/graphql"
+)
+
+// scopePathPrefix prefixes the URL of every scope created by Server.Scope.
+const scopePathPrefix = "/__scope/"
+
+// scopedServer is a view of a server, created by Server.Scope,
+// whose mocks and history are isolated from other scopes.
+//
+// Methods not implemented by scopedServer affect the whole server.
+type scopedServer struct {
+	*server
+	// Identifies the scope, in the mocks it registered and in the requests it received.
+	id string
+}
+
+// Scope implements Server for server.
+func (s *server) Scope(t testing.TB) Server {
+	s.mu.Lock()
+	s.scopes++
+	sc := &scopedServer{
+		server: s,
+		id:     strconv.Itoa(s.scopes),
+	}
+	s.mu.Unlock()
+
+	t.Cleanup(func() {
+		if s.assertOnCleanup {
+			sc.AssertExpectations(t)
+		}
+		sc.Close()
+	})
+
+	return sc
+}
+
+// At implements Server for server.
+// The view's scope is identified by its path, so it never conflicts with the scopes created by Scope.
+func (s *server) At(path string) Server {
+	path = "/" + strings.Trim(path, "/")
+	if path == "/" {
+		return s
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !slices.Contains(s.paths, path) {
+		s.paths = append(s.paths, path)
+		slices.SortFunc(s.paths, func(a, b string) int {
+			return len(b) - len(a)
+		})
+	}
+
+	return &scopedServer{
+		server: s,
+		id:     path,
+	}
+}
+
+// requestScope retrieves the scope that received the request, if any:
+// either the namespace selected by its header, one created by Scope,
+// or the longest path created by At that prefixes the request's path.
+func (s *server) requestScope(r *http.Request) string {
+	if scope, ok := s.requestNamespace(r); ok {
+		return scope
+	}
+
+	if rest, ok := strings.CutPrefix(r.URL.Path, scopePathPrefix); ok {
+		scope, _, _ := strings.Cut(rest, "/")
+		return scope
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, path := range s.paths {
+		if rest, ok := strings.CutPrefix(r.URL.Path, path); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// Close implements Server for scopedServer,
+// removing every mock registered in the scope and every request it received.
+// The underlying server is kept running.
+func (sc *scopedServer) Close() {
+	sc.Reset()
+}
+
+// URL implements Server for scopedServer.
+func (sc *scopedServer) URL() string {
+	if _, ok := sc.namespace(); ok {
+		return sc.server.URL()
+	} else if strings.HasPrefix(sc.id, "/") {
+		return sc.server.URL() + sc.id
+	}
+
+	return sc.server.URL() + scopePathPrefix + sc.id
+}
+
+// GraphQLClient implements Server for scopedServer.
+func (sc *scopedServer) GraphQLClient(opts ...graphql.ClientOption) *graphql.Client {
+	return newGraphQLClient(sc.URL(), sc.Client(), opts)
+}
+
+// ShurcoolClient implements Server for scopedServer.
+func (sc *scopedServer) ShurcoolClient() *shurcool.Client {
+	return shurcool.NewClient(sc.URL(), sc.Client())
+}
+
+// RegisterQuery implements Server for scopedServer.
+func (sc *scopedServer) RegisterQuery(identifier string, mock MockedRequest, opts ...RegisterOptions) {
+	sc.mustRegisterIn(sc.id, identifier, OperationQuery, mock, opts)
+}
+
+// RegisterMutation implements Server for scopedServer.
+func (sc *scopedServer) RegisterMutation(identifier string, mock MockedRequest, opts ...RegisterOptions) {
+	sc.mustRegisterIn(sc.id, identifier, OperationMutation, mock, opts)
+}
+
+// RegisterQueryE implements Server for scopedServer.
+func (sc *scopedServer) RegisterQueryE(identifier string, mock MockedRequest, opts ...RegisterOptions) error {
+	_, err := sc.registerIn(sc.id, identifier, OperationQuery, mock, opts)
+	return err
+}
+
+// RegisterMutationE implements Server for scopedServer.
+func (sc *scopedServer) RegisterMutationE(identifier string, mock MockedRequest, opts ...RegisterOptions) error {
+	_, err := sc.registerIn(sc.id, identifier, OperationMutation, mock, opts)
+	return err
+}
+
+// RegisterPersistedQuery implements Server for scopedServer.
+func (sc *scopedServer) RegisterPersistedQuery(id string, mock MockedRequest, opts ...RegisterOptions) {
+	sc.mustRegisterIn(sc.id, id, OperationQuery, mock, append([]RegisterOptions{persistedQuery(id)}, opts...))
+}
+
+// RegisterDefinitions implements Server for scopedServer.
+func (sc *scopedServer) RegisterDefinitions(defs ...MockDefinition) error {
+	mocks, err := definitionMocks(defs)
+	if err != nil {
+		return err
+	}
+
+	return sc.registerMocksIn(sc.id, mocks)
+}
+
+// LoadMocks implements Server for scopedServer.
+func (sc *scopedServer) LoadMocks(path string) error {
+	mocks, err := loadMocks(path)
+	if err != nil {
+		return err
+	}
+
+	return sc.registerMocksIn(sc.id, mocks)
+}
+
+// LoadMocksFS implements Server for scopedServer.
+func (sc *scopedServer) LoadMocksFS(fsys fs.FS) error {
+	mocks, err := loadMocksFS(fsys)
+	if err != nil {
+		return err
+	}
+
+	return sc.registerMocksIn(sc.id, mocks)
+}
+
+// Unregister implements Server for scopedServer.
+func (sc *scopedServer) Unregister(identifier string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.removeRegistrations(func(reg *registration) bool {
+		return reg.scope == sc.id && reg.identifier == identifier
+	})
+}
+
+// ReplaceQuery implements Server for scopedServer.
+func (sc *scopedServer) ReplaceQuery(identifier string, index int, mock MockedRequest) {
+	sc.replaceIn(sc.id, identifier, fmt.Sprintf("#%d", index), mock, func(i int, reg *registration) bool {
+		return i == index
+	})
+}
+
+// ReplaceNamed implements Server for scopedServer.
+func (sc *scopedServer) ReplaceNamed(identifier, name string, mock MockedRequest) {
+	sc.replaceIn(sc.id, identifier, fmt.Sprintf("'%s'", name), mock, func(i int, reg *registration) bool {
+		return reg.name == name
+	})
+}
+
+// ClearQueries implements Server for scopedServer.
+func (sc *scopedServer) ClearQueries() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.removeRegistrations(func(reg *registration) bool {
+		return reg.scope == sc.id
+	})
+}
+
+// Reset implements Server for scopedServer.
+func (sc *scopedServer) Reset() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.removeRegistrations(func(reg *registration) bool {
+		return reg.scope == sc.id
+	})
+	delete(sc.defaults, sc.id)
+	delete(sc.scopeCounters, sc.id)
+
+	keep := func(entry RecordedRequest) bool {
+		return entry.scope != sc.id
+	}
+	sc.history.retain(keep)
+	sc.unmatched.retain(keep)
+	sc.attempts.retain(func(key requestKey) bool {
+		return key.scope != sc.id
+	})
+}
+
+// ResetAll implements Server for scopedServer.
+// Like Reset, it only affects the scope.
+func (sc *scopedServer) ResetAll() {
+	sc.Reset()
+}
+
+// Requests implements Server for scopedServer.
+func (sc *scopedServer) Requests() []RecordedRequest {
+	return sc.filterHistory(func(entry RecordedRequest) bool {
+		return entry.scope == sc.id
+	})
+}
+
+// RequestsFor implements Server for scopedServer.
+func (sc *scopedServer) RequestsFor(identifier string) []RecordedRequest {
+	return sc.filterHistory(func(entry RecordedRequest) bool {
+		return entry.scope == sc.id && entry.Matched && entry.Identifier == identifier
+	})
+}
+
+// UnmatchedRequests implements Server for scopedServer.
+func (sc *scopedServer) UnmatchedRequests() []RecordedRequest {
+	return sc.filterHistory(func(entry RecordedRequest) bool {
+		return entry.scope == sc.id && !entry.Matched && !entry.Rejected
+	})
+}
+
+// scopeCounters counts the requests received by a scope.
+// Like the server's own counters, they include every request, even if the history is limited or sampled.
+type scopeCounters struct {
+	// How many requests matched each identifier.
+	counts map[string]int
+	// How many requests were received, matched or not.
+	total int
+	// Summarizes the requests handled by the scope, for Stats.
+	stats statsAccumulator
+}
+
+// add counts the request.
+func (c *scopeCounters) add(entry RecordedRequest) {
+	c.total++
+	if entry.Matched && entry.Identifier != "" {
+		c.counts[entry.Identifier]++
+	}
+}
+
+// scopeCountersOf retrieves the counters of the scope, creating them if needed.
+// s.mu must be held by the caller.
+func (s *server) scopeCountersOf(scope string) *scopeCounters {
+	c := s.scopeCounters[scope]
+	if c == nil {
+		c = &scopeCounters{counts: make(map[string]int)}
+		if s.scopeCounters == nil {
+			s.scopeCounters = make(map[string]*scopeCounters)
+		}
+		s.scopeCounters[scope] = c
+	}
+
+	return c
+}
+
+// Counts implements Server for scopedServer.
+func (sc *scopedServer) Counts() map[string]int {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	counts := make(map[string]int)
+	if c := sc.scopeCounters[sc.id]; c != nil {
+		maps.Copy(counts, c.counts)
+	}
+
+	return counts
+}
+
+// TotalCount implements Server for scopedServer.
+func (sc *scopedServer) TotalCount() int {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	if c := sc.scopeCounters[sc.id]; c != nil {
+		return c.total
+	}
+
+	return 0
+}
+
+// WriteHAR implements Server for scopedServer.
+func (sc *scopedServer) WriteHAR(w io.Writer) error {
+	return writeHAR(w, sc.Requests())
+}
+
+// WriteCassette implements Server for scopedServer.
+func (sc *scopedServer) WriteCassette(w io.Writer) error {
+	return writeCassette(w, sc.Requests())
+}
+
+// ExpectationsWereMet implements Server for scopedServer.
+// Only the mocks registered in the scope and the requests it received are checked.
+func (sc *scopedServer) ExpectationsWereMet() error {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	var unmet []string
+
+	for _, id := range sc.sortedIdentifiers() {
+		for _, reg := range sc.registrations[id] {
+			if reg.scope != sc.id {
+				continue
+			}
+
+			if msg := reg.unmetCalls(sc.server); msg != "" {
+				unmet = append(unmet, msg)
+			}
+		}
+	}
+
+	var unmatched []RecordedRequest
+	for _, entry := range sc.unmatched.list() {
+		if entry.scope == sc.id {
+			unmatched = append(unmatched, entry)
+		}
+	}
+	unmet = append(unmet, describeUnmatched(unmatched)...)
+
+	return unmetExpectations(unmet)
+}
+
+// AssertExpectations implements Server for scopedServer.
+func (sc *scopedServer) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+
+	return assertExpectations(t, sc.ExpectationsWereMet())
+}
+
+// AssertNumberOfCalls implements Server for scopedServer.
+func (sc *scopedServer) AssertNumberOfCalls(t testing.TB, identifier string, n int) bool {
+	t.Helper()
+
+	return assertNumberOfCalls(t, identifier, n, sc.Counts()[identifier])
+}
+
+// AssertCalledWith implements Server for scopedServer.
+func (sc *scopedServer) AssertCalledWith(t testing.TB, identifier string, matcher VariablesMatcher) bool {
+	t.Helper()
+
+	return assertCalledWith(t, identifier, matcher, sc.RequestsFor(identifier))
+}
+
+// AssertCalledWithHeader implements Server for scopedServer.
+func (sc *scopedServer) AssertCalledWithHeader(t testing.TB, identifier, name, value string) bool {
+	t.Helper()
+
+	return assertCalledWithHeader(t, identifier, name, value, sc.RequestsFor(identifier))
+}
+
+// AssertBackoff implements Server for scopedServer.
+func (sc *scopedServer) AssertBackoff(t testing.TB, identifier string, minGap time.Duration) bool {
+	t.Helper()
+
+	return assertBackoff(t, identifier, minGap, sc.Requests())
+}
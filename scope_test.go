@@ -0,0 +1,221 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScope checks that parallel subtests may share a server through scopes.
+func TestScope(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 0}}`),
+	})
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 0}}`),
+	})
+
+	t.Run("group", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+
+				sc := s.Scope(t)
+				sc.RegisterQuery("ListFoos", SimpleMockedRequest{
+					StringResponse: StringResponse(fmt.Sprintf(`{"ListFoos": {"foo": %d}}`, i)),
+				}, Required())
+
+				client := graphql.NewClient(sc.URL())
+				for j := 0; j < i; j++ {
+					var resp map[string]any
+					err := client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+					assert.NoError(t, err)
+					assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": float64(i)}}, resp)
+				}
+
+				// Mocks registered directly in the server are visible from the scope.
+				var resp map[string]any
+				err := client.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, map[string]any{"ListBars": map[string]any{"bar": 0.0}}, resp)
+
+				assert.Len(t, sc.Requests(), i+1)
+				sc.AssertNumberOfCalls(t, "ListFoos", i)
+				assert.Equal(t, map[string]int{"ListFoos": i, "ListBars": 1}, sc.Counts())
+				sc.AssertExpectations(t)
+			})
+		}
+	})
+
+	// Every scope was removed along with its subtest.
+	var resp map[string]any
+	err := graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 0.0}}, resp)
+	assert.Len(t, s.Requests(), 1)
+	assert.Len(t, s.RequestsFor("ListFoos"), 1)
+}
+
+// TestScopeIsolation checks that requests sent directly to the server don't match scoped mocks.
+func TestScopeIsolation(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	sc := s.Scope(t)
+	sc.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 1}}`),
+	})
+
+	err := graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), nil)
+	assert.Error(t, err)
+	assert.Empty(t, sc.Requests())
+	assert.NoError(t, sc.ExpectationsWereMet())
+	assert.Error(t, s.ExpectationsWereMet())
+}
+
+// TestAt checks that a single server may emulate multiple services at different paths.
+func TestAt(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	billing := s.At("/billing")
+	billing.RegisterQuery("invoices", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"invoices": [{"id": 1}]}`),
+	})
+	users := s.At("users/")
+	users.RegisterQuery("me", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"me": {"id": 2}}`),
+	})
+	usersAdmin := s.At("/users/admin")
+	usersAdmin.RegisterQuery("me", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"me": {"id": 3}}`),
+	})
+
+	assert.Equal(t, s.URL()+"/billing", billing.URL())
+	assert.Equal(t, s.URL()+"/users", users.URL())
+	assert.Equal(t, s, s.At("/"), "the root path isn't the server")
+
+	type testCase struct {
+		// The address where the query is sent.
+		url string
+		// The query sent.
+		query string
+		// The expected response, or nil if the query shouldn't match any mock.
+		expected map[string]any
+	}
+
+	testCases := []testCase{{
+		url:      billing.URL(),
+		query:    `query { invoices { id } }`,
+		expected: map[string]any{"invoices": []any{map[string]any{"id": 1.0}}},
+	}, {
+		url:      billing.URL() + "/graphql",
+		query:    `query { invoices { id } }`,
+		expected: map[string]any{"invoices": []any{map[string]any{"id": 1.0}}},
+	}, {
+		url:      users.URL(),
+		query:    `query { me { id } }`,
+		expected: map[string]any{"me": map[string]any{"id": 2.0}},
+	}, {
+		url:      usersAdmin.URL(),
+		query:    `query { me { id } }`,
+		expected: map[string]any{"me": map[string]any{"id": 3.0}},
+	}, {
+		url:   s.URL() + "/usersX",
+		query: `query { me { id } }`,
+	}, {
+		url:   users.URL(),
+		query: `query { invoices { id } }`,
+	}, {
+		url:   s.URL(),
+		query: `query { invoices { id } }`,
+	}}
+
+	for i, tc := range testCases {
+		var resp map[string]any
+		err := graphql.NewClient(tc.url).Run(context.Background(), graphql.NewRequest(tc.query), &resp)
+		if tc.expected == nil {
+			assert.Error(t, err, "test %d", i)
+		} else if assert.NoError(t, err, "test %d", i) {
+			assert.Equal(t, tc.expected, resp, "test %d", i)
+		}
+	}
+
+	assert.Len(t, billing.Requests(), 2)
+	assert.Len(t, s.At("/users").Requests(), 2, "the same path didn't share its history")
+
+	billing.Close()
+	assert.Empty(t, billing.Requests())
+	assert.Len(t, s.Requests(), len(testCases)-2)
+}
+
+// TestRootReset checks that removing the server's mocks keeps the mocks and requests of its scopes,
+// unless every mock is removed by ResetAll.
+func TestRootReset(t *testing.T) {
+	type testCase struct {
+		// Removes the server's mocks.
+		reset func(s Server)
+		// Whether the scopes' mocks and requests should be kept.
+		kept bool
+	}
+
+	testCases := []testCase{{
+		reset: func(s Server) { s.Unregister("ListFoos") },
+		kept:  true,
+	}, {
+		reset: func(s Server) { s.ClearQueries() },
+		kept:  true,
+	}, {
+		reset: func(s Server) { s.Reset() },
+		kept:  true,
+	}, {
+		reset: func(s Server) { s.ResetAll() },
+	}}
+
+	for i, tc := range testCases {
+		s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		s.RegisterQuery("ListFoos", SimpleMockedRequest{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 0}}`),
+		})
+
+		scopes := []Server{s.Scope(t), s.At("/scoped")}
+		for j, sc := range scopes {
+			sc.RegisterQuery("ListFoos", SimpleMockedRequest{
+				StringResponse: StringResponse(fmt.Sprintf(`{"ListFoos": {"foo": %d}}`, j+1)),
+			})
+
+			var resp map[string]any
+			err := graphql.NewClient(sc.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+			assert.NoError(t, err, "test %d: scope %d: failed to send the query", i, j)
+		}
+
+		tc.reset(s)
+
+		var resp map[string]any
+		err := graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+		assert.Error(t, err, "test %d: the server's mock wasn't removed", i)
+
+		for j, sc := range scopes {
+			resp = nil
+			err := graphql.NewClient(sc.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+			if tc.kept {
+				assert.NoError(t, err, "test %d: scope %d: the scope's mock was removed", i, j)
+				assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": float64(j + 1)}}, resp, "test %d: scope %d", i, j)
+				assert.Len(t, sc.Requests(), 2, "test %d: scope %d: the scope's requests were removed", i, j)
+			} else {
+				assert.Error(t, err, "test %d: scope %d: the scope's mock wasn't removed", i, j)
+				assert.Len(t, sc.Requests(), 1, "test %d: scope %d: the scope's requests weren't removed", i, j)
+			}
+		}
+
+		s.Close()
+	}
+}
@@ -1,15 +1,33 @@
 package goraphql_mock_server
 
 import (
-	"encoding/json"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	shurcool "github.com/shurcooL/graphql"
 )
 
 // Server manages a mock GraphQL server.
+//
+// Every method is safe for concurrent use, even while requests are being handled:
+// mocks may be registered, replaced or unregistered at any time,
+// affecting only the requests matched afterwards,
+// while requests already matched are answered by the mock they matched.
+// Since requests are handled in parallel, the methods of the mocks themselves
+// (e.g., CompareVariables and Response) may be called concurrently, and must be safe for that.
 type Server interface {
 	// Close closes the underlying http server.
 	Close()
@@ -21,6 +39,15 @@ type Server interface {
 	// if running with TLS enabled.
 	Client() *http.Client
 
+	// GraphQLClient returns a machinebox/graphql client that sends its requests to the server,
+	// through the HTTP client returned by Client (so it works even with TLS enabled).
+	// opts further configure the client (e.g., graphql.UseMultipartForm()).
+	GraphQLClient(opts ...graphql.ClientOption) *graphql.Client
+
+	// ShurcoolClient returns a shurcooL/graphql client that sends its requests to the server,
+	// through the HTTP client returned by Client.
+	ShurcoolClient() *shurcool.Client
+
 	// RegisterQuery registers a new query with a specific response.
 	//
 	// identifier is matched with a simple strings.Contains.
@@ -42,17 +69,398 @@ type Server interface {
 	// So, the most generic mocked request (e.g., one that embeds a KeyOnlyVariables
 	// and that matches the query only on the operation)
 	// should be registered last.
-	RegisterQuery(identifier string, mock MockedRequest)
+	//
+	// opts may further configure the registration (e.g., by marking it as Required()).
+	//
+	// It panics if the mock is invalid (e.g., if its StringResponse isn't a valid JSON object).
+	// Use RegisterQueryE to handle these failures.
+	RegisterQuery(identifier string, mock MockedRequest, opts ...RegisterOptions)
+
+	// RegisterMutation registers a new mutation with a specific response.
+	//
+	// identifier is matched exactly like in RegisterQuery,
+	// but only against requests for mutations.
+	RegisterMutation(identifier string, mock MockedRequest, opts ...RegisterOptions)
+
+	// RegisterQueryE works like RegisterQuery, but returns an error instead of panicking if the mock is invalid,
+	// in which case the mock isn't registered.
+	RegisterQueryE(identifier string, mock MockedRequest, opts ...RegisterOptions) error
+
+	// RegisterMutationE works like RegisterMutation, but returns an error instead of panicking if the mock is invalid,
+	// in which case the mock isn't registered.
+	RegisterMutationE(identifier string, mock MockedRequest, opts ...RegisterOptions) error
+
+	// RegisterQueryFunc registers a new query whose response is generated by fn, for each request.
+	// The mock matches the identifier exactly like in RegisterQuery, with any variables,
+	// and errors returned by fn are sent as GraphQL errors.
+	RegisterQueryFunc(identifier string, fn QueryFunc, opts ...RegisterOptions)
+
+	// RegisterMutationFunc works like RegisterQueryFunc, but only matches requests for mutations.
+	RegisterMutationFunc(identifier string, fn QueryFunc, opts ...RegisterOptions)
+
+	// Expect starts building a query mock for the identifier fluently
+	// (e.g., s.Expect("ListFoos").WithVariables(Subset{"num": 1}).Times(2).Reply(JSON(`...`))).
+	// The mock is registered when Reply is called; see Expectation.
+	Expect(identifier string) *Expectation
+
+	// SetDefault sets the mock that answers every request that doesn't match any other mock
+	// (e.g., with an empty data, or a "not implemented" error),
+	// instead of the error configured WithUnmatchedBehavior.
+	// The default mock matches any operation, regardless of its variables,
+	// and the requests it answers are still recorded as unmatched.
+	//
+	// opts may further configure the mock (e.g., by delaying it), and a nil mock removes the default.
+	// Like RegisterQuery, it panics if the mock is invalid.
+	SetDefault(mock MockedRequest, opts ...RegisterOptions)
+
+	// RegisterPersistedQuery registers a new mock matching requests for the persisted query with the ID
+	// (e.g., the SHA-256 hash sent in Apollo's persistedQuery extension, or Relay's doc_id),
+	// so clients that never send their queries may be tested.
+	// The ID is also the mock's identifier.
+	//
+	// Requests for persisted queries known to the server (e.g., loaded WithPersistedQueries)
+	// may also match mocks registered with RegisterQuery and RegisterMutation.
+	RegisterPersistedQuery(id string, mock MockedRequest, opts ...RegisterOptions)
+
+	// Unregister removes every mock registered with the identifier,
+	// be it a query or a mutation.
+	// On the server itself, the mocks registered in scopes (e.g., by Scope, At or Namespace) are kept.
+	Unregister(identifier string)
+
+	// ReplaceQuery replaces the mock registered with the identifier at the index
+	// (counting from 0, in the order they were registered, be it a query or a mutation),
+	// so a subtest may change a single mock of a baseline set.
+	// The registration's options (e.g., Times) and its calls are kept.
+	// It panics if there's no such mock, or if the new mock is invalid.
+	ReplaceQuery(identifier string, index int, mock MockedRequest)
+
+	// ReplaceNamed works like ReplaceQuery, but replaces every mock registered with the identifier
+	// that was given the name (by Named).
+	ReplaceNamed(identifier, name string, mock MockedRequest)
+
+	// ClearQueries removes every registered mock, be it a query or a mutation.
+	// The history of received requests is kept.
+	// On the server itself, the mocks registered in scopes (e.g., by Scope, At or Namespace) are kept.
+	ClearQueries()
+
+	// Reset restores the server to its initial state,
+	// removing every registered mock (including the default one), the history of received requests,
+	// the request counters and the declared call orders,
+	// so a long-lived server (e.g., started in TestMain) may be reused between tests.
+	// Options given to New are kept.
+	//
+	// On the server itself, the mocks and requests of scopes (e.g., created by Scope, At or Namespace) are kept,
+	// so tests using them in parallel aren't affected.
+	Reset()
+
+	// ResetAll works like Reset, but on the server itself it also removes the mocks and requests of every scope.
+	// On a scope, it works exactly like Reset.
+	ResetAll()
+
+	// RegisterDefinitions registers every mock definition.
+	// If any of them is invalid, none is registered.
+	RegisterDefinitions(defs ...MockDefinition) error
+
+	// LoadMocks registers every mock defined in the file at path.
+	// If path is a directory, every mock definition file in it
+	// (and in its subdirectories) is loaded.
+	//
+	// Files may be either JSON (".json") or YAML (".yaml" or ".yml"),
+	// and must contain either a single MockDefinition or a list of them.
+	LoadMocks(path string) error
+
+	// LoadMocksFS registers every mock defined in the files in fsys.
+	// See LoadMocks for the expected format.
+	LoadMocksFS(fsys fs.FS) error
+
+	// Snapshot captures every mock currently registered directly in the server (i.e., not in its scopes),
+	// so they may be registered in other servers (by calling Snapshot.New)
+	// or restored later (by calling Restore).
+	Snapshot() Snapshot
+
+	// Restore replaces every mock registered directly in the server by the ones in the snapshot,
+	// keeping the mocks registered in its scopes.
+	Restore(snap Snapshot)
+
+	// Requests returns every request received by the server, in the order they were received.
+	Requests() []RecordedRequest
+
+	// RequestsFor returns every request that matched a mock registered with the identifier,
+	// in the order they were received.
+	RequestsFor(identifier string) []RecordedRequest
+
+	// UnmatchedRequests returns every request that didn't match any registered mock,
+	// in the order they were received.
+	// This is usually the first thing to check when a client unexpectedly receives a 404.
+	UnmatchedRequests() []RecordedRequest
+
+	// ExpectationsWereMet checks that every mock was called as many times as expected
+	// (e.g., by registering it as Required() or with Times(n))
+	// and that every request received by the server matched a registered mock,
+	// returning an error describing every unmet expectation.
+	ExpectationsWereMet() error
+
+	// AssertExpectations fails the test if ExpectationsWereMet returns an error,
+	// returning whether every expectation was met.
+	AssertExpectations(t testing.TB) bool
+
+	// AssertNumberOfCalls fails the test if the mocks registered with the identifier
+	// weren't called exactly n times in total, returning whether the assertion succeeded.
+	AssertNumberOfCalls(t testing.TB, identifier string, n int) bool
+
+	// AssertCalledWith fails the test if no request that matched a mock registered with the identifier
+	// has variables matching matcher, returning whether the assertion succeeded.
+	//
+	// On failures, the differences between the expected variables
+	// and the ones in the closest call are reported,
+	// as long as matcher is one of the partial implementations of MockedRequest in this package.
+	AssertCalledWith(t testing.TB, identifier string, matcher VariablesMatcher) bool
+
+	// AssertCalledWithHeader fails the test if no request that matched a mock registered with the identifier
+	// had the header name with the value, returning whether the assertion succeeded.
+	AssertCalledWithHeader(t testing.TB, identifier, name, value string) bool
+
+	// AssertBackoff fails the test if any request that matched a mock registered with the identifier
+	// was retried (i.e., sent again with the same query and variables)
+	// less than minGap after the previous attempt, or if no request was ever retried,
+	// returning whether the assertion succeeded.
+	//
+	// To check other properties of the retries (e.g., that their gaps grow exponentially),
+	// inspect the Attempt and SincePrevious of the requests returned by RequestsFor.
+	AssertBackoff(t testing.TB, identifier string, minGap time.Duration) bool
+
+	// Counts returns how many requests matched the mocks registered with each identifier.
+	// Unlike AssertNumberOfCalls, counts are kept even if the mocks are later replaced.
+	Counts() map[string]int
+
+	// TotalCount returns how many requests were received by the server,
+	// including the ones that didn't match any mock.
+	TotalCount() int
+
+	// Stats summarizes the requests handled by the server:
+	// how many were received, how many bytes were served and how long they took to be handled,
+	// both overall and for each identifier.
+	// The server's summary includes every request, even if its history is limited WithMaxHistory.
+	Stats() Stats
+
+	// WriteHAR writes every request received by the server, along with its response,
+	// to w as a HAR (HTTP Archive) file,
+	// so the traffic may be inspected in browser dev tools or other HAR viewers.
+	WriteHAR(w io.Writer) error
+
+	// WriteCassette writes every request received by the server, along with its response,
+	// to w as a go-vcr cassette (in the version 2 format),
+	// so the traffic may be replayed by go-vcr or imported with ImportCassette.
+	WriteCassette(w io.Writer) error
+
+	// WriteRecordings writes every response recorded WithRecordingProxy to w
+	// as a JSON list of MockDefinition, in the order they were recorded,
+	// so the session may be replayed (e.g., by loading them with LoadMocks).
+	WriteRecordings(w io.Writer) error
+
+	// DumpMocks serializes every registered mock as a JSON list of MockDefinition,
+	// sorted by their identifier,
+	// so mocks configured in Go may be loaded by the standalone server
+	// (or attached to bug reports).
+	//
+	// Mocks whose variables are compared by custom types are described with MatchCustom,
+	// and must be adjusted manually before being loaded.
+	DumpMocks() ([]byte, error)
+
+	// Dump describes every registered mock in a human-readable form:
+	// its identifier, operation, name, how it matches variables and headers,
+	// how many times it was called and a summary of its response,
+	// so a failing test may print what the server knows (e.g., t.Log(s.Dump())).
+	// The server's String method returns the same description.
+	Dump() string
+
+	// InOrder declares that the mocks registered with the identifiers must be called in this order,
+	// so ExpectationsWereMet fails if any of them is called before the one preceding it.
+	//
+	// Only the first call to each identifier is ordered,
+	// so an identifier may be called again after the following ones.
+	// Multiple orders may be declared by calling InOrder multiple times.
+	InOrder(identifiers ...string)
+
+	// Restart simulates a server restart, dropping every active connection.
+	// Registered mocks and recorded requests are kept.
+	Restart()
+
+	// Pause simulates an outage, dropping every active connection
+	// and refusing new ones until the duration elapses.
+	// The server then resumes on the same address,
+	// with every registered mock and recorded request kept.
+	//
+	// Pause returns immediately, without waiting for the server to resume.
+	Pause(d time.Duration)
+
+	// Scope creates a view of the server whose mocks and history are isolated from other scopes,
+	// so a single server may be shared by parallel subtests.
+	// Every mock registered in the scope and every request it received are removed when the test ends.
+	//
+	// Clients must send their requests to the scope's URL.
+	// Requests sent to a scope may match the mocks registered in it
+	// (which take precedence) and the ones registered directly in the server,
+	// while requests sent directly to the server never match mocks registered in scopes.
+	//
+	// Methods that manage the server itself (e.g., Snapshot, Restore, InOrder and DumpMocks)
+	// affect the whole server, even if called on a scope.
+	Scope(t testing.TB) Server
+
+	// At creates a view of the server answering the requests sent to the path (e.g., "/billing"),
+	// or to any path under it, so a single server may emulate multiple GraphQL services
+	// that the application calls at different URLs.
+	//
+	// Like a scope, the path's mocks and history are isolated from other paths and scopes,
+	// and its mocks take precedence over the ones registered directly in the server,
+	// which are visible from every path.
+	// Calling At again with the same path returns a view of the same mocks,
+	// and calling it with "/" returns the server itself.
+	// Closing the view removes its mocks and history, but keeps the server running.
+	At(path string) Server
+
+	// Namespace creates a view of the server answering the requests that select the namespace
+	// through the header set WithNamespaceHeader, so parallel tests may share the server's URL.
+	//
+	// Like a scope, the namespace's mocks and history are isolated from other namespaces and scopes,
+	// and its mocks take precedence over the ones registered directly in the server.
+	// The view's clients (e.g., Client and GraphQLClient) send the header in every request.
+	// Calling Namespace again with the same name returns a view of the same mocks,
+	// and closing the view removes its mocks and history, but keeps the server running.
+	//
+	// It panics if the server wasn't started WithNamespaceHeader.
+	Namespace(name string) Server
+
+	// Clone starts a new mocked GraphQL server with the same mocks
+	// currently registered in this server.
+	// Only the mocks are copied, so opts must configure the new server as needed.
+	Clone(opts ...ServerOptions) Server
 }
 
 // server holds the mock GraphQL server, implementing Server for interacting with it.
 type server struct {
 	// The mocked GraphQL server.
 	server *httptest.Server
+	// Wraps the server's listener, so the server may be paused.
+	listener *pausableListener
+	// The multiplexer routing every request received by the server.
+	mux *http.ServeMux
 	// Whether the server should be started with TLS enabled.
 	useTLS bool
-	// Every registered query in this mocked server.
-	queries map[string][]MockedRequest
+	// The maximum accepted size, in bytes, of a request's body.
+	// If zero, the size isn't limited.
+	maxBodySize int64
+	// Guards the registered mocks and the history.
+	// Methods that only inspect them take a read lock, so they don't block each other.
+	mu sync.RWMutex
+	// Every mock registered in this mocked server, indexed by their identifier.
+	registrations map[string][]*registration
+	// Finds the identifiers that may match each request.
+	index identifierIndex
+	// The text of every persisted query known to the server, indexed by their ID.
+	persistedQueries map[string]string
+	// Every request received by this mocked server, or the last ones if set WithMaxHistory.
+	history requestRing
+	// Every request that didn't match any registered mock, or the last ones if set WithMaxHistory.
+	unmatched requestRing
+	// The probability of storing each matched request in the history.
+	// If zero, every request is stored.
+	historySampling float64
+	// If set, receives the requests evicted from the history.
+	spill *historySpill
+	// The sequence number of the last entry in the history.
+	seq uint64
+	// The last of each set of identical requests, to detect retries.
+	// It's cleared with the history, and bounded like it.
+	attempts attemptTracker
+	// How many requests matched each identifier.
+	counts map[string]int
+	// How many requests were received, matched or not.
+	total int
+	// Summarizes the requests handled by the server, for Stats.
+	stats statsAccumulator
+	// The counters of each scope, so they include every request even if the history is limited or sampled.
+	scopeCounters map[string]*scopeCounters
+	// Every order, declared by InOrder, in which mocks must be called.
+	orders [][]string
+	// Describes every call that didn't respect the declared orders.
+	orderViolations []string
+	// How requests that don't match any mock are answered.
+	unmatchedBehavior UnmatchedBehavior
+	// If set, handles every request that doesn't match any mock.
+	fallback http.Handler
+	// The mocks set by SetDefault, indexed by the scope that set them.
+	// Guarded by mu.
+	defaults map[string]*registration
+	// The schema implemented by the server, if set WithSchema.
+	schema *schema
+	// Whether the responses of the mocks are validated against the schema, as they're registered.
+	validateResponses bool
+	// Whether the queries of the requests are validated against the schema, before being matched.
+	validateQueries bool
+	// If set, answers every request that doesn't match any mock, taking precedence over fallback.
+	schemaMock *SchemaMock
+	// If set, forwards requests to a real GraphQL server, recording its responses.
+	// It takes precedence over schemaMock and fallback.
+	recorder *recordingProxy
+	// The size, in bytes, above which variables are only decoded when needed.
+	// If zero, every request's variables are decoded up front.
+	maxVariablesSize int
+	// Middlewares wrapping the handler for GraphQL requests.
+	middlewares []func(http.Handler) http.Handler
+	// Generates the random numbers used to simulate faults.
+	// Guarded by rngMu.
+	rng *rand.Rand
+	// Guards rng.
+	rngMu sync.Mutex
+	// Simulate faults (e.g., latency) in every GraphQL request, before it's handled.
+	faults []func(http.Handler) http.Handler
+	// Logs events about the server's operation.
+	logger *slog.Logger
+	// The header echoing the ID of every request, if any.
+	requestIDHeader string
+	// The header selecting the namespace of every request, if set WithNamespaceHeader.
+	namespaceHeader string
+	// How requests matching mocks registered with different identifiers are handled.
+	ambiguousMatch AmbiguousMatchBehavior
+	// How many scopes were created by Scope.
+	scopes int
+	// The paths of the views created by At, sorted from the longest to the shortest.
+	paths []string
+	// Describes every request that matched multiple mocks, with AmbiguousMatchFail.
+	ambiguities []string
+	// Whether a span should be started for every request.
+	tracing bool
+	// Whether the responses sent by mocks should have an Apollo tracing extension.
+	apolloTracing bool
+	// The maximum depth of the accepted queries. If zero, the depth isn't limited.
+	maxDepth int
+	// The maximum cost of the accepted queries. If zero, the cost isn't limited.
+	maxCost int
+	// Whether every attempt to match a request should be logged.
+	debug bool
+	// Whether NewT should assert the expectations when the test finishes.
+	assertOnCleanup bool
+	// Wraps every GraphQL response sent by the server, if set WithEnvelope.
+	envelope Envelope
+	// Functions called for every request received by the server.
+	callbacks []func(RecordedRequest)
+	// Closed when the server is closed, signaling background goroutines to stop.
+	done chan struct{}
+	// Ensures done is only closed once.
+	stopOnce sync.Once
+	// Called once the server is completely set up and started,
+	// so options may start background goroutines that use the whole server (e.g., its logger).
+	onStart []func()
+	// Tracks the background goroutines, so Close may wait for them.
+	wg sync.WaitGroup
+	// Every error found while applying the options, reported by NewE.
+	err error
+}
+
+// fail records an error found while applying an option, so the server fails to start.
+func (s *server) fail(err error) {
+	s.err = errors.Join(s.err, err)
 }
 
 // New starts a new mocked GraphQL server.
@@ -62,18 +470,71 @@ type server struct {
 // Mocked requests must be registered by calling RegisterQuery().
 //
 // Be sure to call Close() when done with the server!
+//
+// It panics if any option fails (e.g., if WithAddress can't listen on its address).
+// Use NewE to handle these failures.
 func New(opts ...ServerOptions) Server {
-	s := server{
-		queries: make(map[string][]MockedRequest),
+	s, err := NewE(opts...)
+	if err != nil {
+		panic(err.Error())
 	}
 
-	var mux http.ServeMux
-	mux.HandleFunc("/", s.handler)
+	return s
+}
+
+// NewE starts a new mocked GraphQL server, like New,
+// but returns an error if any option fails, instead of panicking.
+func NewE(opts ...ServerOptions) (Server, error) {
+	s := server{
+		mux:              http.NewServeMux(),
+		registrations:    make(map[string][]*registration),
+		persistedQueries: make(map[string]string),
+		counts:           make(map[string]int),
+		done:             make(chan struct{}),
+		rng:              rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
 
-	s.server = httptest.NewUnstartedServer(&mux)
+	s.server = httptest.NewUnstartedServer(s.mux)
 	for _, fn := range opts {
 		fn(&s)
 	}
+	s.validateRegistered()
+
+	if s.err != nil {
+		s.stop()
+		if s.spill != nil {
+			_ = s.spill.close()
+		}
+		if s.server.Listener != nil {
+			_ = s.server.Listener.Close()
+		}
+		return nil, s.err
+	}
+
+	if s.logger == nil && s.debug {
+		s.logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	} else if s.logger == nil {
+		s.logger = defaultLogger()
+	}
+
+	// Wrap the handler so the first middleware is the outermost one,
+	// so faults are simulated before any middleware,
+	// and so requests rejected by faults are still recorded.
+	var handler http.Handler = http.HandlerFunc(s.handler)
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	for i := len(s.faults) - 1; i >= 0; i-- {
+		handler = s.faults[i](handler)
+	}
+	handler = s.withHistory(handler)
+	if s.envelope != nil {
+		handler = withEnvelope(s.envelope, handler)
+	}
+	s.mux.Handle("/", handler)
+
+	s.listener = newPausableListener(s.server.Listener)
+	s.server.Listener = s.listener
 
 	if s.useTLS {
 		s.server.StartTLS()
@@ -81,12 +542,38 @@ func New(opts ...ServerOptions) Server {
 		s.server.Start()
 	}
 
-	return &s
+	for _, fn := range s.onStart {
+		fn()
+	}
+
+	return &s, nil
 }
 
 // Close implements Server for server.
+// It may be called multiple times (e.g., both deferred and by NewT's cleanup).
 func (s *server) Close() {
+	s.stop()
 	s.server.Close()
+	s.closeSpill()
+}
+
+// stop signals the background goroutines to stop, waiting for them.
+// It may be called multiple times.
+func (s *server) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+}
+
+// isClosed checks whether the server was closed.
+func (s *server) isClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
 }
 
 // Query implements Server for server.
@@ -100,43 +587,244 @@ func (s *server) Client() *http.Client {
 }
 
 // RegisterQuery implements Server for server.
-func (s *server) RegisterQuery(identifier string, mock MockedRequest) {
-	tmp := s.queries[identifier]
-	tmp = append(tmp, mock)
-	s.queries[identifier] = tmp
+func (s *server) RegisterQuery(identifier string, mock MockedRequest, opts ...RegisterOptions) {
+	s.register(identifier, OperationQuery, mock, opts)
+}
+
+// RegisterMutation implements Server for server.
+func (s *server) RegisterMutation(identifier string, mock MockedRequest, opts ...RegisterOptions) {
+	s.register(identifier, OperationMutation, mock, opts)
+}
+
+// RegisterQueryE implements Server for server.
+func (s *server) RegisterQueryE(identifier string, mock MockedRequest, opts ...RegisterOptions) error {
+	_, err := s.registerIn("", identifier, OperationQuery, mock, opts)
+	return err
+}
+
+// RegisterMutationE implements Server for server.
+func (s *server) RegisterMutationE(identifier string, mock MockedRequest, opts ...RegisterOptions) error {
+	_, err := s.registerIn("", identifier, OperationMutation, mock, opts)
+	return err
 }
 
 // handler decodes and processes a single GraphQL request.
 func (s *server) handler(w http.ResponseWriter, r *http.Request) {
-	var reqBody Request
-	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		respondError(w, http.StatusInternalServerError, fmt.Errorf("goraphql_mock_server: decode request body: %e", err), nil)
+	ex := exchangeOf(r)
+	received := ex.received
+
+	// Keep the raw body, so the request may be forwarded to a fallback handler.
+	// Unless a middleware replaced it, the body was already read by withHistory.
+	body, err := ex.body, ex.err
+	if r.Body != ex.replay {
+		body, err = readBody(r.Body, -1)
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.logger.Warn("goraphql_mock_server: request body too large", "limit", maxBytesErr.Limit)
+			respondError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("goraphql_mock_server: request body exceeds %d bytes", maxBytesErr.Limit), nil)
+			return
+		}
+
+		s.logger.Warn("goraphql_mock_server: failed to read request body", "error", err)
+		respondError(w, r, http.StatusInternalServerError, fmt.Errorf("goraphql_mock_server: read request body: %w", err), nil)
+		return
+	}
+
+	reqBody, err := decodeRequest(r.Header.Get("Content-Type"), body, s.maxVariablesSize)
+	if err != nil {
+		s.logger.Warn("goraphql_mock_server: failed to decode request body", "error", err)
+		respondError(w, r, http.StatusInternalServerError, fmt.Errorf("goraphql_mock_server: decode request body: %w", err), nil)
+		return
+	}
+
+	reqBody.header = r.Header
+	reqBody.ctx = r.Context()
+	reqBody.body = body
+	if !s.resolvePersistedQuery(w, r, body, &reqBody) {
+		return
+	}
+
+	s.logger.Debug("goraphql_mock_server: request received", "query", reqBody.Query, "variables", reqBody.Variables)
+	if !s.checkQuery(w, reqBody) {
 		return
 	}
+	if !s.checkComplexity(w, r, reqBody) {
+		return
+	}
+
+	scope := s.requestScope(r)
+	if s.debug {
+		s.logAttempts(reqBody, scope)
+	}
+
+	entry := newRecordedRequest(r, reqBody, received)
+	entry.body = body
+	entry.scope = scope
 
-	switch {
-	case strings.HasPrefix(strings.TrimSpace(reqBody.Query), "query"):
-		for id, mockedRequests := range s.queries {
-			if strings.Contains(reqBody.Query, id) {
-				for _, mockedRequest := range mockedRequests {
-					if s.handleQuery(mockedRequest, reqBody, w) {
-						return
-					}
-				}
+	if s.requestIDHeader != "" {
+		entry.RequestID = s.echoRequestID(w, r)
+	}
+	if s.tracing {
+		entry.Trace = startSpan(w, entry.Trace)
+	}
+
+	var reg *registration
+	var conflicts []string
+	if !s.forwardsAll() {
+		reg, conflicts = s.match(reqBody, scope)
+	}
+	ok := reg != nil
+	var candidates []mismatch
+	if ok {
+		s.logger.Debug("goraphql_mock_server: request matched", "identifier", reg.identifier, "mock", reg.logName())
+		entry.Identifier = reg.identifier
+		entry.Mock = reg.mock
+		entry.MockName = reg.name
+		if len(conflicts) > 0 {
+			s.logger.Warn("goraphql_mock_server: request matched multiple mocks", "query", compactQuery(reqBody.Query), "mocks", conflicts)
+		}
+	} else if s.forwardsAll() {
+		s.logger.Debug("goraphql_mock_server: request forwarded to the upstream")
+	} else if s.schemaMock != nil && s.recorder == nil {
+		s.logger.Debug("goraphql_mock_server: request answered by the schema mock")
+		entry.MockName = schemaMockName
+	} else {
+		candidates = s.mismatches(reqBody, scope)
+		s.logger.Warn("goraphql_mock_server: request didn't match any mock",
+			"query", compactQuery(reqBody.Query),
+			"variables", reqBody.Variables,
+			"candidates", describeMismatches(candidates),
+		)
+	}
+	if reqBody.lazy != nil {
+		// Variables too large to be decoded up front are only recorded if some matcher decoded them.
+		entry.Variables = reqBody.lazy.peek()
+	}
+	answeredBySchema := !ok && s.schemaMock != nil && s.recorder == nil
+	entry.Matched = ok || answeredBySchema
+	entry = s.record(entry)
+	ex.entry = &entry
+	for _, fn := range s.callbacks {
+		fn(entry)
+	}
+	if answeredBySchema {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		s.schemaMock.ServeHTTP(w, r)
+		return
+	} else if !ok {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		s.handleUnmatched(w, r, reqBody, scope, candidates)
+		return
+	} else if len(conflicts) > 0 && s.ambiguousMatch == AmbiguousMatchFail {
+		respondError(w, r, http.StatusInternalServerError, errAmbiguousMatch, ambiguityExtensions{Conflicts: conflicts})
+		return
+	}
+
+	r = withMockRequest(r, reqBody)
+	if s.apolloTracing {
+		r = withTracingRequest(r, reqBody, received)
+	}
+	reg.responseHandler(s).ServeHTTP(w, r)
+}
+
+// match searches for the registered mock that matches the request,
+// returning nil if none was found.
+// The returned registration is a snapshot, so it may be used without holding s.mu
+// even if the mock is replaced while the request is answered.
+// Unless ambiguous matches are ignored, it also describes every mock that matched the request,
+// if it matched mocks registered with different identifiers.
+// Only mocks visible from the scope are considered.
+//
+// The mocks are matched holding only a read lock, so the matchers (which may be slow, e.g., by decoding huge variables)
+// don't serialize concurrent requests. The call is then counted in a short write-locked section.
+func (s *server) match(req Request, scope string) (*registration, []string) {
+	op, ok := operationType(req.Query)
+	if !ok && req.persistedID == "" {
+		return nil, nil
+	}
+
+	for {
+		reg, conflicts := s.findMatch(req, op, scope)
+		if reg == nil {
+			return nil, nil
+		}
+
+		if matched := s.countCall(reg, req, conflicts); matched != nil {
+			return matched, conflicts
+		}
+		// The mock may no longer be called (e.g., a concurrent request made it expire), so match the request again.
+	}
+}
+
+// findMatch searches for the registered mock that matches the request, without counting the call.
+// Unless ambiguous matches are ignored, it also describes every mock that matched the request,
+// if it matched mocks registered with different identifiers.
+func (s *server) findMatch(req Request, op OperationType, scope string) (*registration, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	candidates := s.candidates(req.Query)
+	if s.ambiguousMatch == AmbiguousMatchIgnore {
+		for _, id := range candidates {
+			if reg := matchIdentifier(s.registrations[id], op, req, scope); reg != nil {
+				return reg, nil
 			}
 		}
+
+		return nil, nil
+	}
+
+	// Check every candidate identifier, in a well-defined order, so ambiguous matches may be detected.
+	var matched []*registration
+	for _, id := range candidates {
+		if reg := matchIdentifier(s.registrations[id], op, req, scope); reg != nil {
+			matched = append(matched, reg)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, nil
 	}
 
-	respondError(w, http.StatusNotFound, errors.New("goraphql_mock_server: mocked request not found"), nil)
+	var conflicts []string
+	if len(matched) > 1 {
+		for _, reg := range matched {
+			conflicts = append(conflicts, reg.describe(s))
+		}
+	}
+
+	return matched[0], conflicts
 }
 
-// handleQuery checks if the provided request matches the mocked request,
-// sending the mocked response and returning true if they match.
-func (s *server) handleQuery(mock MockedRequest, req Request, w http.ResponseWriter) bool {
-	if !mock.CompareVariables(req.Variables) {
-		return false
+// matchIdentifier searches for the first of the mocks registered for an identifier that matches the request.
+// s.mu must be held by the caller.
+func matchIdentifier(registrations []*registration, op OperationType, req Request, scope string) *registration {
+	for _, reg := range registrations {
+		if reg.visibleFrom(scope) && reg.matchesQuery(req, op) && reg.inTurn() && reg.compareVariables(req) && reg.matchesHeaders(req.header) {
+			return reg
+		}
 	}
 
-	respondResponse(w, http.StatusOK, mock.Response())
-	return true
+	return nil
+}
+
+// countCall counts the call to the mock matched by the request, returning a snapshot of its registration.
+// It returns nil if the mock may no longer be called (i.e., it was unregistered or it's no longer in turn)
+// since it was matched.
+func (s *server) countCall(reg *registration, req Request, conflicts []string) *registration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !slices.Contains(s.registrations[reg.identifier], reg) || !reg.inTurn() {
+		return nil
+	}
+
+	if len(conflicts) > 0 {
+		s.recordAmbiguity(req, conflicts)
+	}
+	s.checkOrder(reg.identifier)
+	reg.calls++
+	return reg.snapshot()
 }
@@ -1,13 +1,18 @@
 package goraphql_mock_server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/machinebox/graphql"
 	"github.com/stretchr/testify/assert"
@@ -193,21 +198,86 @@ func TestMockServerCustomAddress(t *testing.T) {
 	}
 }
 
+// TestNewE checks that options failing to configure the server are reported as errors,
+// and that New panics with them.
+func TestNewE(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	port := uint16(listener.Addr().(*net.TCPAddr).Port)
+
+	s, err := NewE(WithAddress("127.0.0.1", port, false), WithPassthrough("not a URL"))
+	assert.Nil(t, s)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "goraphql_mock_server: failed to listen on 127.0.0.1:")
+		assert.Contains(t, err.Error(), "goraphql_mock_server: invalid upstream URL 'not a URL'")
+	}
+
+	assert.Panics(t, func() {
+		New(WithAddress("127.0.0.1", port, false))
+	})
+}
+
+// TestCloseTwice checks that a server may be closed multiple times.
+func TestCloseTwice(t *testing.T) {
+	s := New(WithMockDirectory(t.TempDir(), time.Millisecond))
+	s.Pause(time.Hour)
+
+	assert.NotPanics(t, s.Close, "closing the server the first time panicked")
+	assert.NotPanics(t, s.Close, "closing the server again panicked")
+}
+
+// failingResponse implements a MockedRequest whose Response() panics.
+type failingResponse struct {
+	NoVariable
+}
+
+// Response implements MockedRequest for failingResponse.
+func (failingResponse) Response() any {
+	panic("failingResponse")
+}
+
+// TestMockServerResponseFailure checks that mocks that fail to generate their responses
+// are answered with a 500, instead of crashing the handler.
+func TestMockServerResponseFailure(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", failingResponse{})
+	s.RegisterQuery("ListBars", struct {
+		RawResponse
+		NoVariable
+	}{RawResponse: RawResponse{Payload: map[string]any{"ListBars": func() {}}}})
+
+	for i, query := range []string{`query { ListFoos { foo } }`, `query { ListBars { bar } }`} {
+		body, _ := json.Marshal(Request{Query: query})
+		resp, err := s.Client().Post(s.URL(), "application/json", bytes.NewReader(body))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		var res Response
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, "test %d", i)
+		if assert.Len(t, res.Errors, 1, "test %d", i) {
+			assert.Contains(t, res.Errors[0].Message, "goraphql_mock_server: failed to ", "test %d", i)
+		}
+	}
+}
+
 // TestTLSMockServer checks that it's possible to configure the server with TLS communication.
 func TestTLSMockServer(t *testing.T) {
-	// Suppress logs for this test,
-	// re-enabling it afterwards.
-	defer func(w io.Writer) {
-		log.SetOutput(w)
-	}(log.Writer())
-	log.SetOutput(io.Discard)
-
 	type DummyResponse struct {
 		StringResponse
 		NoVariable
 	}
 
-	s := New(WithTLS())
+	// Suppress the logs about failed TLS handshakes.
+	s := New(WithTLS(), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
 	defer s.Close()
 
 	s.RegisterQuery("ListFoos", DummyResponse{
@@ -265,3 +335,268 @@ func TestTLSMockServer(t *testing.T) {
 		}
 	}
 }
+
+// TestMockServerMaxBodySize checks that requests larger than the configured limit are rejected.
+func TestMockServerMaxBodySize(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		KeyOnlyVariables
+	}
+
+	s := New(WithMaxBodySize(256))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{
+			"ListFoos": {
+				"foo": 123
+			}
+		}`),
+		KeyOnlyVariables: KeyOnlyVariables{"foo"},
+	})
+
+	type testCase struct {
+		// The value sent in the request's variable.
+		foo string
+		// The expected HTTP status.
+		status int
+	}
+
+	testCases := []testCase{{
+		foo:    "small",
+		status: http.StatusOK,
+	}, {
+		foo:    strings.Repeat("big", 256),
+		status: http.StatusRequestEntityTooLarge,
+	}}
+
+	for _, tc := range testCases {
+		body, err := json.Marshal(Request{
+			Query: `query ($foo:String!) {
+				ListFoos(foo:$foo) {
+					foo
+				}
+			}`,
+			Variables: map[string]any{
+				"foo": tc.foo,
+			},
+		})
+		if !assert.NoError(t, err, "failed to encode the request") {
+			continue
+		}
+
+		resp, err := s.Client().Post(s.URL(), "application/json", bytes.NewReader(body))
+		if !assert.NoError(t, err, "failed to send the request") {
+			continue
+		}
+
+		var res Response
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+
+		assert.NoError(t, err, "failed to decode the response")
+		assert.Equal(t, tc.status, resp.StatusCode, "unexpected status for a %d bytes request", len(body))
+		if tc.status != http.StatusOK {
+			assert.Len(t, res.Errors, 1, "expected a GraphQL error")
+		}
+	}
+}
+
+// TestMockServerFormRequest checks that requests sent as form fields are decoded like JSON-encoded ones.
+func TestMockServerFormRequest(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", struct {
+		StringResponse
+		ExactVariables
+	}{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		ExactVariables: ExactVariables{Variables: map[string]any{"foo": "bar"}},
+	})
+
+	type testCase struct {
+		// The form sent in the request's body.
+		form url.Values
+		// The expected HTTP status.
+		status int
+	}
+
+	testCases := []testCase{{
+		form: url.Values{
+			"query":     {`query ($foo: String!) { ListFoos(foo: $foo) { foo } }`},
+			"variables": {`{"foo": "bar"}`},
+		},
+		status: http.StatusOK,
+	}, {
+		form: url.Values{
+			"query":     {`query ($foo: String!) { ListFoos(foo: $foo) { foo } }`},
+			"variables": {`{"foo": "baz"}`},
+		},
+		status: http.StatusNotFound,
+	}, {
+		form: url.Values{
+			"query":     {`query ($foo: String!) { ListFoos(foo: $foo) { foo } }`},
+			"variables": {`{"foo": `},
+		},
+		status: http.StatusInternalServerError,
+	}}
+
+	for i, tc := range testCases {
+		resp, err := s.Client().PostForm(s.URL(), tc.form)
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+		resp.Body.Close()
+
+		assert.Equal(t, tc.status, resp.StatusCode, "test %d", i)
+	}
+
+	if requests := s.RequestsFor("ListFoos"); assert.Len(t, requests, 1) {
+		assert.Equal(t, map[string]any{"foo": "bar"}, requests[0].Variables)
+	}
+}
+
+// TestMockServerConnectionSettings checks that the server's timeouts and keep-alives may be configured.
+func TestMockServerConnectionSettings(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	const query = `{"query": "query { ListFoos { foo } }"}`
+
+	t.Run("keep-alives", func(t *testing.T) {
+		for _, enabled := range []bool{true, false} {
+			s := New(WithKeepAlives(enabled))
+			defer s.Close()
+
+			s.RegisterQuery("ListFoos", DummyResponse{
+				StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+			})
+
+			resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(query))
+			if assert.NoError(t, err, "failed to send the request") {
+				resp.Body.Close()
+				assert.Equal(t, !enabled, resp.Close, "unexpected connection state with keep-alives set to %v", enabled)
+			}
+		}
+	})
+
+	t.Run("read timeout", func(t *testing.T) {
+		s := New(WithReadTimeout(50 * time.Millisecond))
+		defer s.Close()
+
+		conn, err := net.Dial("tcp", strings.TrimPrefix(s.URL(), "http://"))
+		if !assert.NoError(t, err, "failed to connect to the server") {
+			return
+		}
+		defer conn.Close()
+
+		// Send only part of the request, so the server times out while waiting for the rest.
+		_, err = conn.Write([]byte("POST / HTTP/1.1\r\nHost: localhost\r\n"))
+		assert.NoError(t, err, "failed to send the partial request")
+
+		err = conn.SetReadDeadline(time.Now().Add(time.Second))
+		assert.NoError(t, err, "failed to set the client's deadline")
+
+		// The server should close the connection once the timeout expires,
+		// without sending a response.
+		_, err = io.ReadAll(conn)
+		assert.NoError(t, err, "the connection wasn't closed by the server")
+	})
+}
+
+// TestMockServerRequestNotifications checks that callbacks and channels are notified of every request.
+func TestMockServerRequestNotifications(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	var callbackRequests []RecordedRequest
+	ch := make(chan RecordedRequest, 2)
+
+	s := New(
+		WithRequestCallback(func(req RecordedRequest) {
+			callbackRequests = append(callbackRequests, req)
+		}),
+		WithRequestChannel(ch),
+	)
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+	for _, query := range []string{"query { ListFoos { foo } }", "query { ListBars { bar } }"} {
+		var resp map[string]any
+		_ = client.Run(context.Background(), graphql.NewRequest(query), &resp)
+	}
+
+	if assert.Len(t, callbackRequests, 2, "unexpected number of notified requests") {
+		assert.True(t, callbackRequests[0].Matched, "the first request should have matched")
+		assert.False(t, callbackRequests[1].Matched, "the second request shouldn't have matched")
+	}
+
+	assert.Equal(t, "ListFoos", (<-ch).Identifier, "unexpected first request in the channel")
+	assert.False(t, (<-ch).Matched, "unexpected second request in the channel")
+}
+
+// TestMockServerMiddleware checks that middlewares wrap the handler in the expected order.
+func TestMockServerMiddleware(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	var calls []string
+
+	// tag creates a middleware that records its call.
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	// auth rejects requests without an authorization header.
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	s := New(WithMiddleware(tag("first"), tag("second")), WithMiddleware(auth))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+
+	var resp map[string]any
+	err := client.Run(context.Background(), graphql.NewRequest("query { ListFoos { foo } }"), &resp)
+	assert.Error(t, err, "the unauthorized request succeeded")
+
+	req := graphql.NewRequest("query { ListFoos { foo } }")
+	req.Header.Set("Authorization", "Bearer token")
+	err = client.Run(context.Background(), req, &resp)
+	assert.NoError(t, err, "the authorized request failed")
+
+	assert.Equal(t, []string{"first", "second", "first", "second"}, calls, "unexpected middleware calls")
+	requests := s.Requests()
+	if assert.Len(t, requests, 2, "unexpected requests") {
+		assert.True(t, requests[0].Rejected, "the unauthorized request reached the mock server")
+		assert.Equal(t, http.StatusUnauthorized, requests[0].Response.Status, "unexpected status for the unauthorized request")
+		assert.True(t, requests[1].Matched, "the authorized request didn't match the mock")
+	}
+}
@@ -0,0 +1,75 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	shurcool "github.com/shurcooL/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShurcoolCompatibility checks that shurcooL/graphql, which builds queries from structs, works against the mock server.
+func TestShurcoolCompatibility(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("viewer{login}", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"viewer": {"login": "foo"}}`),
+	})
+	s.RegisterQuery("user(id:$id)", struct {
+		StringResponse
+		ExactVariables
+	}{
+		StringResponse: StringResponse(`{"user": {"name": "bar", "email": null}}`),
+		ExactVariables: ExactVariables{Variables: map[string]any{"id": "1"}},
+	})
+	s.RegisterMutation("addStar(input:$input)", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"addStar": {"starrable": {"id": "123"}}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"input"},
+	})
+
+	client := s.ShurcoolClient()
+
+	// Queries without variables are sent in the shorthand form (e.g., "{viewer{login}}").
+	var viewer struct {
+		Viewer struct {
+			Login shurcool.String
+		}
+	}
+	if assert.NoError(t, client.Query(context.Background(), &viewer, nil)) {
+		assert.Equal(t, shurcool.String("foo"), viewer.Viewer.Login)
+	}
+
+	var user struct {
+		User struct {
+			Name  shurcool.String
+			Email *shurcool.String
+		} `graphql:"user(id:$id)"`
+	}
+	if assert.NoError(t, client.Query(context.Background(), &user, map[string]any{"id": shurcool.ID("1")})) {
+		assert.Equal(t, shurcool.String("bar"), user.User.Name)
+		assert.Nil(t, user.User.Email)
+	}
+
+	type AddStarInput struct {
+		StarrableID shurcool.ID `json:"starrableId"`
+	}
+	var addStar struct {
+		AddStar struct {
+			Starrable struct {
+				ID shurcool.ID
+			}
+		} `graphql:"addStar(input:$input)"`
+	}
+	if assert.NoError(t, client.Mutate(context.Background(), &addStar, map[string]any{"input": AddStarInput{StarrableID: "123"}})) {
+		assert.Equal(t, shurcool.ID("123"), addStar.AddStar.Starrable.ID)
+	}
+
+	// Requests that don't match any mock fail.
+	err := client.Query(context.Background(), &user, map[string]any{"id": shurcool.ID("2")})
+	assert.Error(t, err)
+
+	assert.Equal(t, map[string]int{"viewer{login}": 1, "user(id:$id)": 1, "addStar(input:$input)": 1}, s.Counts())
+}
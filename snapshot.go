@@ -0,0 +1,96 @@
+package goraphql_mock_server
+
+// Snapshot holds the mocks registered in a server at a given moment,
+// so they may be reused by other servers or restored later.
+//
+// Note that the mocks themselves aren't copied,
+// so stateful mocks are shared by every server created from the snapshot.
+type Snapshot struct {
+	// Every registered mock, indexed by their identifier.
+	registrations map[string][]*registration
+}
+
+// copyRegistrations deeply copies the registrations that aren't scoped (i.e., registered directly in the server),
+// resetting how many times each mock was called.
+// Registrations that refer to others (e.g., mocks replayed in order) refer to their copies.
+func copyRegistrations(src map[string][]*registration) map[string][]*registration {
+	dst := make(map[string][]*registration, len(src))
+	copies := make(map[*registration]*registration)
+	for id, registrations := range src {
+		var regs []*registration
+		for _, reg := range registrations {
+			if reg.scope != "" {
+				continue
+			}
+
+			cpy := *reg
+			cpy.calls = 0
+			regs = append(regs, &cpy)
+			copies[reg] = &cpy
+		}
+
+		if len(regs) > 0 {
+			dst[id] = regs
+		}
+	}
+
+	for _, cpy := range copies {
+		if previous, ok := copies[cpy.previous]; ok {
+			cpy.previous = previous
+		}
+	}
+
+	return dst
+}
+
+// New starts a new mocked GraphQL server with every mock in the snapshot registered.
+// See New for details.
+func (snap Snapshot) New(opts ...ServerOptions) Server {
+	return New(append([]ServerOptions{WithSnapshot(snap)}, opts...)...)
+}
+
+// WithSnapshot registers every mock in the snapshot in the server.
+func WithSnapshot(snap Snapshot) ServerOptions {
+	return func(s *server) {
+		s.Restore(snap)
+	}
+}
+
+// Snapshot implements Server for server.
+func (s *server) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return Snapshot{
+		registrations: copyRegistrations(s.registrations),
+	}
+}
+
+// Restore implements Server for server.
+func (s *server) Restore(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The mocks registered in scopes are kept, still taking precedence over the restored ones.
+	restored := copyRegistrations(snap.registrations)
+	for id, registrations := range s.registrations {
+		var scoped []*registration
+		for _, reg := range registrations {
+			if reg.scope != "" {
+				scoped = append(scoped, reg)
+			}
+		}
+
+		if len(scoped) > 0 {
+			restored[id] = append(scoped, restored[id]...)
+		}
+	}
+
+	s.registrations = restored
+	s.index.invalidate()
+}
+
+// Clone implements Server for server.
+func (s *server) Clone(opts ...ServerOptions) Server {
+	return s.Snapshot().New(opts...)
+}
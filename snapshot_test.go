@@ -0,0 +1,67 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSnapshot checks that snapshots may be used to create and reset servers.
+func TestSnapshot(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	// ok checks whether a query is successfully sent to the server.
+	ok := func(s Server, query string) bool {
+		var resp map[string]any
+		err := graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest(query), &resp)
+		return err == nil
+	}
+
+	base := New()
+	defer base.Close()
+
+	base.RegisterQuery("ListFoos", DummyResponse{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+	snap := base.Snapshot()
+
+	clone := base.Clone()
+	defer clone.Close()
+
+	fromSnap := snap.New()
+	defer fromSnap.Close()
+
+	// Changes to the original server must not affect the snapshot nor the clones.
+	base.RegisterQuery("ListBars", DummyResponse{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 456}}`),
+	})
+
+	for _, s := range []Server{clone, fromSnap} {
+		assert.True(t, ok(s, "query { ListFoos { foo } }"), "the snapshot's query failed")
+		assert.False(t, ok(s, "query { ListBars { bar } }"), "the query registered after the snapshot succeeded")
+	}
+	assert.True(t, ok(base, "query { ListBars { bar } }"), "the query registered after the snapshot failed")
+
+	base.Restore(snap)
+	assert.True(t, ok(base, "query { ListFoos { foo } }"), "the snapshot's query failed after restoring")
+	assert.False(t, ok(base, "query { ListBars { bar } }"), "the query registered after the snapshot wasn't removed")
+
+	// Scoped mocks are neither captured nor replaced.
+	scope := base.Scope(t)
+	scope.RegisterQuery("ListBazs", DummyResponse{
+		StringResponse: StringResponse(`{"ListBazs": {"baz": 789}}`),
+	})
+	scopedSnap := scope.Snapshot()
+	base.Restore(scopedSnap)
+	assert.True(t, ok(scope, "query { ListBazs { baz } }"), "the scoped query was removed by restoring")
+	assert.True(t, ok(scope, "query { ListFoos { foo } }"), "the scoped snapshot didn't capture the unscoped query")
+
+	fromScopedSnap := scopedSnap.New()
+	defer fromScopedSnap.Close()
+	assert.False(t, ok(fromScopedSnap, "query { ListBazs { baz } }"), "the snapshot captured the scoped query")
+}
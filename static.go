@@ -0,0 +1,82 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// staticResponse is the response of a mock that never changes, encoded once when the mock is registered,
+// so it isn't encoded again for every request.
+type staticResponse struct {
+	// The response's encoded data.
+	data json.RawMessage
+	// The whole body of the response, as sent by respond.
+	body []byte
+}
+
+// staticData retrieves the data sent by mocks whose response never changes:
+// a StringResponse (e.g., of a SimpleMockedRequest, or sent by a mock built by Match)
+// and mocks registered from definitions.
+// It returns false for any other mock, since its response may change between requests.
+func staticData(mock MockedRequest) (any, bool) {
+	var responder Responder = mock
+	if m, ok := mock.(*expectedMock); ok {
+		responder = m.responder
+	}
+
+	var str StringResponse
+	switch m := responder.(type) {
+	case definedMock:
+		return m.Payload, true
+	case SimpleMockedRequest:
+		str = m.StringResponse
+	case StringResponse:
+		str = m
+	default:
+		return nil, false
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(str), &data); err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// premarshal encodes the response of the mock once, if it never changes.
+// It must be called whenever the mock is set.
+func (r *registration) premarshal() {
+	r.static = nil
+
+	data, ok := staticData(r.mock)
+	if !ok {
+		return
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	body, err := json.Marshal(Response{Data: json.RawMessage(encoded)})
+	if err != nil {
+		return
+	}
+
+	r.static = &staticResponse{
+		data: encoded,
+		body: append(body, '\n'),
+	}
+}
+
+// respondStatic sends the pre-marshaled response of the mock, if it may be sent as is
+// (i.e., it's static and nothing is added to it), returning whether it was sent.
+func (r *registration) respondStatic(s *server, w http.ResponseWriter) bool {
+	if r.static == nil || r.fields != nil || s.envelope != nil || s.apolloTracing {
+		return false
+	}
+
+	writeJSON(w, http.StatusOK, r.static.body)
+	return true
+}
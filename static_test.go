@@ -0,0 +1,69 @@
+package goraphql_mock_server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStaticResponse checks that static responses are encoded once, exactly as they would be for every request.
+func TestStaticResponse(t *testing.T) {
+	type testCase struct {
+		// The registered mock.
+		mock MockedRequest
+		// Whether the mock's response should be encoded once.
+		static bool
+	}
+
+	testCases := []testCase{{
+		mock:   SimpleMockedRequest{StringResponse: StringResponse(`{"ListFoos": {"foo": 1.50, "bar": [1e3, "x"]}}`)},
+		static: true,
+	}, {
+		mock:   Match(AnyVariables{}).Respond(JSON(`{"ListFoos": {"foo": 123}}`)),
+		static: true,
+	}, {
+		mock:   definedMock{RawResponse: RawResponse{Payload: map[string]any{"ListFoos": nil}}, VariablesMatcher: AnyVariables{}},
+		static: true,
+	}, {
+		mock: struct {
+			RawResponse
+			AnyVariables
+		}{RawResponse: RawResponse{Payload: map[string]any{"ListFoos": nil}}},
+		static: false,
+	}, {
+		mock:   SimpleMockedRequest{},
+		static: false,
+	}}
+
+	for i, tc := range testCases {
+		reg := &registration{mock: tc.mock}
+		reg.premarshal()
+		if !assert.Equal(t, tc.static, reg.static != nil, "test %d", i) || !tc.static {
+			continue
+		}
+
+		rec := httptest.NewRecorder()
+		respond(rec, http.StatusOK, Response{Data: tc.mock.Response()})
+		assert.Equal(t, rec.Body.String(), string(reg.static.body), "test %d", i)
+	}
+
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", testCases[0].mock)
+
+	resp, err := http.Post(s.URL(), "application/json", strings.NewReader(`{"query": "query { ListFoos { foo } }"}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"data":{"ListFoos":{"bar":[1000,"x"],"foo":1.5}}}`+"\n", string(body))
+}
@@ -0,0 +1,255 @@
+package goraphql_mock_server
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Stats summarizes the requests handled by the server, so performance-oriented client tests
+// may report server-side numbers.
+type Stats struct {
+	// How many requests were received, matched, unmatched or rejected.
+	Requests int `json:"requests"`
+	// How many requests matched a registered mock.
+	Matched int `json:"matched"`
+	// How many requests didn't match any registered mock.
+	Unmatched int `json:"unmatched"`
+	// How many requests were rejected before being matched (e.g., by a simulated fault or rate limit).
+	Rejected int `json:"rejected"`
+	// How many bytes were sent in the bodies of the responses.
+	BytesServed int64 `json:"bytesServed"`
+	// How long the server took to handle the requests.
+	Latency LatencyStats `json:"latency"`
+	// How many requests were handled per second,
+	// from when the first request was received until the last response was sent.
+	Throughput float64 `json:"throughput"`
+	// The summary of the requests that matched the mocks registered with each identifier.
+	Identifiers map[string]IdentifierStats `json:"identifiers,omitempty"`
+}
+
+// IdentifierStats summarizes the requests that matched the mocks registered with an identifier.
+type IdentifierStats struct {
+	// How many requests matched the mocks.
+	Requests int `json:"requests"`
+	// How many bytes were sent in the bodies of the responses.
+	BytesServed int64 `json:"bytesServed"`
+	// How long the server took to handle the requests.
+	Latency LatencyStats `json:"latency"`
+}
+
+// LatencyStats summarizes how long the server took to handle requests,
+// from when each request was received until its response was completely sent.
+//
+// Percentiles are estimated from a histogram, within 12.5% of the actual latency,
+// so the summary's memory usage doesn't grow with the number of requests.
+type LatencyStats struct {
+	// The average latency.
+	Mean time.Duration `json:"mean"`
+	// The median latency.
+	P50 time.Duration `json:"p50"`
+	// The 95th percentile of the latency.
+	P95 time.Duration `json:"p95"`
+	// The 99th percentile of the latency.
+	P99 time.Duration `json:"p99"`
+	// The longest latency.
+	Max time.Duration `json:"max"`
+}
+
+// histogramPrecision is the number of bits, after the most significant one,
+// kept for each duration in a latencyHistogram.
+const histogramPrecision = 3
+
+// histogramSubBuckets is the number of buckets in a latencyHistogram for each power of two.
+const histogramSubBuckets = 1 << histogramPrecision
+
+// latencyHistogram counts durations in logarithmic buckets,
+// each spanning 1/histogramSubBuckets of a power of two.
+type latencyHistogram struct {
+	// How many durations were added.
+	count int
+	// The sum of every duration.
+	sum time.Duration
+	// The longest duration.
+	max time.Duration
+	// How many durations fell in each bucket.
+	buckets [histogramSubBuckets * (64 - histogramPrecision)]int
+}
+
+// bucketOf returns the index of the bucket of the duration.
+func bucketOf(d time.Duration) int {
+	v := uint64(max(d, 0))
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+
+	exp := bits.Len64(v) - 1 - histogramPrecision
+	mantissa := int(v>>exp) - histogramSubBuckets
+	return histogramSubBuckets*(exp+1) + mantissa
+}
+
+// bucketUpperBound returns the longest duration in the bucket.
+func bucketUpperBound(bucket int) time.Duration {
+	if bucket < histogramSubBuckets {
+		return time.Duration(bucket)
+	}
+
+	exp := bucket/histogramSubBuckets - 1
+	mantissa := uint64(bucket%histogramSubBuckets + histogramSubBuckets)
+	return time.Duration((mantissa+1)<<exp - 1)
+}
+
+// add counts the duration.
+func (h *latencyHistogram) add(d time.Duration) {
+	h.count++
+	h.sum += d
+	h.max = max(h.max, d)
+	h.buckets[bucketOf(d)]++
+}
+
+// percentile estimates the duration below which the fraction p (in (0, 1]) of the durations fell.
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(h.count)))
+
+	seen := 0
+	for bucket, n := range h.buckets {
+		seen += n
+		if seen >= rank {
+			return min(bucketUpperBound(bucket), h.max)
+		}
+	}
+
+	return h.max
+}
+
+// summary summarizes the counted durations.
+func (h *latencyHistogram) summary() LatencyStats {
+	if h.count == 0 {
+		return LatencyStats{}
+	}
+
+	return LatencyStats{
+		Mean: h.sum / time.Duration(h.count),
+		P50:  h.percentile(0.50),
+		P95:  h.percentile(0.95),
+		P99:  h.percentile(0.99),
+		Max:  h.max,
+	}
+}
+
+// identifierStats accumulates the summary of the requests that matched an identifier.
+type identifierStats struct {
+	// How many requests matched the identifier.
+	requests int
+	// How many bytes were sent in the bodies of the responses.
+	bytes int64
+	// How long the server took to handle the requests.
+	latency latencyHistogram
+}
+
+// statsAccumulator accumulates the summary of the requests handled by the server.
+type statsAccumulator struct {
+	// How many requests were handled.
+	requests int
+	// How many requests didn't match any registered mock.
+	unmatched int
+	// How many requests were rejected before being matched.
+	rejected int
+	// How many bytes were sent in the bodies of the responses.
+	bytes int64
+	// How long the server took to handle the requests.
+	latency latencyHistogram
+	// When the first request was received.
+	first time.Time
+	// When the last response was sent.
+	last time.Time
+	// The summary of the requests that matched each identifier.
+	identifiers map[string]*identifierStats
+}
+
+// add accumulates a handled request, whose response had size bytes and took latency to be sent.
+func (sa *statsAccumulator) add(entry RecordedRequest, size int, latency time.Duration) {
+	sa.requests++
+	sa.bytes += int64(size)
+	sa.latency.add(latency)
+
+	if sa.first.IsZero() || entry.Time.Before(sa.first) {
+		sa.first = entry.Time
+	}
+	if end := entry.Time.Add(latency); end.After(sa.last) {
+		sa.last = end
+	}
+
+	if entry.Rejected {
+		sa.rejected++
+		return
+	} else if !entry.Matched {
+		sa.unmatched++
+		return
+	} else if entry.Identifier == "" {
+		// Requests answered by the schema mock aren't summarized for any identifier.
+		return
+	}
+
+	if sa.identifiers == nil {
+		sa.identifiers = make(map[string]*identifierStats)
+	}
+	id := sa.identifiers[entry.Identifier]
+	if id == nil {
+		id = &identifierStats{}
+		sa.identifiers[entry.Identifier] = id
+	}
+	id.requests++
+	id.bytes += int64(size)
+	id.latency.add(latency)
+}
+
+// stats summarizes the accumulated requests.
+func (sa *statsAccumulator) stats() Stats {
+	stats := Stats{
+		Requests:    sa.requests,
+		Matched:     sa.requests - sa.unmatched - sa.rejected,
+		Unmatched:   sa.unmatched,
+		Rejected:    sa.rejected,
+		BytesServed: sa.bytes,
+		Latency:     sa.latency.summary(),
+	}
+
+	if elapsed := sa.last.Sub(sa.first); elapsed > 0 {
+		stats.Throughput = float64(sa.requests) / elapsed.Seconds()
+	}
+
+	if len(sa.identifiers) > 0 {
+		stats.Identifiers = make(map[string]IdentifierStats, len(sa.identifiers))
+		for name, id := range sa.identifiers {
+			stats.Identifiers[name] = IdentifierStats{
+				Requests:    id.requests,
+				BytesServed: id.bytes,
+				Latency:     id.latency.summary(),
+			}
+		}
+	}
+
+	return stats
+}
+
+// Stats implements Server for server.
+func (s *server) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.stats.stats()
+}
+
+// Stats implements Server for scopedServer.
+func (sc *scopedServer) Stats() Stats {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	if c := sc.scopeCounters[sc.id]; c != nil {
+		return c.stats.stats()
+	}
+
+	var empty statsAccumulator
+	return empty.stats()
+}
@@ -0,0 +1,99 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStats checks that the server summarizes the requests it handled,
+// both overall and for each identifier.
+func TestStats(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": [{"bar": 1}, {"bar": 2}]}`),
+	})
+
+	assert.Equal(t, Stats{}, s.Stats(), "the server summarized requests before receiving any")
+
+	client := graphql.NewClient(s.URL())
+	send := func(query string) {
+		var resp map[string]any
+		_ = client.Run(context.Background(), graphql.NewRequest(query), &resp)
+	}
+	for i := 0; i < 3; i++ {
+		send(`query { ListFoos { foo } }`)
+	}
+	send(`query { ListBars { bar } }`)
+	send(`query { ListBazs { baz } }`)
+
+	stats := s.Stats()
+	assert.Equal(t, 5, stats.Requests)
+	assert.Equal(t, 4, stats.Matched)
+	assert.Equal(t, 1, stats.Unmatched)
+	assert.Positive(t, stats.Throughput)
+
+	var bytes int64
+	for _, req := range s.Requests() {
+		bytes += int64(len(req.Response.Body))
+	}
+	assert.Equal(t, bytes, stats.BytesServed)
+
+	assert.Positive(t, stats.Latency.P50)
+	assert.LessOrEqual(t, stats.Latency.P50, stats.Latency.P95)
+	assert.LessOrEqual(t, stats.Latency.P95, stats.Latency.P99)
+	assert.LessOrEqual(t, stats.Latency.P99, stats.Latency.Max)
+
+	if assert.Len(t, stats.Identifiers, 2) {
+		assert.Equal(t, 3, stats.Identifiers["ListFoos"].Requests)
+		assert.Equal(t, int64(3*len(s.RequestsFor("ListFoos")[0].Response.Body)), stats.Identifiers["ListFoos"].BytesServed)
+		assert.Equal(t, 1, stats.Identifiers["ListBars"].Requests)
+		assert.Positive(t, stats.Identifiers["ListBars"].Latency.Max)
+	}
+
+	sc := s.Scope(t)
+	scopedClient := graphql.NewClient(sc.URL())
+	var resp map[string]any
+	err := scopedClient.Run(context.Background(), graphql.NewRequest(`query { ListBars { bar } }`), &resp)
+	assert.NoError(t, err)
+
+	scoped := sc.Stats()
+	assert.Equal(t, 1, scoped.Requests)
+	assert.Equal(t, 1, scoped.Identifiers["ListBars"].Requests)
+	assert.Equal(t, 6, s.Stats().Requests)
+
+	s.Reset()
+	assert.Equal(t, Stats{}, s.Stats(), "the summary wasn't reset")
+}
+
+// TestLatencyHistogram checks that the percentiles estimated by a latencyHistogram
+// are close to the actual ones.
+func TestLatencyHistogram(t *testing.T) {
+	var h latencyHistogram
+	for i := 1; i <= 1000; i++ {
+		h.add(time.Duration(i) * time.Microsecond)
+	}
+
+	summary := h.summary()
+	assert.InEpsilon(t, 500*time.Microsecond, summary.P50, 0.125)
+	assert.InEpsilon(t, 950*time.Microsecond, summary.P95, 0.125)
+	assert.InEpsilon(t, 990*time.Microsecond, summary.P99, 0.125)
+	assert.Equal(t, 1000*time.Microsecond, summary.Max)
+	assert.Equal(t, 500500*time.Nanosecond, summary.Mean)
+
+	var constant latencyHistogram
+	for i := 0; i < 10; i++ {
+		constant.add(3 * time.Millisecond)
+	}
+	assert.Equal(t, 3*time.Millisecond, constant.summary().P50, "a constant latency wasn't exact")
+}
@@ -0,0 +1,75 @@
+package goraphql_mock_server
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// NewT starts a new mocked GraphQL server bound to the test,
+// closing it automatically when the test finishes.
+// The server may still be closed explicitly (e.g., by a deferred Close).
+//
+// Every event logged by the server with at least slog.LevelWarn
+// (e.g., requests that don't match any registered mock
+// and errors from the underlying http.Server) is sent to t.Log.
+// If the server is configured WithDebug(), the match attempts are also logged.
+// This may be overridden by configuring the server WithLogger.
+// If the server is configured WithAssertExpectations(),
+// the expectations are also asserted when the test finishes.
+func NewT(t testing.TB, opts ...ServerOptions) Server {
+	t.Helper()
+
+	var level slog.LevelVar
+	level.Set(slog.LevelWarn)
+
+	handler := slog.NewTextHandler(testWriter{t: t}, &slog.HandlerOptions{
+		Level: &level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// The test's log already has enough context.
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	testOpts := []ServerOptions{WithLogger(slog.New(handler))}
+	testOpts = append(testOpts, opts...)
+	testOpts = append(testOpts, func(s *server) {
+		if s.debug {
+			level.Set(slog.LevelInfo)
+		}
+	})
+
+	srv := New(testOpts...)
+	t.Cleanup(func() {
+		srv.Close()
+
+		if s, ok := srv.(*server); ok && s.assertOnCleanup {
+			srv.AssertExpectations(t)
+		}
+	})
+
+	return srv
+}
+
+// WithAssertExpectations causes servers started by NewT
+// to call Server.AssertExpectations when the test finishes.
+// It doesn't have any effect on servers started by New.
+func WithAssertExpectations() ServerOptions {
+	return func(s *server) {
+		s.assertOnCleanup = true
+	}
+}
+
+// testWriter implements io.Writer, writing every message to the test's log.
+type testWriter struct {
+	t testing.TB
+}
+
+// Write implements io.Writer for testWriter.
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
@@ -0,0 +1,71 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewT checks that servers bound to a test are closed, log and assert their expectations.
+func TestNewT(t *testing.T) {
+	type DummyResponse struct {
+		StringResponse
+		NoVariable
+	}
+
+	type testCase struct {
+		// The options used to configure the server.
+		opts []ServerOptions
+		// Whether the test should fail when finished.
+		fail bool
+	}
+
+	testCases := []testCase{{
+		fail: false,
+	}, {
+		opts: []ServerOptions{WithAssertExpectations()},
+		fail: true,
+	}}
+
+	for _, tc := range testCases {
+		rec := &recorderT{TB: t}
+		s := NewT(rec, tc.opts...)
+
+		s.RegisterQuery("ListFoos", DummyResponse{
+			StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+		}, Required())
+
+		var resp map[string]any
+		err := graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest("query { ListBars { bar } }"), &resp)
+		assert.Error(t, err, "the unregistered query succeeded")
+
+		if assert.Len(t, rec.logs, 1, "unexpected number of logs") {
+			assert.True(t, strings.Contains(rec.logs[0], "ListBars"), "the unmatched request wasn't logged")
+		}
+
+		rec.finish()
+
+		_, err = s.Client().Get(s.URL())
+		assert.Error(t, err, "the server wasn't closed when the test finished")
+
+		if tc.fail {
+			assert.Len(t, rec.errors, 1, "the expectations weren't asserted")
+		} else {
+			assert.Empty(t, rec.errors, "the expectations were asserted")
+		}
+	}
+}
+
+// TestNewTClosed checks that servers bound to a test may also be closed explicitly,
+// as when the test defers Close.
+func TestNewTClosed(t *testing.T) {
+	rec := &recorderT{TB: t}
+	s := NewT(rec)
+	s.Close()
+
+	assert.NotPanics(t, rec.finish, "closing the server again when the test finished panicked")
+	assert.Empty(t, rec.errors, "the test failed")
+}
@@ -0,0 +1,164 @@
+package goraphql_mock_server
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+// Pool hands out mock servers to (possibly parallel) tests, reclaiming them when each test finishes,
+// so a test suite doesn't start a new server, listening on a new port, for every test.
+//
+// Servers handed out by Get are only used by a single test at a time,
+// and are reset (as by Server.Reset) before being handed out again.
+// Scopes handed out by Scope share a single server, isolated from each other as by Server.Scope.
+//
+// A Pool is safe for concurrent use.
+// It should be created once (e.g., in TestMain) and closed after every test finished.
+type Pool struct {
+	// The options used to start every server.
+	opts []ServerOptions
+	// Guards every field below.
+	mu sync.Mutex
+	// The servers that aren't used by any test.
+	idle []Server
+	// Every server started by the pool, so they may be closed.
+	servers []Server
+	// The server shared by the scopes handed out by Scope, started when first needed.
+	shared Server
+	// Whether the pool was closed.
+	closed bool
+}
+
+// NewPool creates a Pool, starting its servers with the options as they are needed.
+func NewPool(opts ...ServerOptions) *Pool {
+	return &Pool{opts: opts}
+}
+
+// Get hands out a server used only by the test, returning it to the pool when the test finishes.
+// If the server was started WithAssertExpectations, the expectations are asserted before that.
+// If the test closes the server, it's discarded instead.
+//
+// The test fails if a new server must be started, but it fails to start.
+func (p *Pool) Get(t testing.TB) Server {
+	t.Helper()
+
+	srv, err := p.get()
+	if err != nil {
+		t.Fatalf("goraphql_mock_server: failed to start a pooled server: %v", err)
+	}
+
+	t.Cleanup(func() {
+		s, ok := srv.(*server)
+		if ok && s.assertOnCleanup {
+			srv.AssertExpectations(t)
+		}
+		if ok && s.isClosed() {
+			// The test closed the server itself, so it can't be handed out again.
+			p.forget(srv)
+			return
+		}
+		srv.ResetAll()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if !p.closed {
+			p.idle = append(p.idle, srv)
+		}
+	})
+
+	return srv
+}
+
+// Scope hands out a scope, created by Server.Scope, of the server shared by every scope in the pool.
+// The scope is closed when the test finishes.
+//
+// The test fails if the shared server must be started, but it fails to start.
+func (p *Pool) Scope(t testing.TB) Server {
+	t.Helper()
+
+	shared, err := p.getShared()
+	if err != nil {
+		t.Fatalf("goraphql_mock_server: failed to start a pooled server: %v", err)
+	}
+
+	return shared.Scope(t)
+}
+
+// Close closes every server started by the pool.
+// Servers still used by some test are also closed.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	servers := p.servers
+	p.servers = nil
+	p.idle = nil
+	p.shared = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, srv := range servers {
+		srv.Close()
+	}
+}
+
+// get retrieves an idle server, starting a new one if there's none.
+func (p *Pool) get() (Server, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		panic("goraphql_mock_server: Get called on a closed Pool")
+	}
+
+	if n := len(p.idle); n > 0 {
+		srv := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		return srv, nil
+	}
+
+	return p.start()
+}
+
+// getShared retrieves the server shared by the scopes, starting it if needed.
+func (p *Pool) getShared() (Server, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		panic("goraphql_mock_server: Scope called on a closed Pool")
+	}
+
+	if p.shared == nil {
+		srv, err := p.start()
+		if err != nil {
+			return nil, err
+		}
+		p.shared = srv
+	}
+
+	return p.shared, nil
+}
+
+// start starts a new server, tracking it so it's closed with the pool.
+// p.mu must be held by the caller.
+func (p *Pool) start() (Server, error) {
+	srv, err := NewE(p.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.servers = append(p.servers, srv)
+
+	return srv, nil
+}
+
+// forget stops tracking a server started by the pool.
+func (p *Pool) forget(srv Server) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.servers = slices.DeleteFunc(p.servers, func(tracked Server) bool {
+		return tracked == srv
+	})
+}
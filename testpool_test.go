@@ -0,0 +1,103 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPool checks that a pool hands out isolated servers to parallel tests,
+// reusing them once the tests finish.
+func TestPool(t *testing.T) {
+	pool := NewPool(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer pool.Close()
+
+	var mu sync.Mutex
+	urls := make(map[string]bool)
+
+	for round := 0; round < 3; round++ {
+		t.Run(fmt.Sprintf("round %d", round), func(t *testing.T) {
+			for i := 0; i < 4; i++ {
+				t.Run(fmt.Sprint(i), func(t *testing.T) {
+					t.Parallel()
+
+					s := pool.Get(t)
+					assert.Zero(t, s.TotalCount(), "the server wasn't reset")
+
+					s.RegisterQuery("ListFoos", SimpleMockedRequest{
+						StringResponse: StringResponse(fmt.Sprintf(`{"ListFoos": {"foo": %d}}`, i)),
+					})
+
+					var resp map[string]any
+					err := graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+					assert.NoError(t, err)
+					assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": float64(i)}}, resp)
+
+					mu.Lock()
+					urls[s.URL()] = true
+					mu.Unlock()
+				})
+			}
+		})
+	}
+
+	assert.LessOrEqual(t, len(urls), 4, "the servers weren't reused")
+	assert.Len(t, pool.idle, len(urls), "the servers weren't returned to the pool")
+}
+
+// TestPoolScope checks that a pool hands out isolated scopes of a single server.
+func TestPoolScope(t *testing.T) {
+	pool := NewPool(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer pool.Close()
+
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+
+				sc := pool.Scope(t)
+				sc.RegisterQuery("ListFoos", SimpleMockedRequest{
+					StringResponse: StringResponse(fmt.Sprintf(`{"ListFoos": {"foo": %d}}`, i)),
+				})
+
+				var resp map[string]any
+				err := graphql.NewClient(sc.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": float64(i)}}, resp)
+				assert.Equal(t, 1, sc.TotalCount())
+			})
+		}
+	})
+
+	assert.Len(t, pool.servers, 1, "the scopes didn't share a server")
+	assert.Empty(t, pool.shared.Requests(), "the scopes' requests weren't removed")
+}
+
+// TestPoolClosedServer checks that servers closed by their tests aren't handed out again.
+func TestPoolClosedServer(t *testing.T) {
+	pool := NewPool(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	var closed Server
+	t.Run("close", func(t *testing.T) {
+		closed = pool.Get(t)
+		closed.Close()
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		s := pool.Get(t)
+		assert.NotSame(t, closed, s, "a closed server was handed out again")
+
+		var resp map[string]any
+		err := graphql.NewClient(s.URL()).Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), &resp)
+		assert.ErrorContains(t, err, "mocked request not found", "the server didn't answer the request")
+	})
+
+	assert.Len(t, pool.servers, 1, "the closed server was still tracked")
+	assert.NotPanics(t, pool.Close, "closing the pool panicked")
+}
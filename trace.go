@@ -0,0 +1,157 @@
+package goraphql_mock_server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TraceContext describes the W3C trace context propagated by a request,
+// so tests may check that the client propagates its telemetry.
+type TraceContext struct {
+	// The trace ID in the request's traceparent header.
+	TraceID string `json:"traceId,omitempty"`
+	// The ID of the client's span, in the request's traceparent header.
+	ParentID string `json:"parentId,omitempty"`
+	// The trace flags in the request's traceparent header.
+	Flags string `json:"flags,omitempty"`
+	// Whether the client sampled the trace.
+	Sampled bool `json:"sampled"`
+	// The request's tracestate header.
+	State string `json:"state,omitempty"`
+	// The entries in the request's baggage header.
+	Baggage map[string]string `json:"baggage,omitempty"`
+	// The ID of the span started by the server for the request, if WithTracing is used.
+	SpanID string `json:"spanId,omitempty"`
+}
+
+// WithTracing starts a span for every request received by the server,
+// as a child of the span propagated in the request's traceparent header, if any.
+// The span is recorded in the request's TraceContext
+// and sent back to the client in the traceresponse header.
+func WithTracing() ServerOptions {
+	return func(s *server) {
+		s.tracing = true
+	}
+}
+
+// parseTraceContext retrieves the trace context propagated in the request's headers.
+// It returns nil if the request didn't propagate any trace context.
+func parseTraceContext(header http.Header) *TraceContext {
+	var tc TraceContext
+	var found bool
+
+	if traceID, parentID, flags, ok := parseTraceparent(header.Get("traceparent")); ok {
+		tc.TraceID = traceID
+		tc.ParentID = parentID
+		tc.Flags = flags
+		tc.Sampled = flags[1]&1 == 1
+		tc.State = header.Get("tracestate")
+		found = true
+	}
+
+	if baggage := parseBaggage(header.Values("baggage")); len(baggage) > 0 {
+		tc.Baggage = baggage
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return &tc
+}
+
+// parseTraceparent parses a traceparent header,
+// as in "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01".
+func parseTraceparent(value string) (traceID, parentID, flags string, ok bool) {
+	fields := strings.Split(strings.TrimSpace(value), "-")
+	if len(fields) < 4 {
+		return "", "", "", false
+	}
+
+	version := fields[0]
+	traceID, parentID, flags = fields[1], fields[2], fields[3]
+
+	// Version 00 defines exactly four fields, but future versions may append more.
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(fields) != 4) {
+		return "", "", "", false
+	} else if !isHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return "", "", "", false
+	} else if !isHex(parentID, 16) || parentID == strings.Repeat("0", 16) {
+		return "", "", "", false
+	} else if !isHex(flags, 2) {
+		return "", "", "", false
+	}
+
+	return traceID, parentID, flags, true
+}
+
+// isHex checks whether s is a lowercase hexadecimal string with exactly n digits.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseBaggage parses the entries in the baggage headers (e.g., "userId=alice,isProduction=false"),
+// ignoring their properties.
+func parseBaggage(values []string) map[string]string {
+	baggage := make(map[string]string)
+
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member, _, _ = strings.Cut(member, ";")
+			key, val, ok := strings.Cut(member, "=")
+			if !ok {
+				continue
+			}
+
+			key = strings.TrimSpace(key)
+			val, err := url.PathUnescape(strings.TrimSpace(val))
+			if key == "" || err != nil {
+				continue
+			}
+
+			baggage[key] = val
+		}
+	}
+
+	return baggage
+}
+
+// startSpan starts the server's span for the request, as a child of the propagated trace context, if any,
+// and reports it to the client in the traceresponse header.
+func startSpan(w http.ResponseWriter, tc *TraceContext) *TraceContext {
+	if tc == nil {
+		tc = &TraceContext{}
+	}
+
+	if tc.TraceID == "" {
+		tc.TraceID = randomHex(16)
+		tc.Flags = "00"
+	}
+	tc.SpanID = randomHex(8)
+
+	w.Header().Set("traceresponse", "00-"+tc.TraceID+"-"+tc.SpanID+"-"+tc.Flags)
+	return tc
+}
+
+// randomHex generates n random bytes, encoded as a hexadecimal string.
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,120 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTraceContext checks that the trace context propagated by requests is recorded.
+func TestTraceContext(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+
+	type testCase struct {
+		// The headers sent along the request.
+		header map[string]string
+		// The expected trace context.
+		expected *TraceContext
+	}
+
+	testCases := []testCase{{
+		header: map[string]string{
+			"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			"tracestate":  "congo=t61rcWkgMzE",
+		},
+		expected: &TraceContext{
+			TraceID:  "0af7651916cd43dd8448eb211c80319c",
+			ParentID: "b7ad6b7169203331",
+			Flags:    "01",
+			Sampled:  true,
+			State:    "congo=t61rcWkgMzE",
+		},
+	}, {
+		header: map[string]string{
+			"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00",
+			"baggage":     "userId=alice, serverNode=DF%2028;prop=1",
+		},
+		expected: &TraceContext{
+			TraceID:  "0af7651916cd43dd8448eb211c80319c",
+			ParentID: "b7ad6b7169203331",
+			Flags:    "00",
+			Baggage: map[string]string{
+				"userId":     "alice",
+				"serverNode": "DF 28",
+			},
+		},
+	}, {
+		header: map[string]string{
+			"traceparent": "00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		},
+	}, {
+		header: map[string]string{
+			"traceparent": "not a trace",
+		},
+	}, {}}
+
+	for _, tc := range testCases {
+		req := graphql.NewRequest(`query { ListFoos { foo } }`)
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+
+		err := client.Run(context.Background(), req, nil)
+		assert.NoError(t, err)
+	}
+
+	requests := s.Requests()
+	if assert.Len(t, requests, len(testCases)) {
+		for i, tc := range testCases {
+			assert.Equal(t, tc.expected, requests[i].Trace, "test case %d", i)
+		}
+	}
+}
+
+// TestWithTracing checks that the server starts a span for every request.
+func TestWithTracing(t *testing.T) {
+	s := New(WithTracing())
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	client := graphql.NewClient(s.URL())
+
+	req := graphql.NewRequest(`query { ListFoos { foo } }`)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	err := client.Run(context.Background(), req, nil)
+	assert.NoError(t, err)
+
+	err = client.Run(context.Background(), graphql.NewRequest(`query { ListFoos { foo } }`), nil)
+	assert.NoError(t, err)
+
+	requests := s.Requests()
+	if !assert.Len(t, requests, 2) {
+		return
+	}
+
+	// The first span continues the client's trace.
+	trace := requests[0].Trace
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", trace.TraceID)
+	assert.Len(t, trace.SpanID, 16)
+	assert.Equal(t, "00-"+trace.TraceID+"-"+trace.SpanID+"-01", requests[0].Response.Header.Get("traceresponse"))
+
+	// The second span starts a new trace.
+	trace = requests[1].Trace
+	assert.Len(t, trace.TraceID, 32)
+	assert.Len(t, trace.SpanID, 16)
+	assert.Empty(t, trace.ParentID)
+	assert.True(t, strings.HasPrefix(requests[1].Response.Header.Get("traceresponse"), "00-"+trace.TraceID))
+}
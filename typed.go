@@ -0,0 +1,115 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Register registers a typed mock for the operation, matching the identifier like RegisterQuery.
+//
+// The request's variables are decoded (as JSON) into TVars and compared to vars,
+// so no VariableDecoder must be implemented.
+// Requests with variables that aren't in TVars don't match the mock,
+// while variables missing from the request are decoded as their zero value.
+// resp is encoded as JSON and sent as the response's data.
+func Register[TVars, TResp any](s Server, op OperationType, identifier string, vars TVars, resp TResp, opts ...RegisterOptions) {
+	registerTyped(s, op, identifier, &typedMock[TVars, TResp]{vars: vars, resp: resp}, opts)
+}
+
+// RegisterFunc registers a typed mock for the operation, matching the identifier like RegisterQuery,
+// whose response is generated by fn from the request's variables.
+//
+// The mock matches any request whose variables may be decoded (as JSON) into TVars.
+// fn's result is encoded as JSON and sent as the response's data.
+func RegisterFunc[TVars, TResp any](s Server, op OperationType, identifier string, fn func(TVars) TResp, opts ...RegisterOptions) {
+	registerTyped(s, op, identifier, &typedMock[TVars, TResp]{fn: fn}, opts)
+}
+
+// registerTyped registers the typed mock in s, as a query or as a mutation.
+func registerTyped(s Server, op OperationType, identifier string, mock MockedRequest, opts []RegisterOptions) {
+	switch op {
+	case OperationQuery:
+		s.RegisterQuery(identifier, mock, opts...)
+	case OperationMutation:
+		s.RegisterMutation(identifier, mock, opts...)
+	default:
+		panic(fmt.Sprintf("goraphql_mock_server: invalid operation type '%s'", op))
+	}
+}
+
+// typedMock implements MockedRequest for the mocks registered with Register and RegisterFunc.
+type typedMock[TVars, TResp any] struct {
+	// The expected variables. Ignored if fn is set.
+	vars TVars
+	// The response. Ignored if fn is set.
+	resp TResp
+	// Generates the response from the request's variables, if set.
+	fn func(TVars) TResp
+}
+
+// Response implements MockedRequest for typedMock.
+func (m *typedMock[TVars, TResp]) Response() any {
+	return m.resp
+}
+
+// CompareVariables implements MockedRequest for typedMock.
+func (m *typedMock[TVars, TResp]) CompareVariables(v map[string]any) bool {
+	got, ok := decodeVariables[TVars](v)
+	if !ok {
+		return false
+	}
+
+	return m.fn != nil || reflect.DeepEqual(m.vars, got)
+}
+
+// CompareRawVariables implements RawVariablesMatcher for typedMock,
+// so the request's variables are decoded directly into TVars.
+func (m *typedMock[TVars, TResp]) CompareRawVariables(raw json.RawMessage) bool {
+	got, ok := decodeRawVariables[TVars](raw)
+	if !ok {
+		return false
+	}
+
+	return m.fn != nil || reflect.DeepEqual(m.vars, got)
+}
+
+// respond implements requestMock for typedMock.
+func (m *typedMock[TVars, TResp]) respond(req Request) Response {
+	if m.fn == nil {
+		return Response{Data: m.resp}
+	}
+
+	// The variables were already decoded when the mock was matched.
+	vars, _ := decodeRawVariables[TVars](req.RawVariables())
+	return Response{Data: m.fn(vars)}
+}
+
+// decodeVariables decodes the request's variables into T,
+// failing if any variable isn't a field of T or doesn't have the field's type.
+func decodeVariables[T any](v map[string]any) (T, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		var zero T
+		return zero, false
+	}
+
+	return decodeRawVariables[T](data)
+}
+
+// decodeRawVariables decodes the request's encoded variables into T, like decodeVariables.
+func decodeRawVariables[T any](raw json.RawMessage) (T, bool) {
+	var decoded T
+	if len(raw) == 0 {
+		return decoded, true
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&decoded); err != nil {
+		return decoded, false
+	}
+
+	return decoded, true
+}
@@ -0,0 +1,92 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRegisterTyped checks that typed mocks match the variables decoded into their type.
+func TestRegisterTyped(t *testing.T) {
+	type userVars struct {
+		ID   string `json:"id"`
+		Full bool   `json:"full"`
+	}
+	type user struct {
+		ID   string `json:"id"`
+		Name string `json:"name,omitempty"`
+	}
+	type userResp struct {
+		User user `json:"user"`
+	}
+	type deleteVars struct {
+		ID string `json:"id"`
+	}
+
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	Register(s, OperationQuery, "user", userVars{ID: "1", Full: true}, userResp{User: user{ID: "1", Name: "Alice"}})
+	RegisterFunc(s, OperationQuery, "user", func(vars userVars) userResp {
+		return userResp{User: user{ID: vars.ID}}
+	})
+	RegisterFunc(s, OperationMutation, "deleteUser", func(vars deleteVars) map[string]bool {
+		return map[string]bool{"deleteUser": vars.ID != ""}
+	})
+
+	type testCase struct {
+		// The query sent to the server.
+		query string
+		// The request's variables.
+		vars map[string]any
+		// The expected response.
+		expected map[string]any
+		// Whether the request should fail to match any mock.
+		fail bool
+	}
+
+	testCases := []testCase{{
+		query:    `query ($id: ID!, $full: Boolean) { user(id: $id, full: $full) { id name } }`,
+		vars:     map[string]any{"id": "1", "full": true},
+		expected: map[string]any{"user": map[string]any{"id": "1", "name": "Alice"}},
+	}, {
+		query:    `query ($id: ID!) { user(id: $id) { id } }`,
+		vars:     map[string]any{"id": "2"},
+		expected: map[string]any{"user": map[string]any{"id": "2"}},
+	}, {
+		query: `query ($id: ID!, $extra: Int) { user(id: $id, extra: $extra) { id } }`,
+		vars:  map[string]any{"id": "2", "extra": 1},
+		fail:  true,
+	}, {
+		query: `query ($id: Int!) { user(id: $id) { id } }`,
+		vars:  map[string]any{"id": 2},
+		fail:  true,
+	}, {
+		query:    `mutation ($id: ID!) { deleteUser(id: $id) }`,
+		vars:     map[string]any{"id": "1"},
+		expected: map[string]any{"deleteUser": true},
+	}}
+
+	for i, tc := range testCases {
+		req := graphql.NewRequest(tc.query)
+		for k, v := range tc.vars {
+			req.Var(k, v)
+		}
+
+		var resp map[string]any
+		err := s.GraphQLClient().Run(context.Background(), req, &resp)
+		if tc.fail {
+			assert.Error(t, err, "test %d", i)
+		} else if assert.NoError(t, err, "test %d", i) {
+			assert.Equal(t, tc.expected, resp, "test %d", i)
+		}
+	}
+
+	assert.Panics(t, func() {
+		Register(s, OperationType("subscription"), "user", userVars{}, userResp{})
+	})
+}
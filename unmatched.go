@@ -0,0 +1,140 @@
+package goraphql_mock_server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// UnmatchedBehavior defines how the server answers requests that don't match any registered mock.
+type UnmatchedBehavior int
+
+const (
+	// UnmatchedNotFound answers with a 404 and a GraphQL error. This is the default.
+	UnmatchedNotFound UnmatchedBehavior = iota
+	// UnmatchedOK answers with a 200 and a spec-compliant GraphQL error (with a null data).
+	UnmatchedOK
+	// UnmatchedBadRequest answers with a 400 and a GraphQL error.
+	UnmatchedBadRequest
+	// UnmatchedPanic panics in the handler, aborting the connection without answering.
+	// This makes the failure as loud as possible, since the client sees a transport error.
+	UnmatchedPanic
+)
+
+// errNotFound is the error sent for requests that don't match any mock.
+var errNotFound = errors.New("goraphql_mock_server: mocked request not found")
+
+// WithUnmatchedBehavior configures how the server answers requests that don't match any registered mock.
+func WithUnmatchedBehavior(behavior UnmatchedBehavior) ServerOptions {
+	return func(s *server) {
+		s.unmatchedBehavior = behavior
+	}
+}
+
+// WithFallbackHandler delegates every request that doesn't match any registered mock to h.
+// The request is forwarded with its original body,
+// and it's still recorded as an unmatched request.
+//
+// This takes precedence over WithUnmatchedBehavior, but not over a mock set by Server.SetDefault.
+func WithFallbackHandler(h http.Handler) ServerOptions {
+	return func(s *server) {
+		s.fallback = h
+	}
+}
+
+// SetDefault implements Server for server.
+func (s *server) SetDefault(mock MockedRequest, opts ...RegisterOptions) {
+	s.setDefaultIn("", mock, opts)
+}
+
+// SetDefault implements Server for scopedServer.
+func (sc *scopedServer) SetDefault(mock MockedRequest, opts ...RegisterOptions) {
+	sc.setDefaultIn(sc.id, mock, opts)
+}
+
+// setDefaultIn sets the default mock of the scope, removing it if mock is nil.
+// Like RegisterQuery, it panics if the mock is invalid.
+func (s *server) setDefaultIn(scope string, mock MockedRequest, opts []RegisterOptions) {
+	if mock != nil {
+		// The default mock answers any operation, but its response must at least fit a query.
+		if err := s.validateRegistration("default", OperationQuery, mock); err != nil {
+			panic(err.Error())
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if mock == nil {
+		delete(s.defaults, scope)
+		return
+	}
+
+	reg := &registration{
+		mock:  mock,
+		scope: scope,
+		name:  "default",
+	}
+	if err := reg.apply(opts); err != nil {
+		panic(err.Error())
+	}
+	reg.premarshal()
+
+	if s.defaults == nil {
+		s.defaults = make(map[string]*registration)
+	}
+	s.defaults[scope] = reg
+}
+
+// defaultMock retrieves a snapshot of the default mock visible from the scope, if any.
+// The scope's own default takes precedence over the server's.
+func (s *server) defaultMock(scope string) *registration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reg, ok := s.defaults[scope]
+	if !ok {
+		reg = s.defaults[""]
+	}
+	if reg == nil {
+		return nil
+	}
+
+	return reg.snapshot()
+}
+
+// handleUnmatched answers a request, from the scope, that didn't match any registered mock.
+// candidates explain why each mock registered for the request's operation didn't match,
+// and are sent in the error's extensions.
+func (s *server) handleUnmatched(w http.ResponseWriter, r *http.Request, req Request, scope string, candidates []mismatch) {
+	if s.recorder != nil {
+		s.recorder.record(w, r, req)
+		return
+	} else if reg := s.defaultMock(scope); reg != nil {
+		reg.responseHandler(s).ServeHTTP(w, withMockRequest(r, req))
+		return
+	} else if s.fallback != nil {
+		s.fallback.ServeHTTP(w, r)
+		return
+	}
+
+	var extensions any
+	if len(candidates) > 0 {
+		extensions = mismatchExtensions{Candidates: candidates}
+	}
+
+	switch s.unmatchedBehavior {
+	case UnmatchedOK:
+		respondError(w, r, http.StatusOK, errNotFound, extensions)
+	case UnmatchedBadRequest:
+		respondError(w, r, http.StatusBadRequest, errNotFound, extensions)
+	case UnmatchedPanic:
+		msg := fmt.Sprintf("goraphql_mock_server: request '%s' with variables %v didn't match any mock", compactQuery(req.Query), req.Variables)
+		for _, line := range describeMismatches(candidates) {
+			msg += "\n\t- " + line
+		}
+		panic(msg)
+	default:
+		respondError(w, r, http.StatusNotFound, errNotFound, extensions)
+	}
+}
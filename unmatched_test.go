@@ -0,0 +1,220 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUnmatchedBehavior checks each of the ways unmatched requests may be answered.
+func TestUnmatchedBehavior(t *testing.T) {
+	// Suppress logs for this test (as the panic gets logged),
+	// re-enabling it afterwards.
+	defer func(w io.Writer) {
+		log.SetOutput(w)
+	}(log.Writer())
+	log.SetOutput(io.Discard)
+
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write(body)
+	})
+
+	type testCase struct {
+		// The options used to configure the server.
+		opts []ServerOptions
+		// The expected HTTP status, or zero if the request should fail.
+		status int
+		// Whether a GraphQL error is expected in the response.
+		graphqlErr bool
+	}
+
+	testCases := []testCase{{
+		status:     http.StatusNotFound,
+		graphqlErr: true,
+	}, {
+		opts:       []ServerOptions{WithUnmatchedBehavior(UnmatchedOK)},
+		status:     http.StatusOK,
+		graphqlErr: true,
+	}, {
+		opts:       []ServerOptions{WithUnmatchedBehavior(UnmatchedBadRequest)},
+		status:     http.StatusBadRequest,
+		graphqlErr: true,
+	}, {
+		opts: []ServerOptions{WithUnmatchedBehavior(UnmatchedPanic)},
+	}, {
+		opts:   []ServerOptions{WithUnmatchedBehavior(UnmatchedBadRequest), WithFallbackHandler(fallback)},
+		status: http.StatusTeapot,
+	}}
+
+	const query = `{"query": "query { ListFoos { foo } }"}`
+
+	for i, tc := range testCases {
+		s := New(tc.opts...)
+
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(query))
+		if tc.status == 0 {
+			assert.Error(t, err, "test %d: the request should have failed", i)
+		} else if assert.NoError(t, err, "test %d: failed to send the request", i) {
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.NoError(t, err, "test %d: failed to read the response", i)
+			assert.Equal(t, tc.status, resp.StatusCode, "test %d: unexpected status", i)
+
+			if tc.graphqlErr {
+				var res map[string]any
+				err = json.Unmarshal(body, &res)
+				if assert.NoError(t, err, "test %d: failed to decode the response", i) {
+					assert.Contains(t, res, "data", "test %d: the response doesn't have data", i)
+					assert.Nil(t, res["data"], "test %d: the response has non-null data", i)
+					assert.NotEmpty(t, res["errors"], "test %d: the response doesn't have errors", i)
+				}
+			} else {
+				assert.Equal(t, query, string(body), "test %d: the fallback didn't receive the original body", i)
+			}
+		}
+
+		unmatched := s.UnmatchedRequests()
+		if assert.Len(t, unmatched, 1, "test %d: the request wasn't recorded as unmatched", i) && tc.status != 0 {
+			if assert.NotNil(t, unmatched[0].Response, "test %d: the response wasn't recorded", i) {
+				assert.Equal(t, tc.status, unmatched[0].Response.Status, "test %d: unexpected recorded status", i)
+			}
+		}
+		s.Close()
+	}
+}
+
+// TestMismatchDiagnostics checks that unmatched requests are answered
+// explaining why each candidate mock didn't match.
+func TestMismatchDiagnostics(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"ListFoos": {"foo": 123}}`),
+		KeyOnlyVariables: KeyOnlyVariables{"num", "page"},
+	})
+	s.RegisterQuery("ListFoos", struct {
+		StringResponse
+		ExactVariables
+	}{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 456}}`),
+		ExactVariables: ExactVariables{Variables: map[string]any{"num": 1.0}},
+	})
+	s.RegisterMutation("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 789}}`),
+	})
+	s.RegisterQuery("ListBars", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListBars": {"bar": 123}}`),
+	})
+
+	const query = `{"query": "query { ListFoos { foo } }", "variables": {"num": 2, "size": 10}}`
+
+	resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(query))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Errors []struct {
+			Extensions struct {
+				Candidates []struct {
+					Mock      string         `json:"mock"`
+					Variables map[string]any `json:"variables"`
+				} `json:"candidates"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	if !assert.Len(t, res.Errors, 1) {
+		return
+	}
+
+	candidates := res.Errors[0].Extensions.Candidates
+	if assert.Len(t, candidates, 2) {
+		assert.Contains(t, candidates[0].Mock, "query mock #0 for 'ListFoos'")
+		assert.Equal(t, map[string]any{
+			"missing":    []any{"page"},
+			"unexpected": []any{"size"},
+		}, candidates[0].Variables)
+
+		assert.Contains(t, candidates[1].Mock, "query mock #1 for 'ListFoos'")
+		assert.Equal(t, map[string]any{
+			"unexpected": []any{"size"},
+			"values": []any{map[string]any{
+				"variable": "num",
+				"expected": 1.0,
+				"got":      2.0,
+			}},
+		}, candidates[1].Variables)
+	}
+}
+
+// TestSetDefault checks that the default mock answers the requests that don't match any other mock.
+func TestSetDefault(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	s.RegisterQuery("ListFoos", SimpleMockedRequest{
+		StringResponse: StringResponse(`{"ListFoos": {"foo": 123}}`),
+	})
+
+	post := func(url, query string) (int, string) {
+		resp, err := s.Client().Post(url, "application/json", strings.NewReader(query))
+		if !assert.NoError(t, err) {
+			return 0, ""
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := post(s.URL(), `{"query": "query { ListBars { bar } }"}`)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	s.SetDefault(struct {
+		RawResponse
+		NoVariable
+	}{RawResponse: RawResponse{Payload: map[string]any{}}})
+	status, body := post(s.URL(), `{"query": "mutation { CreateBar { id } }", "variables": {"id": 1}}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"data": {}}`, body)
+
+	// Registered mocks still take precedence.
+	_, body = post(s.URL(), `{"query": "query { ListFoos { foo } }"}`)
+	assert.JSONEq(t, `{"data": {"ListFoos": {"foo": 123}}}`, body)
+
+	// Scopes may override the default.
+	sc := s.Scope(t)
+	sc.SetDefault(SimpleMockedRequest{StringResponse: StringResponse(`{"scoped": true}`)})
+	_, body = post(sc.URL(), `{"query": "query { ListBars { bar } }"}`)
+	assert.JSONEq(t, `{"data": {"scoped": true}}`, body)
+	_, body = post(s.URL(), `{"query": "query { ListBars { bar } }"}`)
+	assert.JSONEq(t, `{"data": {}}`, body)
+
+	assert.Len(t, s.UnmatchedRequests(), 4)
+
+	// Invalid defaults are rejected, keeping the previous one.
+	assert.PanicsWithValue(t, "goraphql_mock_server: the StringResponse of 'default' isn't a valid JSON object: unexpected end of JSON input", func() {
+		s.SetDefault(SimpleMockedRequest{StringResponse: `{"a": `})
+	})
+	_, body = post(s.URL(), `{"query": "query { ListBars { bar } }"}`)
+	assert.JSONEq(t, `{"data": {}}`, body)
+
+	s.SetDefault(nil)
+	status, _ = post(s.URL(), `{"query": "query { ListBars { bar } }"}`)
+	assert.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,198 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// uplinkSchema is the subset of Apollo Uplink's schema queried by routers,
+// to fetch their supergraph SDL and their entitlements.
+const uplinkSchema = `
+type Query {
+	routerConfig(ref: String!, apiKey: String!, ifAfterId: ID): RouterConfigResponse!
+	routerEntitlements(ref: String!, apiKey: String!, ifAfterId: ID, unlessId: ID): RouterEntitlementsResponse!
+}
+
+union RouterConfigResponse = RouterConfigResult | Unchanged | FetchError
+union RouterEntitlementsResponse = RouterEntitlementsResult | Unchanged | FetchError
+
+type RouterConfigResult {
+	id: ID!
+	supergraphSDL: String!
+	minDelaySeconds: Float!
+	messages: [Message!]!
+}
+
+type RouterEntitlementsResult {
+	id: ID!
+	minDelaySeconds: Float!
+	entitlement: RouterEntitlement
+}
+
+type RouterEntitlement {
+	jwt: String!
+}
+
+type Unchanged {
+	id: ID!
+	minDelaySeconds: Float!
+}
+
+type FetchError {
+	code: FetchErrorCode!
+	message: String!
+}
+
+type Message {
+	level: MessageLevel!
+	body: String!
+}
+
+enum MessageLevel { ERROR WARN INFO }
+
+enum FetchErrorCode { AUTHENTICATION_FAILED ACCESS_DENIED UNKNOWN_REF RETRY_LATER NOT_IMPLEMENTED_ON_THIS_INSTANCE }
+`
+
+// Uplink stubs the Apollo Uplink endpoint polled by Apollo Router (and Gateway) for its supergraph SDL,
+// so a router under test may fetch its schema from the mock instead of GraphOS.
+// The router must be started with APOLLO_UPLINK_ENDPOINTS set to the uplink's URL,
+// along with any APOLLO_KEY and APOLLO_GRAPH_REF (which aren't checked).
+//
+// Every poll is answered with the current supergraph SDL,
+// or with "Unchanged" if the router already has it.
+// Routers are never entitled to enterprise features.
+type Uplink struct {
+	// Answers the uplink queries.
+	mock *SchemaMock
+	// Serves the uplink, if started by NewUplink.
+	server *httptest.Server
+	// Guards every field below.
+	mu sync.Mutex
+	// The current supergraph SDL.
+	supergraph string
+	// Identifies the current supergraph SDL, changing whenever it's set.
+	version int
+	// How long routers should wait before polling again.
+	minDelay time.Duration
+	// How many times the supergraph SDL was polled.
+	polls int
+}
+
+// NewUplink starts serving the Apollo Uplink endpoint, with the supergraph SDL (composed, e.g., by rover).
+//
+// Be sure to call Close() when done with the uplink!
+func NewUplink(supergraph string) *Uplink {
+	u := newUplink(supergraph)
+	u.server = httptest.NewServer(u)
+
+	return u
+}
+
+// newUplink creates an Uplink with the supergraph SDL, without serving it.
+func newUplink(supergraph string) *Uplink {
+	mock, err := NewSchemaMock(uplinkSchema)
+	if err != nil {
+		panic(fmt.Sprintf("goraphql_mock_server: invalid uplink schema: %v", err))
+	}
+
+	u := &Uplink{
+		mock:       mock,
+		supergraph: supergraph,
+		version:    1,
+		minDelay:   time.Second,
+	}
+	mock.Resolve("Query.routerConfig", u.routerConfig)
+	mock.Resolve("Query.routerEntitlements", u.routerEntitlements)
+
+	return u
+}
+
+// URL returns the address to be set in the router's APOLLO_UPLINK_ENDPOINTS.
+func (u *Uplink) URL() string {
+	return u.server.URL
+}
+
+// Close stops serving the uplink.
+func (u *Uplink) Close() {
+	u.server.Close()
+}
+
+// SetSupergraph replaces the supergraph SDL, so routers pick it up on their next poll
+// (e.g., to test hot reloads of the schema).
+func (u *Uplink) SetSupergraph(supergraph string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.supergraph = supergraph
+	u.version++
+}
+
+// SetMinDelay sets how long routers should wait between polls. It defaults to one second.
+//
+// Note that routers may enforce a longer interval between polls.
+func (u *Uplink) SetMinDelay(d time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.minDelay = d
+}
+
+// Polls returns how many times the supergraph SDL was polled, whether it changed or not.
+func (u *Uplink) Polls() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.polls
+}
+
+// ServeHTTP implements http.Handler for Uplink.
+func (u *Uplink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("goraphql_mock_server: the uplink only accepts POST requests"), nil)
+		return
+	}
+
+	u.mock.ServeHTTP(w, r)
+}
+
+// routerConfig resolves the router's poll for the supergraph SDL.
+func (u *Uplink) routerConfig(parent any, args map[string]any) any {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.polls++
+
+	id := strconv.Itoa(u.version)
+	if args["ifAfterId"] == id {
+		return map[string]any{
+			"__typename":      "Unchanged",
+			"id":              id,
+			"minDelaySeconds": u.minDelay.Seconds(),
+		}
+	}
+
+	return map[string]any{
+		"__typename":      "RouterConfigResult",
+		"id":              id,
+		"supergraphSDL":   u.supergraph,
+		"minDelaySeconds": u.minDelay.Seconds(),
+		"messages":        []any{},
+	}
+}
+
+// routerEntitlements resolves the router's poll for its license, which is never granted.
+func (u *Uplink) routerEntitlements(parent any, args map[string]any) any {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return map[string]any{
+		"__typename":      "RouterEntitlementsResult",
+		"id":              "1",
+		"minDelaySeconds": u.minDelay.Seconds(),
+		"entitlement":     nil,
+	}
+}
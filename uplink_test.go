@@ -0,0 +1,82 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// uplinkQuery is the query sent by Apollo Router to poll for its supergraph SDL.
+const uplinkQuery = `query SupergraphSdlQuery($apiKey: String!, $graph_ref: String!, $ifAfterId: ID) {
+	routerConfig(ref: $graph_ref, apiKey: $apiKey, ifAfterId: $ifAfterId) {
+		__typename
+		... on RouterConfigResult { id supergraphSdl: supergraphSDL minDelaySeconds }
+		... on Unchanged { id minDelaySeconds }
+		... on FetchError { code message }
+	}
+}`
+
+// pollUplink polls the uplink at the address for the supergraph SDL, as a router would.
+func pollUplink(t *testing.T, url string, ifAfterId any) map[string]any {
+	body, err := json.Marshal(map[string]any{
+		"query":         uplinkQuery,
+		"operationName": "SupergraphSdlQuery",
+		"variables":     map[string]any{"apiKey": "key", "graph_ref": "graph@current", "ifAfterId": ifAfterId},
+	})
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(string(body)))
+	if !assert.NoError(t, err, "failed to poll the uplink") {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Data struct {
+			RouterConfig map[string]any `json:"routerConfig"`
+		} `json:"data"`
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+
+	return res.Data.RouterConfig
+}
+
+// TestUplink checks that routers polling the uplink receive the current supergraph SDL.
+func TestUplink(t *testing.T) {
+	u := NewUplink("schema { query: Query } type Query { foo: Int }")
+	defer u.Close()
+
+	assert.Equal(t, map[string]any{
+		"__typename":      "RouterConfigResult",
+		"id":              "1",
+		"supergraphSdl":   "schema { query: Query } type Query { foo: Int }",
+		"minDelaySeconds": 1.0,
+	}, pollUplink(t, u.URL(), nil), "unexpected first poll")
+
+	assert.Equal(t, map[string]any{
+		"__typename":      "Unchanged",
+		"id":              "1",
+		"minDelaySeconds": 1.0,
+	}, pollUplink(t, u.URL(), "1"), "the unchanged supergraph was sent")
+
+	u.SetSupergraph("schema { query: Query } type Query { bar: Int }")
+	config := pollUplink(t, u.URL(), "1")
+	assert.Equal(t, "2", config["id"], "the supergraph's ID didn't change")
+	assert.Equal(t, "schema { query: Query } type Query { bar: Int }", config["supergraphSdl"], "the new supergraph wasn't sent")
+	assert.Equal(t, 3, u.Polls())
+}
+
+// TestFederationUplink checks that the supergraph of a Federation may be polled through its uplink.
+func TestFederationUplink(t *testing.T) {
+	f := NewFederation(`enum join__Graph { USERS @join__graph(name: "users", url: "http://localhost:4001") }`, []Subgraph{{Name: "users"}})
+	defer f.Close()
+
+	config := pollUplink(t, f.SupergraphURL(), nil)
+	assert.Equal(t, f.Supergraph(), config["supergraphSdl"], "unexpected supergraph")
+}
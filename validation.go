@@ -0,0 +1,867 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithSchema sets the schema (in SDL), split across the sources, implemented by the mocked server.
+// By itself, the schema doesn't change how requests are answered;
+// it's used by the options that validate the mocks (e.g., WithResponseValidation).
+// The server fails to start if the schema is invalid.
+func WithSchema(sources ...string) ServerOptions {
+	sch, err := parseSchema(sources...)
+
+	return func(s *server) {
+		if err != nil {
+			s.fail(fmt.Errorf("goraphql_mock_server: parse schema: %w", err))
+			return
+		}
+
+		s.schema = sch
+	}
+}
+
+// WithSchemaFiles sets the schema (in SDL) split across the files (e.g., the schema files of a gqlgen project),
+// like WithSchema. The server fails to start if any file can't be read or if the schema is invalid.
+func WithSchemaFiles(paths ...string) ServerOptions {
+	sources := make([]string, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return func(s *server) {
+				s.fail(fmt.Errorf("goraphql_mock_server: read schema: %w", err))
+			}
+		}
+
+		sources = append(sources, string(data))
+	}
+
+	return WithSchema(sources...)
+}
+
+// WithResponseValidation validates the response of every mock against the schema set WithSchema,
+// as the mock is registered, so fixtures that drifted from the schema fail the test
+// instead of being decoded into garbage by the client.
+//
+// The response's data is checked for fields that the schema doesn't define,
+// null values for non-null fields, values of the wrong type and unknown enum values.
+// Since fixtures only have the selected fields, missing fields aren't reported.
+// Aliased fields are reported as unknown fields, since the query isn't known at registration.
+//
+// Registering a mock whose response doesn't match the schema fails
+// (i.e., RegisterQueryE and RegisterMutationE return an error, and the other methods panic).
+// Only static responses (a StringResponse, JSON or a mock definition) are validated,
+// since the others may change between requests.
+// Without a schema, this option doesn't have any effect.
+func WithResponseValidation() ServerOptions {
+	return func(s *server) {
+		s.validateResponses = true
+	}
+}
+
+// validateRegistration checks whether the mock's StringResponse is a valid JSON object,
+// so the failure is reported with the identifier when the mock is registered, instead of when it's requested,
+// and whether the response of the mock matches the schema, if the server validates the responses.
+func (s *server) validateRegistration(identifier string, op OperationType, mock MockedRequest) error {
+	// An empty StringResponse is left unset by mocks that never send it (e.g., that always fail).
+	if res, ok := findStringResponse(mock); ok && res != "" {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(res), &data); err != nil {
+			return fmt.Errorf("goraphql_mock_server: the StringResponse of '%s' isn't a valid JSON object: %w", identifier, err)
+		}
+	}
+
+	if s.schema == nil || !s.validateResponses {
+		return nil
+	}
+
+	// Responses that may change between requests (e.g., generated by a function) aren't validated,
+	// since calling the mock would run its side effects before any request is received.
+	data, ok := staticData(mock)
+	if !ok {
+		return nil
+	}
+
+	if problems := s.schema.validateResponse(op, data); len(problems) > 0 {
+		return fmt.Errorf("goraphql_mock_server: the response of '%s' doesn't match the schema: %s", identifier, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validateRegistered checks every mock registered by the options (e.g., WithMockDirectory and WithReplay),
+// once every option was applied, so they're checked against the schema regardless of the order of the options.
+func (s *server) validateRegistered() {
+	identifiers := make([]string, 0, len(s.registrations))
+	for identifier := range s.registrations {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	for _, identifier := range identifiers {
+		for _, reg := range s.registrations[identifier] {
+			if err := s.validateRegistration(identifier, reg.operation, reg.mock); err != nil {
+				s.fail(err)
+			}
+		}
+	}
+}
+
+// findStringResponse retrieves the StringResponse that generates the mock's response, if any:
+// either the mock itself, the responder of a mock built by Match or Expect,
+// or a StringResponse embedded in the mock.
+func findStringResponse(mock any) (StringResponse, bool) {
+	switch mock := mock.(type) {
+	case StringResponse:
+		return mock, true
+	case *expectedMock:
+		return findStringResponse(mock.responder)
+	}
+
+	v := reflect.ValueOf(mock)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous && field.Type == reflect.TypeOf(StringResponse("")) {
+			return v.Field(i).Interface().(StringResponse), true
+		}
+	}
+
+	return "", false
+}
+
+// validateResponse checks the data of a response to an operation of the type,
+// describing every problem found.
+func (sch *schema) validateResponse(op OperationType, data any) []string {
+	if data == nil {
+		return nil
+	}
+
+	root := sch.rootType(string(op))
+	if root == nil {
+		return []string{fmt.Sprintf("the schema doesn't define a %s type", op)}
+	}
+
+	// Normalize the response, so it's validated exactly as it would be sent.
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to encode the response: %v", err)}
+	}
+
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return []string{fmt.Sprintf("failed to decode the response: %v", err)}
+	}
+
+	v := &responseValidator{schema: sch}
+	v.object(root, decoded, nil)
+	return v.problems
+}
+
+// responseValidator checks a response's data against a schema.
+type responseValidator struct {
+	schema *schema
+	// Describes every problem found.
+	problems []string
+}
+
+// fail reports a problem with the value at the path.
+func (v *responseValidator) fail(path []string, format string, args ...any) {
+	where := "data"
+	if len(path) > 0 {
+		where = strings.Join(path, ".")
+	}
+
+	v.problems = append(v.problems, where+": "+fmt.Sprintf(format, args...))
+}
+
+// value checks a value of the type.
+func (v *responseValidator) value(typ *typeRef, val any, path []string) {
+	if val == nil {
+		if typ.nonNull {
+			v.fail(path, "null for non-null type %s", typ)
+		}
+		return
+	}
+
+	if typ.elem != nil {
+		items, ok := val.([]any)
+		if !ok {
+			v.fail(path, "expected a list of type %s, got %s", typ, jsonKind(val))
+			return
+		}
+
+		for i, item := range items {
+			v.value(typ.elem, item, append(slices.Clip(path), fmt.Sprint(i)))
+		}
+		return
+	}
+
+	t, ok := v.schema.types[typ.name]
+	if !ok {
+		v.fail(path, "unknown type %s", typ.name)
+		return
+	}
+
+	switch t.kind {
+	case kindScalar:
+		if !validScalar(t.name, val) {
+			v.fail(path, "%s isn't a valid %s", jsonKind(val), t.name)
+		}
+	case kindEnum:
+		if s, ok := val.(string); !ok || !slices.Contains(t.enumValues, s) {
+			v.fail(path, "%s isn't a valid %s", jsonKind(val), t.name)
+		}
+	case kindObject, kindInterface, kindUnion:
+		v.object(t, val, path)
+	}
+}
+
+// object checks a value of an object or abstract type.
+// Values of abstract types are checked against the type in their __typename, if any,
+// or otherwise against the first possible type that defines each field.
+func (v *responseValidator) object(t *schemaType, val any, path []string) {
+	obj, ok := val.(map[string]any)
+	if !ok {
+		v.fail(path, "expected an object of type %s, got %s", t.name, jsonKind(val))
+		return
+	}
+
+	possible := v.schema.possibleTypes(t)
+	if name, ok := obj["__typename"]; ok {
+		concrete, ok := v.schema.types[fmt.Sprint(name)]
+		if !ok || !slices.Contains(possible, concrete) {
+			v.fail(append(slices.Clip(path), "__typename"), "%v isn't a possible type of %s", name, t.name)
+			return
+		}
+		possible = []*schemaType{concrete}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if key == "__typename" {
+			continue
+		}
+
+		fieldPath := append(slices.Clip(path), key)
+
+		var def *schemaField
+		for _, p := range possible {
+			if def = p.field(key); def != nil {
+				break
+			}
+		}
+		if def == nil {
+			v.fail(fieldPath, "the type %s doesn't define the field", t.name)
+			continue
+		}
+
+		v.value(def.typ, obj[key], fieldPath)
+	}
+}
+
+// validScalar checks whether the (decoded) value is valid for the scalar.
+// Custom scalars accept any value.
+func validScalar(name string, val any) bool {
+	switch name {
+	case "Int":
+		n, ok := val.(float64)
+		return ok && n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32
+	case "Float":
+		_, ok := val.(float64)
+		return ok
+	case "String":
+		_, ok := val.(string)
+		return ok
+	case "Boolean":
+		_, ok := val.(bool)
+		return ok
+	case "ID":
+		switch val := val.(type) {
+		case string:
+			return true
+		case float64:
+			return val == math.Trunc(val)
+		default:
+			return false
+		}
+	default:
+		return true
+	}
+}
+
+// jsonKind describes the kind of the (decoded) JSON value.
+func jsonKind(val any) string {
+	switch val := val.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "a list"
+	case string:
+		return fmt.Sprintf("%q", val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// Codes identifying, in their extensions, the errors for queries rejected WithQueryValidation,
+// as sent by Apollo Server.
+const (
+	// queryParseCode identifies queries that aren't valid GraphQL documents.
+	queryParseCode = "GRAPHQL_PARSE_FAILED"
+	// queryValidationCode identifies queries that aren't valid in the schema.
+	queryValidationCode = "GRAPHQL_VALIDATION_FAILED"
+)
+
+// WithQueryValidation validates the query of every request against the schema set WithSchema, before matching it,
+// so queries the real server would reject are caught by the mock.
+//
+// Invalid queries are rejected with a 400 (Bad Request) and an error for every problem found,
+// with the locations of the problem in the query and its code
+// (GRAPHQL_PARSE_FAILED or GRAPHQL_VALIDATION_FAILED) in its extensions, like Apollo Server does.
+// They're recorded as Rejected, and aren't matched against any mock.
+//
+// The query is checked for fields and arguments that the schema doesn't define,
+// missing required arguments, selections on leaf fields (and missing selections on objects),
+// unknown types and fragments, undefined and unused variables,
+// literals and variables of the wrong type, and required variables without a value.
+// Without a schema, this option doesn't have any effect.
+func WithQueryValidation() ServerOptions {
+	return func(s *server) {
+		s.validateQueries = true
+	}
+}
+
+// queryErrorBody is the response sent for queries rejected WithQueryValidation.
+// Since the query isn't executed, it doesn't have any data.
+type queryErrorBody struct {
+	Errors []queryError `json:"errors"`
+}
+
+// queryError is a problem found in a query.
+type queryError struct {
+	Message    string              `json:"message"`
+	Locations  []queryLocation     `json:"locations,omitempty"`
+	Extensions queryErrorExtension `json:"extensions"`
+}
+
+// queryLocation is the position of a problem in the query.
+type queryLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// queryErrorExtension are the extensions of a problem found in a query.
+type queryErrorExtension struct {
+	Code string `json:"code"`
+}
+
+// checkQuery validates the request's query against the schema, rejecting it if it's invalid.
+func (s *server) checkQuery(w http.ResponseWriter, req Request) bool {
+	if s.schema == nil || !s.validateQueries {
+		return true
+	}
+
+	var errs []queryError
+	doc, err := parseQuery(req.Query)
+	if err != nil {
+		errs = []queryError{{
+			Message:    "Syntax Error: " + err.Error(),
+			Extensions: queryErrorExtension{Code: queryParseCode},
+		}}
+	} else {
+		errs = s.schema.validateQuery(req.Query, doc, req.variables())
+	}
+
+	if len(errs) == 0 {
+		return true
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+	s.logger.Warn("goraphql_mock_server: query doesn't match the schema", "query", compactQuery(req.Query), "errors", messages)
+
+	respond(w, http.StatusBadRequest, queryErrorBody{Errors: errs})
+	return false
+}
+
+// validateQuery checks every operation in the document, and the request's variables, against the schema.
+// src is the document's source, used to locate the problems.
+func (sch *schema) validateQuery(src string, doc *document, variables map[string]any) []queryError {
+	v := &queryValidator{schema: sch, src: src, doc: doc}
+	for _, op := range doc.operations {
+		v.operation(op, variables)
+	}
+
+	return v.errors
+}
+
+// queryValidator checks a document against a schema.
+type queryValidator struct {
+	schema *schema
+	src    string
+	doc    *document
+	// Every problem found.
+	errors []queryError
+	// The operation being checked.
+	op *operation
+	// The variables used by the operation being checked.
+	used map[string]bool
+	// The fragments already checked for the operation being checked.
+	visited map[string]bool
+}
+
+// fail reports a problem at the byte offset in the document.
+func (v *queryValidator) fail(pos int, format string, args ...any) {
+	line, lineStart := 1, 0
+	for i := 0; i < pos && i < len(v.src); i++ {
+		if v.src[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+
+	v.errors = append(v.errors, queryError{
+		Message:    fmt.Sprintf(format, args...),
+		Locations:  []queryLocation{{Line: line, Column: utf8.RuneCountInString(v.src[lineStart:min(pos, len(v.src))]) + 1}},
+		Extensions: queryErrorExtension{Code: queryValidationCode},
+	})
+}
+
+// operation checks the operation and the values of its variables.
+func (v *queryValidator) operation(op *operation, variables map[string]any) {
+	v.op = op
+	v.used = make(map[string]bool)
+	v.visited = make(map[string]bool)
+
+	for _, def := range op.variables {
+		t, ok := v.schema.types[namedType(def.typ)]
+		if !ok {
+			v.fail(def.pos, "Unknown type %q.", namedType(def.typ))
+			continue
+		} else if t.kind != kindScalar && t.kind != kindEnum && t.kind != kindInputObject {
+			v.fail(def.pos, "Variable \"$%s\" cannot be non-input type \"%s\".", def.name, def.typ)
+			continue
+		}
+
+		if def.defaultValue != nil {
+			v.literal(def.typ, *def.defaultValue, def.pos)
+		}
+
+		val, ok := variables[def.name]
+		if (!ok || val == nil) && def.typ.nonNull && def.defaultValue == nil {
+			v.fail(def.pos, "Variable \"$%s\" of required type \"%s\" was not provided.", def.name, def.typ)
+		} else if ok && !v.variableValue(def.typ, val) {
+			v.fail(def.pos, "Variable \"$%s\" got invalid value %s; Expected type \"%s\".", def.name, encodeValue(val), def.typ)
+		}
+	}
+
+	root := v.schema.rootType(op.kind)
+	if root == nil {
+		v.fail(op.pos, "Schema is not configured to execute %s operation.", op.kind)
+		return
+	}
+
+	v.directives(op.directives, op.pos)
+	v.selections(root, op.selections)
+
+	for _, def := range op.variables {
+		if !v.used[def.name] {
+			if op.name != "" {
+				v.fail(def.pos, "Variable \"$%s\" is never used in operation \"%s\".", def.name, op.name)
+			} else {
+				v.fail(def.pos, "Variable \"$%s\" is never used.", def.name)
+			}
+		}
+	}
+}
+
+// selections checks the selections on a value of the (composite) type.
+func (v *queryValidator) selections(t *schemaType, sels []selection) {
+	for _, sel := range sels {
+		v.directives(sel.directives, sel.pos)
+
+		switch sel.kind {
+		case selectionField:
+			v.field(t, sel)
+		case selectionInlineFragment:
+			cond := t
+			if sel.typeCondition != "" {
+				var ok bool
+				if cond, ok = v.schema.types[sel.typeCondition]; !ok {
+					v.fail(sel.pos, "Unknown type %q.", sel.typeCondition)
+					continue
+				}
+			}
+			v.selections(cond, sel.selections)
+		case selectionFragmentSpread:
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.fail(sel.pos, "Unknown fragment %q.", sel.name)
+				continue
+			} else if v.visited[sel.name] {
+				continue
+			}
+			v.visited[sel.name] = true
+
+			cond, ok := v.schema.types[frag.typeCondition]
+			if !ok {
+				v.fail(sel.pos, "Unknown type %q.", frag.typeCondition)
+				continue
+			}
+			v.directives(frag.directives, sel.pos)
+			v.selections(cond, frag.selections)
+		}
+	}
+}
+
+// field checks a field selected on a value of the (composite) type.
+func (v *queryValidator) field(t *schemaType, sel selection) {
+	// Introspection fields aren't part of the schema.
+	if strings.HasPrefix(sel.name, "__") {
+		for _, arg := range sel.arguments {
+			v.literal(nil, arg.value, sel.pos)
+		}
+		return
+	}
+
+	def := t.field(sel.name)
+	if def == nil || t.kind == kindUnion {
+		v.fail(sel.pos, "Cannot query field %q on type %q.", sel.name, t.name)
+		return
+	}
+
+	v.arguments(t.name+"."+def.name, def.arguments, sel.arguments, sel.pos, func(arg string) string {
+		return fmt.Sprintf("Field %q argument %q", def.name, arg)
+	})
+
+	ft, ok := v.schema.types[namedType(def.typ)]
+	if !ok {
+		return
+	}
+
+	switch ft.kind {
+	case kindScalar, kindEnum:
+		if len(sel.selections) > 0 {
+			v.fail(sel.pos, "Field %q must not have a selection since type \"%s\" has no subfields.", sel.name, def.typ)
+		}
+	default:
+		if len(sel.selections) == 0 {
+			v.fail(sel.pos, "Field %q of type \"%s\" must have a selection of subfields. Did you mean \"%s { ... }\"?", sel.name, def.typ, sel.name)
+			return
+		}
+		v.selections(ft, sel.selections)
+	}
+}
+
+// directives checks the arguments of the directives applied at the byte offset.
+// Only the arguments of @skip and @include are known, so the arguments of other directives are only checked for variables.
+func (v *queryValidator) directives(dirs []directive, pos int) {
+	for _, dir := range dirs {
+		if dir.name != "skip" && dir.name != "include" {
+			for _, arg := range dir.arguments {
+				v.literal(nil, arg.value, pos)
+			}
+			continue
+		}
+
+		ifArg := &schemaField{name: "if", typ: &typeRef{name: "Boolean", nonNull: true}}
+		v.arguments("@"+dir.name, []*schemaField{ifArg}, dir.arguments, pos, func(arg string) string {
+			return fmt.Sprintf("Directive \"@%s\" argument %q", dir.name, arg)
+		})
+	}
+}
+
+// arguments checks the arguments given to the field (or directive) at the coordinate,
+// against the arguments it defines.
+// describe describes an argument in the errors for missing arguments.
+func (v *queryValidator) arguments(coordinate string, defs []*schemaField, args []argument, pos int, describe func(arg string) string) {
+	for _, arg := range args {
+		var def *schemaField
+		for _, d := range defs {
+			if d.name == arg.name {
+				def = d
+			}
+		}
+		if def == nil {
+			v.fail(pos, "Unknown argument %q on %q.", arg.name, coordinate)
+			v.literal(nil, arg.value, pos)
+			continue
+		}
+
+		v.literal(def.typ, arg.value, pos)
+	}
+
+	for _, def := range defs {
+		if !def.typ.nonNull || def.defaultValue != nil {
+			continue
+		}
+
+		provided := false
+		for _, arg := range args {
+			provided = provided || arg.name == def.name
+		}
+		if !provided {
+			v.fail(pos, "%s of type \"%s\" is required, but it was not provided.", describe(def.name), def.typ)
+		}
+	}
+}
+
+// literal checks a value written in the document, expected to be of the type.
+// If typ is nil, the value is only checked for undefined variables.
+func (v *queryValidator) literal(typ *typeRef, val value, pos int) {
+	if val.kind == valueVariable {
+		v.variable(typ, val.raw, pos)
+		return
+	}
+
+	// Custom scalars accept any value, and unknown types were already reported.
+	var t *schemaType
+	if typ != nil && typ.elem == nil {
+		var ok bool
+		if t, ok = v.schema.types[typ.name]; !ok || (t.kind == kindScalar && !slices.Contains(builtinScalars, t.name)) {
+			typ, t = nil, nil
+		}
+	}
+
+	switch {
+	case typ == nil:
+		for _, elem := range val.list {
+			v.literal(nil, elem, pos)
+		}
+		for _, field := range val.fields {
+			v.literal(nil, field.value, pos)
+		}
+	case val.kind == valueNull:
+		if typ.nonNull {
+			v.fail(pos, "Expected value of type \"%s\", found null.", typ)
+		}
+	case typ.elem != nil && val.kind == valueList:
+		for _, elem := range val.list {
+			v.literal(typ.elem, elem, pos)
+		}
+	case typ.elem != nil:
+		// Single values are coerced to lists.
+		v.literal(typ.elem, val, pos)
+	case val.kind == valueObject && t.kind == kindInputObject:
+		v.inputObject(t, val, pos)
+	case !validLiteral(t, val):
+		v.fail(pos, "Expected value of type \"%s\", found %s.", typ, printValue(val))
+		v.literal(nil, val, pos)
+	}
+}
+
+// inputObject checks an object written in the document, expected to be of the input object type.
+func (v *queryValidator) inputObject(t *schemaType, val value, pos int) {
+	for _, field := range val.fields {
+		def := t.field(field.name)
+		if def == nil {
+			v.fail(pos, "Field %q is not defined by type %q.", field.name, t.name)
+			v.literal(nil, field.value, pos)
+			continue
+		}
+
+		v.literal(def.typ, field.value, pos)
+	}
+
+	for _, def := range t.fields {
+		if !def.typ.nonNull || def.defaultValue != nil {
+			continue
+		}
+
+		provided := false
+		for _, field := range val.fields {
+			provided = provided || field.name == def.name
+		}
+		if !provided {
+			v.fail(pos, "Field \"%s.%s\" of required type \"%s\" was not provided.", t.name, def.name, def.typ)
+		}
+	}
+}
+
+// variable checks a variable used where a value of the type is expected.
+// If typ is nil, the variable is only checked for being defined.
+func (v *queryValidator) variable(typ *typeRef, name string, pos int) {
+	v.used[name] = true
+
+	var def *variableDefinition
+	for i := range v.op.variables {
+		if v.op.variables[i].name == name {
+			def = &v.op.variables[i]
+		}
+	}
+	if def == nil {
+		if v.op.name != "" {
+			v.fail(pos, "Variable \"$%s\" is not defined by operation %q.", name, v.op.name)
+		} else {
+			v.fail(pos, "Variable \"$%s\" is not defined.", name)
+		}
+		return
+	}
+
+	if typ != nil && !compatibleVariable(def.typ, def.defaultValue != nil, typ) {
+		v.fail(pos, "Variable \"$%s\" of type \"%s\" used in position expecting type \"%s\".", name, def.typ, typ)
+	}
+}
+
+// variableValue checks whether the (decoded) value of a variable is valid for the type.
+func (v *queryValidator) variableValue(typ *typeRef, val any) bool {
+	if val == nil {
+		return !typ.nonNull
+	}
+
+	if typ.elem != nil {
+		items, ok := val.([]any)
+		if !ok {
+			// Single values are coerced to lists.
+			return v.variableValue(typ.elem, val)
+		}
+
+		for _, item := range items {
+			if !v.variableValue(typ.elem, item) {
+				return false
+			}
+		}
+		return true
+	}
+
+	t, ok := v.schema.types[typ.name]
+	if !ok {
+		return true
+	}
+
+	switch t.kind {
+	case kindScalar:
+		return validScalar(t.name, val)
+	case kindEnum:
+		s, ok := val.(string)
+		return ok && slices.Contains(t.enumValues, s)
+	case kindInputObject:
+		obj, ok := val.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for key, fieldVal := range obj {
+			def := t.field(key)
+			if def == nil || !v.variableValue(def.typ, fieldVal) {
+				return false
+			}
+		}
+		for _, def := range t.fields {
+			if _, ok := obj[def.name]; !ok && def.typ.nonNull && def.defaultValue == nil {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// compatibleVariable checks whether a variable of the type may be used where a value of the expected type is expected.
+// Nullable variables with a default value may be used where non-null values are expected.
+func compatibleVariable(typ *typeRef, hasDefault bool, expected *typeRef) bool {
+	if expected.nonNull && !typ.nonNull {
+		if !hasDefault {
+			return false
+		}
+		return compatibleType(&typeRef{name: typ.name, elem: typ.elem, nonNull: true}, expected)
+	}
+
+	return compatibleType(typ, expected)
+}
+
+// compatibleType checks whether the type is a subtype of the expected type (e.g., "Int!" of "Int").
+func compatibleType(typ, expected *typeRef) bool {
+	if expected.nonNull && !typ.nonNull {
+		return false
+	}
+
+	if typ.elem != nil || expected.elem != nil {
+		return typ.elem != nil && expected.elem != nil && compatibleType(typ.elem, expected.elem)
+	}
+
+	return typ.name == expected.name
+}
+
+// validLiteral checks whether the literal (neither a list, an object, a variable nor null) is valid for the type.
+func validLiteral(t *schemaType, val value) bool {
+	switch t.kind {
+	case kindScalar:
+		switch t.name {
+		case "Int":
+			return val.kind == valueInt
+		case "Float":
+			return val.kind == valueInt || val.kind == valueFloat
+		case "String":
+			return val.kind == valueString
+		case "Boolean":
+			return val.kind == valueBoolean
+		case "ID":
+			return val.kind == valueString || val.kind == valueInt
+		default:
+			return true
+		}
+	case kindEnum:
+		return val.kind == valueEnum && slices.Contains(t.enumValues, val.raw)
+	default:
+		return false
+	}
+}
+
+// namedType retrieves the name of the type, unwrapping its lists.
+func namedType(typ *typeRef) string {
+	for typ.elem != nil {
+		typ = typ.elem
+	}
+
+	return typ.name
+}
+
+// printValue formats the value as in a GraphQL document.
+func printValue(val value) string {
+	switch val.kind {
+	case valueVariable:
+		return "$" + val.raw
+	case valueString:
+		return strconv.Quote(val.raw)
+	case valueList:
+		items := make([]string, 0, len(val.list))
+		for _, item := range val.list {
+			items = append(items, printValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case valueObject:
+		fields := make([]string, 0, len(val.fields))
+		for _, field := range val.fields {
+			fields = append(fields, field.name+": "+printValue(field.value))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	default:
+		return val.raw
+	}
+}
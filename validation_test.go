@@ -0,0 +1,448 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// validationSchema is the schema used to validate the mocks in the tests.
+const validationSchema = `
+type Query {
+	user(id: ID!): User
+	users(role: Role, first: Int = 10): [User!]!
+	node(id: ID!): Node
+}
+
+type Mutation {
+	deleteUser(id: ID!): Boolean!
+	createUser(input: CreateUserInput!): User
+}
+
+input CreateUserInput {
+	name: String!
+	role: Role
+}
+
+interface Node { id: ID! }
+
+enum Role { ADMIN USER }
+
+type User implements Node {
+	id: ID!
+	name: String!
+	age: Int
+	role: Role
+}
+
+type Post implements Node {
+	id: ID!
+	title: String
+}
+`
+
+// TestResponseValidation checks that responses that don't match the schema are rejected on registration.
+func TestResponseValidation(t *testing.T) {
+	type testCase struct {
+		// The type of the mocked operation.
+		op OperationType
+		// The mocked response.
+		response string
+		// The problems reported for the response. Empty if it's valid.
+		expected string
+	}
+
+	testCases := []testCase{{
+		op:       OperationQuery,
+		response: `{"user": {"id": "1", "name": "foo", "age": 30, "role": "ADMIN"}}`,
+	}, {
+		op:       OperationQuery,
+		response: `{"user": null, "users": [{"id": 1, "name": "foo"}]}`,
+	}, {
+		op:       OperationQuery,
+		response: `{"node": {"__typename": "Post", "id": "1", "title": "bar"}}`,
+	}, {
+		op:       OperationMutation,
+		response: `{"deleteUser": true}`,
+	}, {
+		op:       OperationQuery,
+		response: `{"user": {"id": "1", "fullName": "foo"}}`,
+		expected: "user.fullName: the type User doesn't define the field",
+	}, {
+		op:       OperationQuery,
+		response: `{"users": [{"id": "1", "name": null}]}`,
+		expected: "users.0.name: null for non-null type String!",
+	}, {
+		op:       OperationQuery,
+		response: `{"user": {"age": "30", "role": "OWNER"}}`,
+		expected: `user.age: "30" isn't a valid Int; user.role: "OWNER" isn't a valid Role`,
+	}, {
+		op:       OperationQuery,
+		response: `{"users": {"id": "1"}}`,
+		expected: "users: expected a list of type [User!]!, got an object",
+	}, {
+		op:       OperationQuery,
+		response: `{"node": {"__typename": "Role", "id": "1"}}`,
+		expected: "node.__typename: Role isn't a possible type of Node",
+	}, {
+		op:       OperationMutation,
+		response: `{"deleteUser": null}`,
+		expected: "deleteUser: null for non-null type Boolean!",
+	}}
+
+	for i, tc := range testCases {
+		s := New(
+			WithSchema(validationSchema),
+			WithResponseValidation(),
+			WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		)
+
+		register := s.RegisterQuery
+		if tc.op == OperationMutation {
+			register = s.RegisterMutation
+		}
+		mock := SimpleMockedRequest{StringResponse: StringResponse(tc.response)}
+
+		if tc.expected == "" {
+			assert.NotPanics(t, func() { register("op", mock) }, "test %d", i)
+		} else {
+			assert.PanicsWithValue(t, fmt.Sprintf("goraphql_mock_server: the response of 'op' doesn't match the schema: %s", tc.expected), func() {
+				register("op", mock)
+			}, "test %d", i)
+		}
+
+		s.Close()
+	}
+
+	// Without the option, the responses aren't validated.
+	s := New(WithSchema(validationSchema))
+	defer s.Close()
+	assert.NotPanics(t, func() {
+		s.RegisterQuery("op", SimpleMockedRequest{StringResponse: StringResponse(`{"unknown": 1}`)})
+	})
+}
+
+// TestQueryValidation checks that queries that aren't valid in the schema are rejected with spec-compliant errors.
+func TestQueryValidation(t *testing.T) {
+	type testCase struct {
+		// The query sent to the server.
+		query string
+		// The variables sent along with the query.
+		variables map[string]any
+		// The messages of the errors sent for the query. Empty if it's valid.
+		expected []string
+		// The location of the first error.
+		location queryLocation
+	}
+
+	testCases := []testCase{{
+		query: `query { user(id: "1") { id name ...on User { age } } }`,
+	}, {
+		query:     `query GetUsers($role: Role, $first: Int!) { users(role: $role, first: $first) { __typename id role } }`,
+		variables: map[string]any{"role": "ADMIN", "first": 10},
+	}, {
+		query:     `mutation Create($input: CreateUserInput!) { createUser(input: $input) { id } }`,
+		variables: map[string]any{"input": map[string]any{"name": "foo"}},
+	}, {
+		query:    "query {\n  user(id: \"1\") {\n    fullName\n  }\n}",
+		expected: []string{`Cannot query field "fullName" on type "User".`},
+		location: queryLocation{Line: 3, Column: 5},
+	}, {
+		query: `query { user { id } users(limit: 1) }`,
+		expected: []string{
+			`Field "user" argument "id" of type "ID!" is required, but it was not provided.`,
+			`Unknown argument "limit" on "Query.users".`,
+			`Field "users" of type "[User!]!" must have a selection of subfields. Did you mean "users { ... }"?`,
+		},
+		location: queryLocation{Line: 1, Column: 9},
+	}, {
+		query:    `query { user(id: "1") { name { first } } }`,
+		expected: []string{`Field "name" must not have a selection since type "String!" has no subfields.`},
+		location: queryLocation{Line: 1, Column: 25},
+	}, {
+		query:    `query GetUser($unused: ID) { user(id: $id) { id } }`,
+		expected: []string{`Variable "$id" is not defined by operation "GetUser".`, `Variable "$unused" is never used in operation "GetUser".`},
+		location: queryLocation{Line: 1, Column: 30},
+	}, {
+		query:     `query ($id: String, $first: Int!) { user(id: $id) { id } users(first: $first) { id } }`,
+		variables: map[string]any{"id": "1", "first": "10"},
+		expected: []string{
+			`Variable "$first" got invalid value "10"; Expected type "Int!".`,
+			`Variable "$id" of type "String" used in position expecting type "ID!".`,
+		},
+		location: queryLocation{Line: 1, Column: 21},
+	}, {
+		query:    `query { users(role: OWNER, first: "10") { id } }`,
+		expected: []string{`Expected value of type "Role", found OWNER.`, `Expected value of type "Int", found "10".`},
+		location: queryLocation{Line: 1, Column: 9},
+	}, {
+		query:    `mutation ($id: ID!) { deleteUser(id: $id) createUser(input: {role: ADMIN}) { id } }`,
+		expected: []string{`Variable "$id" of required type "ID!" was not provided.`, `Field "CreateUserInput.name" of required type "String!" was not provided.`},
+		location: queryLocation{Line: 1, Column: 11},
+	}, {
+		query:    `query { ...Missing node(id: 1) { ... on Comment { id } } }`,
+		expected: []string{`Unknown fragment "Missing".`, `Unknown type "Comment".`},
+		location: queryLocation{Line: 1, Column: 9},
+	}, {
+		query:    `subscription { userCreated { id } }`,
+		expected: []string{`Schema is not configured to execute subscription operation.`},
+		location: queryLocation{Line: 1, Column: 1},
+	}}
+
+	s := New(
+		WithSchema(validationSchema),
+		WithQueryValidation(),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	defer s.Close()
+
+	s.RegisterQuery("user", SimpleMockedRequest{StringResponse: StringResponse(`{"user": null}`)})
+	s.RegisterQuery("GetUsers", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"users": []}`),
+		KeyOnlyVariables: KeyOnlyVariables{"role", "first"},
+	})
+	s.RegisterMutation("Create", SimpleMockedRequest{
+		StringResponse:   StringResponse(`{"createUser": null}`),
+		KeyOnlyVariables: KeyOnlyVariables{"input"},
+	})
+
+	for i, tc := range testCases {
+		body, err := json.Marshal(Request{Query: tc.query, Variables: tc.variables})
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		resp, err := s.Client().Post(s.URL(), "application/json", strings.NewReader(string(body)))
+		if !assert.NoError(t, err, "test %d", i) {
+			continue
+		}
+
+		var res queryErrorBody
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		assert.NoError(t, err, "test %d", i)
+
+		if len(tc.expected) == 0 {
+			assert.Equal(t, http.StatusOK, resp.StatusCode, "test %d: %v", i, res.Errors)
+			continue
+		}
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "test %d", i)
+		var messages []string
+		for _, e := range res.Errors {
+			messages = append(messages, e.Message)
+			assert.Equal(t, queryValidationCode, e.Extensions.Code, "test %d", i)
+		}
+		assert.Equal(t, tc.expected, messages, "test %d", i)
+		if assert.NotEmpty(t, res.Errors, "test %d", i) {
+			assert.Equal(t, []queryLocation{tc.location}, res.Errors[0].Locations, "test %d", i)
+		}
+	}
+
+	var valid int
+	for _, req := range s.Requests() {
+		if !req.Rejected {
+			valid++
+		} else {
+			assert.Equal(t, http.StatusBadRequest, req.Response.Status, "unexpected status for '%s'", req.Query)
+		}
+	}
+	assert.Equal(t, 3, valid, "the invalid queries were matched")
+	assert.Empty(t, s.UnmatchedRequests(), "the invalid queries were reported as unmatched")
+}
+
+// TestStringResponseValidation checks that invalid StringResponses are reported as the mocks are registered.
+func TestStringResponseValidation(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	type testCase struct {
+		// The mock being registered.
+		mock MockedRequest
+		// Whether the mock's response is valid.
+		valid bool
+	}
+
+	testCases := []testCase{{
+		mock:  SimpleMockedRequest{StringResponse: `{"ListFoos": {"foo": 123}}`},
+		valid: true,
+	}, {
+		mock: SimpleMockedRequest{StringResponse: `{"ListFoos": `},
+	}, {
+		mock: &SimpleMockedRequest{StringResponse: `[1, 2]`},
+	}, {
+		mock: Match(nil).Respond(JSON(`{"ListFoos": {"foo": 123}`)),
+	}, {
+		mock:  Match(nil).Respond(RawResponse{Payload: "not an object"}),
+		valid: true,
+	}}
+
+	for i, tc := range testCases {
+		register := func() { s.RegisterQuery("ListFoos", tc.mock) }
+		if tc.valid {
+			assert.NotPanics(t, register, "test %d", i)
+		} else {
+			func() {
+				defer func() {
+					assert.Contains(t, fmt.Sprint(recover()), "goraphql_mock_server: the StringResponse of 'ListFoos' isn't a valid JSON object", "test %d", i)
+				}()
+				register()
+			}()
+		}
+	}
+}
+
+// TestRegisterE checks that invalid mocks are reported by RegisterQueryE and RegisterMutationE, without being registered.
+func TestRegisterE(t *testing.T) {
+	s := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	type testCase struct {
+		// Registers the mock, returning the error.
+		register func(srv Server, mock MockedRequest) error
+		// The mock being registered.
+		mock MockedRequest
+		// Whether the mock's response is valid.
+		valid bool
+	}
+
+	query := func(srv Server, mock MockedRequest) error {
+		return srv.RegisterQueryE("ListFoos", mock)
+	}
+	mutation := func(srv Server, mock MockedRequest) error {
+		return srv.RegisterMutationE("ListFoos", mock)
+	}
+	testCases := []testCase{{
+		register: query,
+		mock:     SimpleMockedRequest{StringResponse: `{"ListFoos": {"foo": 123}}`},
+		valid:    true,
+	}, {
+		register: query,
+		mock:     SimpleMockedRequest{StringResponse: `{"ListFoos": `},
+	}, {
+		register: mutation,
+		mock:     SimpleMockedRequest{StringResponse: `{"ListFoos": {"foo": 123}}`},
+		valid:    true,
+	}, {
+		register: mutation,
+		mock:     &SimpleMockedRequest{StringResponse: `[1, 2]`},
+	}}
+
+	for i, tc := range testCases {
+		for _, srv := range []Server{s, s.Scope(t)} {
+			registered := len(s.(*server).registrations["ListFoos"])
+
+			err := tc.register(srv, tc.mock)
+			if tc.valid {
+				assert.NoError(t, err, "test %d", i)
+				registered++
+			} else if assert.Error(t, err, "test %d", i) {
+				assert.Contains(t, err.Error(), "goraphql_mock_server: the StringResponse of 'ListFoos' isn't a valid JSON object", "test %d", i)
+			}
+			assert.Len(t, s.(*server).registrations["ListFoos"], registered, "test %d: unexpected registrations", i)
+		}
+	}
+}
+
+// TestSourcedMockValidation checks that mocks registered from definitions are validated like the ones registered directly.
+func TestSourcedMockValidation(t *testing.T) {
+	const (
+		schema  = `type Query { foo: Int }`
+		invalid = `[{"identifier": "foo", "response": {"foo": "bar"}}]`
+		message = "goraphql_mock_server: the response of 'foo' doesn't match the schema"
+	)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mocks.json")
+	err := os.WriteFile(path, []byte(invalid), 0o644)
+	if !assert.NoError(t, err, "failed to write the mocks") {
+		return
+	}
+
+	defs, err := decodeDefinitions([]byte(invalid))
+	if !assert.NoError(t, err, "failed to decode the mocks") {
+		return
+	}
+
+	logger := WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s := New(WithSchema(schema), WithResponseValidation(), WithAdmin("/__admin"), logger)
+	defer s.Close()
+
+	type testCase struct {
+		// Registers the invalid mocks, returning the error.
+		register func() error
+	}
+
+	testCases := []testCase{{
+		register: func() error { return s.RegisterDefinitions(defs...) },
+	}, {
+		register: func() error { return s.LoadMocks(path) },
+	}, {
+		register: func() error { return s.LoadMocksFS(os.DirFS(dir)) },
+	}, {
+		register: func() error { return s.Scope(t).RegisterDefinitions(defs...) },
+	}, {
+		register: func() error {
+			_, err := NewE(WithMockDirectory(dir, 0), WithSchema(schema), WithResponseValidation(), logger)
+			return err
+		},
+	}, {
+		register: func() error {
+			_, err := NewE(WithReplay(path, ReplayByContent), WithSchema(schema), WithResponseValidation(), logger)
+			return err
+		},
+	}}
+
+	for i, tc := range testCases {
+		err := tc.register()
+		if assert.Error(t, err, "test %d: the invalid mocks were accepted", i) {
+			assert.Contains(t, err.Error(), message, "test %d", i)
+		}
+		assert.Empty(t, s.(*server).registrations, "test %d: the invalid mocks were registered", i)
+	}
+
+	resp, err := http.Post(s.URL()+"/__admin/mocks", "application/json", strings.NewReader(invalid))
+	if assert.NoError(t, err, "failed to send the mocks to the admin API") {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "the admin API accepted the invalid mocks")
+	}
+	assert.Empty(t, s.(*server).registrations, "the invalid mocks were registered through the admin API")
+}
+
+// countingMock is a dynamic mock that counts how many times its response was generated.
+type countingMock struct {
+	KeyOnlyVariables
+	// How many times Response was called.
+	calls *int
+}
+
+// Response implements MockedRequest for countingMock.
+func (m countingMock) Response() any {
+	*m.calls++
+	return map[string]any{"foo": "not an int"}
+}
+
+// TestDynamicResponseValidation checks that dynamic responses aren't generated to be validated when they're registered.
+func TestDynamicResponseValidation(t *testing.T) {
+	s := New(WithSchema(`type Query { foo: Int }`), WithResponseValidation(), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer s.Close()
+
+	var calls int
+	err := s.RegisterQueryE("foo", countingMock{calls: &calls})
+	assert.NoError(t, err, "the dynamic mock was validated")
+	assert.Zero(t, calls, "the response was generated on registration")
+
+	s.RegisterQueryFunc("foo", func(req Request) (any, error) {
+		calls++
+		return nil, nil
+	})
+	assert.Zero(t, calls, "the function was called on registration")
+}
@@ -0,0 +1,147 @@
+package goraphql_mock_server
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState describes a file at a given moment, to detect modifications.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// WithMockDirectory registers every mock definition stored in the directory,
+// reloading them whenever a file in the directory is modified, added or removed.
+//
+// Each file must be in a format accepted by Server.LoadMocks.
+// Files are loaded in lexical order.
+//
+// The directory is checked for modifications at every interval.
+// If interval is zero, the directory is only loaded once.
+// If the directory fails to load while reloading,
+// the previously loaded mocks are kept and the error is logged (see WithLogger).
+func WithMockDirectory(dir string, interval time.Duration) ServerOptions {
+	return func(s *server) {
+		source := "dir:" + dir
+
+		state, err := s.loadDirectory(dir, source)
+		if err != nil {
+			s.fail(fmt.Errorf("goraphql_mock_server: failed to load mocks from %s: %w", dir, err))
+			return
+		}
+
+		if interval <= 0 {
+			return
+		}
+
+		// The directory is only watched once the server is completely set up (e.g., once its logger is set).
+		s.onStart = append(s.onStart, func() {
+			s.wg.Add(1)
+			go s.watchDirectory(dir, source, interval, state)
+		})
+	}
+}
+
+// watchDirectory reloads the mocks registered from source whenever the directory is modified,
+// checking it at every interval, until the server is closed.
+func (s *server) watchDirectory(dir, source string, interval time.Duration, state map[string]fileState) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := directoryState(dir)
+		if err != nil {
+			s.logger.Error("goraphql_mock_server: failed to check directory for modifications", "dir", dir, "error", err)
+			continue
+		} else if maps.Equal(state, cur) {
+			continue
+		}
+
+		state, err = s.loadDirectory(dir, source)
+		if err != nil {
+			s.logger.Error("goraphql_mock_server: failed to reload mocks", "dir", dir, "error", err)
+			state = cur
+		}
+	}
+}
+
+// loadDirectory replaces the mocks registered from source
+// by the ones defined in the directory, returning the state of the loaded files.
+func (s *server) loadDirectory(dir, source string) (map[string]fileState, error) {
+	state, err := directoryState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(state))
+	for name := range state {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mocks []sourcedMock
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		defs, err := decodeDefinitionFile(name, data)
+		if err != nil {
+			return nil, err
+		}
+
+		fileMocks, err := definitionMocks(defs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		mocks = append(mocks, fileMocks...)
+	}
+
+	if err := s.replaceSource(source, mocks); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// directoryState retrieves the state of every mock definition file in the directory.
+func directoryState(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]fileState)
+	for _, entry := range entries {
+		if entry.IsDir() || !isDefinitionFile(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		state[entry.Name()] = fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+	}
+
+	return state, nil
+}
@@ -0,0 +1,121 @@
+package goraphql_mock_server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMockDirectory checks that mocks are loaded from a directory and reloaded on modifications.
+func TestMockDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list_foos.json")
+
+	writeMock := func(foo int) {
+		data := []byte(`{
+			"identifier": "ListFoos",
+			"response": {"ListFoos": {"foo": ` + strconv.Itoa(foo) + `}}
+		}`)
+
+		err := os.WriteFile(path, data, 0o644)
+		assert.NoError(t, err, "failed to write the mock")
+	}
+
+	// getFoo sends a query, returning the received foo or -1 on errors.
+	getFoo := func(client *graphql.Client) int {
+		var resp struct {
+			ListFoos struct {
+				Foo int `json:"foo"`
+			} `json:"ListFoos"`
+		}
+
+		err := client.Run(context.Background(), graphql.NewRequest("query { ListFoos { foo } }"), &resp)
+		if err != nil {
+			return -1
+		}
+
+		return resp.ListFoos.Foo
+	}
+
+	writeMock(1)
+
+	s := New(WithMockDirectory(dir, 10*time.Millisecond))
+	defer s.Close()
+
+	client := graphql.NewClient(s.URL())
+	assert.Equal(t, 1, getFoo(client), "the mock wasn't loaded from the directory")
+
+	// Rewrite the mock, waiting for it to be reloaded.
+	writeMock(2)
+	assert.Eventually(t, func() bool {
+		return getFoo(client) == 2
+	}, time.Second, 10*time.Millisecond, "the mock wasn't reloaded")
+
+	// An invalid file must keep the previous mocks.
+	err := os.WriteFile(filepath.Join(dir, "invalid.json"), []byte("{"), 0o644)
+	assert.NoError(t, err, "failed to write the invalid mock")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, getFoo(client), "the mock was unloaded after an invalid modification")
+
+	// Removing every file must unregister the mocks.
+	assert.NoError(t, os.Remove(filepath.Join(dir, "invalid.json")), "failed to remove the invalid mock")
+	assert.NoError(t, os.Remove(path), "failed to remove the mock")
+	assert.Eventually(t, func() bool {
+		return getFoo(client) == -1
+	}, time.Second, 10*time.Millisecond, "the mock wasn't unregistered")
+}
+
+// TestMockDirectoryLogger checks that the directory is only watched once the server is set up,
+// so errors found while watching it are logged by the logger set after WithMockDirectory.
+func TestMockDirectoryLogger(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mocks")
+	assert.NoError(t, os.Mkdir(dir, 0o755), "failed to create the directory")
+
+	var logs syncBuffer
+	s := New(
+		WithMockDirectory(dir, time.Millisecond),
+		func(s *server) {
+			// Give the watcher a chance to check the missing directory before the logger is set.
+			assert.NoError(t, os.Remove(dir), "failed to remove the directory")
+			time.Sleep(10 * time.Millisecond)
+		},
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	defer s.Close()
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "failed to check directory for modifications")
+	}, time.Second, 10*time.Millisecond, "the error wasn't logged")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use, receiving logs written by the server's goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer for syncBuffer.
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// String returns everything written so far.
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
@@ -0,0 +1,173 @@
+package goraphql_mock_server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// wireMockMappings is a file with multiple WireMock stub mappings.
+type wireMockMappings struct {
+	Mappings []wireMockMapping `json:"mappings"`
+}
+
+// wireMockMapping is a single WireMock stub mapping.
+type wireMockMapping struct {
+	Name     string           `json:"name"`
+	Request  wireMockRequest  `json:"request"`
+	Response wireMockResponse `json:"response"`
+}
+
+// wireMockRequest is the request matched by a WireMock stub mapping.
+type wireMockRequest struct {
+	Method       string                `json:"method"`
+	BodyPatterns []wireMockBodyPattern `json:"bodyPatterns"`
+}
+
+// wireMockBodyPattern is a pattern matched against the request's body.
+// Only the patterns that may be converted into a MockDefinition are decoded.
+type wireMockBodyPattern struct {
+	EqualToJSON json.RawMessage `json:"equalToJson"`
+	Contains    string          `json:"contains"`
+}
+
+// wireMockResponse is the response sent by a WireMock stub mapping.
+type wireMockResponse struct {
+	Status   int             `json:"status"`
+	Body     string          `json:"body"`
+	JSONBody json.RawMessage `json:"jsonBody"`
+}
+
+// ImportWireMock converts WireMock stub mappings into mock definitions,
+// which may then be registered with Server.RegisterDefinitions.
+//
+// r may contain either a single stub mapping or an object with a list of "mappings".
+// Each mapping must match a POST request with either:
+//
+//   - an "equalToJson" body pattern, whose query is used as the identifier
+//     and whose variables, if any, are matched exactly;
+//   - a "contains" body pattern, whose value is used as the identifier.
+//
+// The mapping's response must be a successful GraphQL response,
+// either in "jsonBody" or in "body", whose "data" is used as the mocked response.
+// Mappings that can't be converted cause the import to fail.
+func ImportWireMock(r io.Reader) ([]MockDefinition, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: read WireMock mappings: %w", err)
+	}
+
+	var doc struct {
+		wireMockMappings
+		wireMockMapping
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("goraphql_mock_server: decode WireMock mappings: %w", err)
+	}
+
+	mappings := doc.Mappings
+	if mappings == nil {
+		mappings = []wireMockMapping{doc.wireMockMapping}
+	}
+
+	defs := make([]MockDefinition, 0, len(mappings))
+	for i, mapping := range mappings {
+		def, err := mapping.definition()
+		if err != nil {
+			name := mapping.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+
+			return nil, fmt.Errorf("goraphql_mock_server: WireMock mapping %s: %w", name, err)
+		}
+
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
+
+// definition converts the stub mapping into a MockDefinition.
+func (m wireMockMapping) definition() (MockDefinition, error) {
+	var def MockDefinition
+
+	if m.Request.Method != "" && m.Request.Method != http.MethodPost && m.Request.Method != "ANY" {
+		return def, fmt.Errorf("unsupported method '%s'", m.Request.Method)
+	} else if len(m.Request.BodyPatterns) != 1 {
+		return def, errors.New("expected exactly one body pattern")
+	}
+
+	pattern := m.Request.BodyPatterns[0]
+	switch {
+	case len(pattern.EqualToJSON) > 0:
+		req, err := decodeEmbeddedJSON[Request](pattern.EqualToJSON)
+		if err != nil {
+			return def, fmt.Errorf("invalid equalToJson: %w", err)
+		}
+
+		def.Identifier = req.Query
+		if len(req.Variables) > 0 {
+			def.Variables = &VariablesDefinition{
+				Match:  MatchExact,
+				Values: req.Variables,
+			}
+		}
+	case pattern.Contains != "":
+		def.Identifier = pattern.Contains
+	default:
+		return def, errors.New("unsupported body pattern")
+	}
+
+	if op, ok := operationType(def.Identifier); ok {
+		def.Operation = op
+	}
+
+	if m.Response.Status != 0 && m.Response.Status != http.StatusOK {
+		return def, fmt.Errorf("unsupported response status %d", m.Response.Status)
+	}
+
+	body := m.Response.JSONBody
+	if len(body) == 0 {
+		body = json.RawMessage(m.Response.Body)
+	}
+
+	data, err := responseData(body)
+	if err != nil {
+		return def, err
+	}
+
+	def.Response = data
+	return def, nil
+}
+
+// responseData extracts the data from the body of a successful GraphQL response.
+func responseData(body []byte) (json.RawMessage, error) {
+	var res struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []ResponseError `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("invalid response body: %w", err)
+	} else if len(res.Errors) > 0 {
+		return nil, errors.New("responses with errors aren't supported")
+	}
+
+	return res.Data, nil
+}
+
+// decodeEmbeddedJSON decodes data into a T,
+// accepting both a JSON object and a string containing a JSON object.
+func decodeEmbeddedJSON[T any](data json.RawMessage) (T, error) {
+	var v T
+
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		data = json.RawMessage(str)
+	}
+
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
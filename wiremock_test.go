@@ -0,0 +1,78 @@
+package goraphql_mock_server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestImportWireMock checks that WireMock stub mappings are converted into working mocks.
+func TestImportWireMock(t *testing.T) {
+	const query = `query ($num:Integer!) { ListFoos(num:$num) { foo } }`
+
+	const mappings = `{
+		"mappings": [{
+			"name": "exact ListFoos",
+			"request": {
+				"method": "POST",
+				"url": "/graphql",
+				"bodyPatterns": [{
+					"equalToJson": "{\"query\": \"query ($num:Integer!) { ListFoos(num:$num) { foo } }\", \"variables\": {\"num\": 1}}"
+				}]
+			},
+			"response": {
+				"status": 200,
+				"jsonBody": {"data": {"ListFoos": {"foo": 123}}}
+			}
+		}, {
+			"request": {
+				"method": "POST",
+				"bodyPatterns": [{"contains": "CreateFoo"}]
+			},
+			"response": {
+				"body": "{\"data\": {\"CreateFoo\": {\"id\": \"abc\"}}}"
+			}
+		}]
+	}`
+
+	defs, err := ImportWireMock(strings.NewReader(mappings))
+	if !assert.NoError(t, err, "failed to import the mappings") || !assert.Len(t, defs, 2, "unexpected number of mappings") {
+		return
+	}
+	assert.Equal(t, query, defs[0].Identifier, "unexpected identifier for the exact mapping")
+	assert.Equal(t, "CreateFoo", defs[1].Identifier, "unexpected identifier for the contains mapping")
+
+	s := New()
+	defer s.Close()
+
+	err = s.RegisterDefinitions(defs...)
+	if !assert.NoError(t, err, "failed to register the mappings") {
+		return
+	}
+
+	client := graphql.NewClient(s.URL())
+
+	req := graphql.NewRequest(query)
+	req.Var("num", 1)
+
+	var resp map[string]any
+	err = client.Run(context.Background(), req, &resp)
+	if assert.NoError(t, err, "failed to send the query") {
+		assert.Equal(t, map[string]any{"ListFoos": map[string]any{"foo": 123.0}}, resp, "unexpected response")
+	}
+
+	resp = nil
+	err = client.Run(context.Background(), graphql.NewRequest(`query { CreateFoo { id } }`), &resp)
+	if assert.NoError(t, err, "failed to send the second query") {
+		assert.Equal(t, map[string]any{"CreateFoo": map[string]any{"id": "abc"}}, resp, "unexpected response")
+	}
+
+	_, err = ImportWireMock(strings.NewReader(`{
+		"request": {"method": "GET", "bodyPatterns": [{"contains": "ListFoos"}]},
+		"response": {"jsonBody": {"data": {}}}
+	}`))
+	assert.Error(t, err, "a GET mapping was imported")
+}